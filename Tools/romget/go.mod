@@ -1,3 +1,7 @@
 module github.com/emubuddy/romget
 
 go 1.21
+
+require github.com/emubuddy/download v0.0.0
+
+replace github.com/emubuddy/download => ../../pkg/download
@@ -26,7 +26,7 @@ cd ../..
 # Build Installer
 echo "[2/2] Building macOS Installer..."
 cd installer
-CGO_ENABLED=0 go build -ldflags="-s -w" -o ../EmuBuddySetup-macos main.go
+CGO_ENABLED=0 go build -ldflags="-s -w" -o ../EmuBuddySetup-macos .
 echo "  [OK] EmuBuddySetup-macos"
 cd ..
 
@@ -25,7 +25,7 @@ mkdir -p dist
 # ==========================================
 echo "Building Installer..."
 cd installer
-CGO_ENABLED=0 go build -ldflags="-s -w" -o ../EmuBuddySetup-macos main.go
+CGO_ENABLED=0 go build -ldflags="-s -w" -o ../EmuBuddySetup-macos .
 echo "  [OK] EmuBuddySetup-macos"
 cd ..
 
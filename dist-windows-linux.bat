@@ -31,14 +31,14 @@ set CGO_ENABLED=0
 echo   Windows...
 set GOOS=windows
 set GOARCH=amd64
-go build -ldflags="-s -w" -o ..\EmuBuddySetup.exe main.go
+go build -ldflags="-s -w" -o ..\EmuBuddySetup.exe .
 if %ERRORLEVEL% NEQ 0 (echo   [FAILED] & goto :error)
 echo   [OK] EmuBuddySetup.exe
 
 echo   Linux...
 set GOOS=linux
 set GOARCH=amd64
-go build -ldflags="-s -w" -o ..\EmuBuddySetup-linux main.go
+go build -ldflags="-s -w" -o ..\EmuBuddySetup-linux .
 if %ERRORLEVEL% NEQ 0 (echo   [FAILED] & goto :error)
 echo   [OK] EmuBuddySetup-linux
 
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// catalogFileName is the optional catalog next to the installer binary. It
+// overrides the built-in emulator list, cores and BIOS URLs without a rebuild.
+const catalogFileName = "emulators.json"
+
+// catalogFile is the on-disk shape of emulators.json. Every section is
+// optional; emulators and additional cores are merged into the built-in
+// lists by ID/name, so a file can bump a single emulator.
+type catalogFile struct {
+	Emulators            []json.RawMessage `json:"emulators,omitempty"`
+	RetroArchCores       *EmulatorURL      `json:"retroarchCores,omitempty"`
+	RetroArchCoresSHA256 map[string]string `json:"retroarchCoresSHA256,omitempty"`
+	AdditionalCores      []json.RawMessage `json:"additionalCores,omitempty"`
+	RetroArchBIOSURL     string            `json:"retroarchBIOSURL,omitempty"`
+	PS2BIOSURL           string            `json:"ps2BIOSURL,omitempty"`
+}
+
+// catalogPlatforms are the keys used for per-platform maps (archiveName, sha256, executable)
+var catalogPlatforms = []string{"windows", "linux", "darwin"}
+
+// catalogPath returns where emulators.json is looked for: next to the executable
+func catalogPath() (string, error) {
+	exePath, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(exePath), catalogFileName), nil
+}
+
+// loadCatalog applies emulators.json, if present, to the built-in catalog.
+// It returns the problems found; malformed entries are skipped (keeping the
+// built-in entry of the same ID, if any) and a file that isn't valid JSON is
+// ignored entirely.
+func loadCatalog() (loaded bool, problems []string) {
+	path, err := catalogPath()
+	if err != nil {
+		return false, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, []string{fmt.Sprintf("%s: %v (using built-in catalog)", catalogFileName, err)}
+	}
+
+	var file catalogFile
+	if err := decodeStrict(data, &file); err != nil {
+		return false, []string{fmt.Sprintf("%s: %v (using built-in catalog)", catalogFileName, err)}
+	}
+
+	seen := make(map[string]bool)
+	for i, raw := range file.Emulators {
+		var emu Emulator
+		err := decodeStrict(raw, &emu)
+		if err == nil {
+			err = validateEmulator(emu)
+		}
+		if err == nil && seen[strings.ToLower(emu.ID)] {
+			err = fmt.Errorf("duplicate id %q", emu.ID)
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: emulators[%d]%s: %v", catalogFileName, i, entryLabel(emu.ID), err))
+			continue
+		}
+		seen[strings.ToLower(emu.ID)] = true
+		mergeEmulator(emu)
+	}
+
+	if file.RetroArchCores != nil {
+		if err := validateURLs(*file.RetroArchCores); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: retroarchCores: %v", catalogFileName, err))
+		} else {
+			retroarchCores = *file.RetroArchCores
+		}
+	}
+	if file.RetroArchCoresSHA256 != nil {
+		if err := validateSHA256(file.RetroArchCoresSHA256); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: retroarchCoresSHA256: %v", catalogFileName, err))
+		} else {
+			retroarchCoresSHA256 = file.RetroArchCoresSHA256
+		}
+	}
+
+	for i, raw := range file.AdditionalCores {
+		var core RetroArchCore
+		err := decodeStrict(raw, &core)
+		if err == nil {
+			err = validateCore(core)
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: additionalCores[%d]%s: %v", catalogFileName, i, entryLabel(core.Name), err))
+			continue
+		}
+		mergeCore(core)
+	}
+
+	if file.RetroArchBIOSURL != "" {
+		if err := validateURL(file.RetroArchBIOSURL); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: retroarchBIOSURL: %v", catalogFileName, err))
+		} else {
+			retroarchBIOSURL = file.RetroArchBIOSURL
+		}
+	}
+	if file.PS2BIOSURL != "" {
+		if err := validateURL(file.PS2BIOSURL); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: ps2BIOSURL: %v", catalogFileName, err))
+		} else {
+			ps2BIOSURL = file.PS2BIOSURL
+		}
+	}
+
+	return true, problems
+}
+
+// decodeStrict unmarshals JSON, rejecting unknown fields so typos are reported
+// instead of silently ignored
+func decodeStrict(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+func entryLabel(id string) string {
+	if id == "" {
+		return ""
+	}
+	return " (" + id + ")"
+}
+
+// mergeEmulator replaces the built-in emulator with the same ID, or appends it
+func mergeEmulator(emu Emulator) {
+	for i := range emulators {
+		if strings.EqualFold(emulators[i].ID, emu.ID) {
+			emulators[i] = emu
+			return
+		}
+	}
+	emulators = append(emulators, emu)
+}
+
+// mergeCore replaces the built-in additional core with the same name, or appends it
+func mergeCore(core RetroArchCore) {
+	for i := range additionalCores {
+		if strings.EqualFold(additionalCores[i].Name, core.Name) {
+			additionalCores[i] = core
+			return
+		}
+	}
+	additionalCores = append(additionalCores, core)
+}
+
+func validateEmulator(emu Emulator) error {
+	if emu.ID == "" {
+		return fmt.Errorf("missing id")
+	}
+	if strings.ContainsAny(emu.ID, ", \t") {
+		return fmt.Errorf("id %q can't contain spaces or commas", emu.ID)
+	}
+	if emu.Name == "" {
+		return fmt.Errorf("missing name")
+	}
+	if emu.ExtractDir == "" {
+		return fmt.Errorf("missing extractDir")
+	}
+	if dir := filepath.Clean(emu.ExtractDir); filepath.IsAbs(dir) || dir == ".." || strings.HasPrefix(dir, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("extractDir %q must be a folder name under Emulators", emu.ExtractDir)
+	}
+	if err := validateURLs(emu.URLs); err != nil {
+		return err
+	}
+	for _, platform := range catalogPlatforms {
+		if getURLForPlatform(emu.URLs, platform) == "" {
+			continue
+		}
+		name := emu.ArchiveName[platform]
+		if name == "" {
+			return fmt.Errorf("missing archiveName for %s", platform)
+		}
+		if name != filepath.Base(name) || strings.ContainsAny(name, `/\`) {
+			return fmt.Errorf("archiveName %q for %s must be a file name", name, platform)
+		}
+	}
+	if err := validateExecutable(emu.Executable); err != nil {
+		return err
+	}
+	return validateSHA256(emu.SHA256)
+}
+
+func validateCore(core RetroArchCore) error {
+	if core.Name == "" {
+		return fmt.Errorf("missing name")
+	}
+	if err := validateURLs(core.URLs); err != nil {
+		return err
+	}
+	return validateSHA256(core.SHA256)
+}
+
+// validateURLs requires at least one platform URL and that each set one is http(s)
+func validateURLs(urls EmulatorURL) error {
+	found := false
+	for _, platform := range catalogPlatforms {
+		url := getURLForPlatform(urls, platform)
+		if url == "" {
+			continue
+		}
+		found = true
+		if err := validateURL(url); err != nil {
+			return fmt.Errorf("%s URL: %v", platform, err)
+		}
+	}
+	if !found {
+		return fmt.Errorf("no URL for any platform")
+	}
+	return nil
+}
+
+func validateURL(url string) error {
+	if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+		return fmt.Errorf("%q is not an http(s) URL", url)
+	}
+	return nil
+}
+
+func validateSHA256(hashes map[string]string) error {
+	for platform, sum := range hashes {
+		if !isKnownPlatform(platform) {
+			return fmt.Errorf("sha256 has unknown platform %q (use windows, linux or darwin)", platform)
+		}
+		if len(sum) != 64 || strings.Trim(strings.ToLower(sum), "0123456789abcdef") != "" {
+			return fmt.Errorf("sha256 for %s is not a 64-character hex hash", platform)
+		}
+	}
+	return nil
+}
+
+// validateExecutable checks the executable patterns are valid globs inside
+// the emulator's folder
+func validateExecutable(executables map[string][]string) error {
+	for platform, patterns := range executables {
+		if !isKnownPlatform(platform) {
+			return fmt.Errorf("executable has unknown platform %q (use windows, linux or darwin)", platform)
+		}
+		for _, pattern := range patterns {
+			clean := filepath.Clean(filepath.FromSlash(pattern))
+			if pattern == "" || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+				return fmt.Errorf("executable %q for %s must be a path inside extractDir", pattern, platform)
+			}
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return fmt.Errorf("executable %q for %s is not a valid pattern", pattern, platform)
+			}
+		}
+	}
+	return nil
+}
+
+func isKnownPlatform(platform string) bool {
+	for _, p := range catalogPlatforms {
+		if p == platform {
+			return true
+		}
+	}
+	return false
+}
+
+// writeCatalog saves the built-in catalog as emulators.json, as a starting
+// point for editing. It won't overwrite an existing file.
+func writeCatalog() error {
+	path, err := catalogPath()
+	if err != nil {
+		return err
+	}
+	if fileExists(path) {
+		return fmt.Errorf("%s already exists", path)
+	}
+
+	cores := retroarchCores
+	file := struct {
+		Emulators            []Emulator        `json:"emulators"`
+		RetroArchCores       *EmulatorURL      `json:"retroarchCores"`
+		RetroArchCoresSHA256 map[string]string `json:"retroarchCoresSHA256,omitempty"`
+		AdditionalCores      []RetroArchCore   `json:"additionalCores"`
+		RetroArchBIOSURL     string            `json:"retroarchBIOSURL"`
+		PS2BIOSURL           string            `json:"ps2BIOSURL"`
+	}{emulators, &cores, retroarchCoresSHA256, additionalCores, retroarchBIOSURL, ps2BIOSURL}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	printSuccess("Wrote " + path)
+	return nil
+}
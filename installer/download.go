@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"github.com/emubuddy/download"
+)
+
+// Downloads go through the shared download package, as in the launcher and
+// romget. Large files (the RetroArch cores pack, emulator archives) are split
+// across several connections when the server supports ranged requests; small
+// ones like single core zips use one stream. The connection count
+// (-connections) defaults low so rate-limited hosts aren't hammered; 1 turns
+// parallel downloads off.
+
+const (
+	defaultConnections = 2
+	maxConnections     = download.MaxWorkers
+	defaultRetries     = 3
+	// parallelMinSize is the smallest file split across connections
+	parallelMinSize = 32 * 1024 * 1024
+	// installerUserAgent avoids the rate limiting some hosts apply to Go's
+	installerUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+)
+
+var (
+	// downloadConnections (-connections) is how many ranges of a large file
+	// are downloaded at once
+	downloadConnections = defaultConnections
+	// downloadRetries (-retries) is how many times a failed download, or
+	// range of one, is tried again before giving up
+	downloadRetries = defaultRetries
+	// downloadTransport carries every request; nil until -proxy is read,
+	// which leaves Go's default (honouring HTTP_PROXY/HTTPS_PROXY)
+	downloadTransport http.RoundTripper
+	// downloadCtx is cancelled by Ctrl+C (see watchInterrupt), which aborts
+	// the request in flight even while the connection is stalled
+	downloadCtx = context.Background()
+)
+
+// setProxy makes downloads go through proxy (-proxy) instead of the one
+// HTTP_PROXY/HTTPS_PROXY name, if any
+func setProxy(proxy string) error {
+	transport, err := download.NewTransport(proxy)
+	if err != nil {
+		return err
+	}
+	downloadTransport = transport
+	return nil
+}
+
+// watchInterrupt makes Ctrl+C cancel downloadCtx rather than kill the
+// installer outright, so an interrupted download saves its progress first
+func watchInterrupt() {
+	downloadCtx, _ = signal.NotifyContext(context.Background(), os.Interrupt)
+}
+
+// exitInterrupted ends the installer after Ctrl+C stopped a download
+func exitInterrupted() {
+	fmt.Println()
+	printWarning("Cancelled. Partly downloaded files are kept and resume the next time the installer runs.")
+	os.Exit(exitFailed)
+}
+
+// downloadFileWithReferer downloads a file with an optional Referer header.
+// Data goes to destPath+".part" first and is kept if the download fails, so
+// running the installer again resumes it.
+func downloadFileWithReferer(url, destPath, referer string) error {
+	progress := &downloadProgress{lastPrint: time.Now()}
+	err := download.Download(downloadCtx, url, destPath, download.Options{
+		Referer:         referer,
+		UserAgent:       installerUserAgent,
+		Retries:         downloadRetries,
+		Workers:         downloadConnections,
+		MinParallelSize: parallelMinSize,
+		Resume:          true,
+		Progress:        progress.update,
+		Logf: func(format string, args ...interface{}) {
+			progress.endLine()
+			printInfo("  " + fmt.Sprintf(format, args...))
+		},
+		Client: &http.Client{Timeout: 30 * time.Minute, Transport: downloadTransport},
+	})
+	progress.endLine()
+	if downloadCtx.Err() != nil {
+		exitInterrupted()
+	}
+	return err
+}
+
+// downloadProgress prints a download's progress once a second. Parallel
+// ranges report from several goroutines.
+type downloadProgress struct {
+	mu        sync.Mutex
+	lastPrint time.Time
+	printed   bool
+}
+
+func (p *downloadProgress) update(downloaded, total int64) {
+	if quietMode {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if time.Since(p.lastPrint) < time.Second {
+		return
+	}
+	if total > 0 {
+		pct := float64(downloaded) / float64(total) * 100
+		fmt.Printf("\r  Progress: %.1f%% (%s / %s)", pct, formatBytes(downloaded), formatBytes(total))
+	} else {
+		fmt.Printf("\r  Downloaded: %s", formatBytes(downloaded))
+	}
+	p.lastPrint = time.Now()
+	p.printed = true
+}
+
+// endLine finishes the progress line, if one was printed
+func (p *downloadProgress) endLine() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.printed {
+		fmt.Println()
+		p.printed = false
+	}
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// encryptedZip returns a zip holding one entry as an encryptor would write
+// it: AES ones with method 99 and the AE-x extra field, ZipCrypto ones with
+// only the encrypted flag. The contents are random bytes, which is all the
+// check needs.
+func encryptedZip(t *testing.T, aes bool) []byte {
+	t.Helper()
+	header := &zip.FileHeader{Name: "emulator.exe", Method: zip.Deflate, Flags: 0x1, CompressedSize64: 16, UncompressedSize64: 16}
+	if aes {
+		// AE-2, AES-256, the real method deflate
+		header.Method = zipMethodAES
+		header.Extra = []byte{0x01, 0x99, 7, 0, 2, 0, 'A', 'E', 3, byte(zip.Deflate), 0}
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.CreateRaw(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(bytes.Repeat([]byte{0x5a}, 16))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// zip64Zip returns a zip holding one stored entry whose sizes and offset are
+// in a Zip64 extra field, as they are for entries over 4GB. archive/zip only
+// writes that for files that size, so it's put together by hand.
+func zip64Zip(name, body string) []byte {
+	var buf bytes.Buffer
+	le := binary.LittleEndian
+	crc := crc32.ChecksumIEEE([]byte(body))
+	size := uint64(len(body))
+	extra := func(offset bool) []byte {
+		b := []byte{0x01, 0x00, 16, 0}
+		b = le.AppendUint64(le.AppendUint64(b, size), size)
+		if offset {
+			b[2] = 24
+			b = le.AppendUint64(b, 0)
+		}
+		return b
+	}
+
+	// Local file header
+	local := extra(false)
+	for _, v := range []interface{}{uint32(0x04034b50), uint16(45), uint16(0), uint16(zip.Store), uint16(0), uint16(0x21),
+		crc, uint32(0xffffffff), uint32(0xffffffff), uint16(len(name)), uint16(len(local))} {
+		binary.Write(&buf, le, v)
+	}
+	buf.WriteString(name)
+	buf.Write(local)
+	buf.WriteString(body)
+
+	// Central directory
+	dirOffset := buf.Len()
+	central := extra(true)
+	for _, v := range []interface{}{uint32(0x02014b50), uint16(45), uint16(45), uint16(0), uint16(zip.Store), uint16(0), uint16(0x21),
+		crc, uint32(0xffffffff), uint32(0xffffffff), uint16(len(name)), uint16(len(central)), uint16(0), uint16(0), uint16(0), uint32(0), uint32(0xffffffff)} {
+		binary.Write(&buf, le, v)
+	}
+	buf.WriteString(name)
+	buf.Write(central)
+	dirSize := buf.Len() - dirOffset
+
+	// End of central directory
+	for _, v := range []interface{}{uint32(0x06054b50), uint16(0), uint16(0), uint16(1), uint16(1), uint32(dirSize), uint32(dirOffset), uint16(0)} {
+		binary.Write(&buf, le, v)
+	}
+	return buf.Bytes()
+}
+
+func TestCheckZipEntries(t *testing.T) {
+	tests := []struct {
+		desc    string
+		archive []byte
+		want    string // "" when the archive can be extracted
+	}{
+		{"AES", encryptedZip(t, true), "archive is password protected (AES encryption): emulator.exe"},
+		{"ZipCrypto", encryptedZip(t, false), "archive is password protected (ZipCrypto encryption): emulator.exe"},
+		{"Zip64", zip64Zip("emulator.exe", "exe"), ""},
+	}
+	for _, tt := range tests {
+		r, err := zip.NewReader(bytes.NewReader(tt.archive), int64(len(tt.archive)))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.desc, err)
+		}
+		err = checkZipEntries(r.File)
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("%s: %v", tt.desc, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("%s: got %v, want %q", tt.desc, err, tt.want)
+		}
+	}
+}
+
+func TestExtractZipEncryptedAndZip64(t *testing.T) {
+	for _, aes := range []bool{true, false} {
+		zipPath := filepath.Join(t.TempDir(), "emulator.zip")
+		os.WriteFile(zipPath, encryptedZip(t, aes), 0644)
+		destDir := filepath.Join(t.TempDir(), "dest")
+
+		err := extractZip(zipPath, destDir)
+		if err == nil || !strings.HasPrefix(err.Error(), "emulator.zip: archive is password protected") {
+			t.Errorf("extractZip of an encrypted zip (AES %v): got %v, want the password protected error", aes, err)
+		}
+		if entries, _ := os.ReadDir(destDir); len(entries) > 0 {
+			t.Errorf("extractZip of an encrypted zip (AES %v) wrote %s", aes, entries[0].Name())
+		}
+	}
+
+	zipPath := filepath.Join(t.TempDir(), "emulator.zip")
+	os.WriteFile(zipPath, zip64Zip("emulator.exe", "exe"), 0644)
+	destDir := t.TempDir()
+	if err := extractZip(zipPath, destDir); err != nil {
+		t.Fatalf("extractZip of a Zip64 zip: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(destDir, "emulator.exe")); err != nil || string(data) != "exe" {
+		t.Errorf("extractZip of a Zip64 zip wrote %q, %v, want \"exe\"", data, err)
+	}
+}
+
+// archiveEntry is one entry written by writeZip or writeTar. A non-empty
+// link makes it a symlink to that target, or with hard set a tar hard link.
+type archiveEntry struct {
+	name, body, link string
+	hard             bool
+}
+
+// writeZip writes entries to a zip in a temp folder and returns its path
+func writeZip(t *testing.T, entries []archiveEntry) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for _, e := range entries {
+		header := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		body := e.body
+		if e.link != "" {
+			header.SetMode(os.ModeSymlink | 0777)
+			body = e.link
+		}
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(body))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// writeTar returns entries as an uncompressed tar
+func writeTar(t *testing.T, entries []archiveEntry) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		header := &tar.Header{Name: e.name, Mode: 0644, Typeflag: tar.TypeReg, Size: int64(len(e.body))}
+		switch {
+		case e.link != "" && e.hard:
+			header.Typeflag, header.Linkname, header.Size = tar.TypeLink, e.link, 0
+		case e.link != "":
+			header.Typeflag, header.Linkname, header.Size = tar.TypeSymlink, e.link, 0
+		case e.body == "" && e.name[len(e.name)-1] == '/':
+			header.Typeflag, header.Mode = tar.TypeDir, 0755
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(e.body))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+func TestSafeJoin(t *testing.T) {
+	destDir := filepath.Join(t.TempDir(), "dest")
+	tests := []struct {
+		name string
+		want string // "" when the name must be refused
+	}{
+		{"emulator.exe", "emulator.exe"},
+		{"bin/emulator", filepath.Join("bin", "emulator")},
+		{`bin\emulator.exe`, filepath.Join("bin", "emulator.exe")},
+		{"bin/../emulator.ini", "emulator.ini"},
+		{"../evil.sh", ""},
+		{"bin/../../evil.sh", ""},
+		{`..\evil.exe`, ""},
+		{`bin\..\..\evil.exe`, ""},
+		{"..", ""},
+		{"/etc/cron.d/evil", ""},
+		{`\Windows\evil.dll`, ""},
+		{"C:/Windows/evil.dll", ""},
+		{`C:\Windows\evil.dll`, ""},
+		{"C:evil.exe", ""},
+		{"//server/share/evil.exe", ""},
+	}
+	for _, tt := range tests {
+		got, err := safeJoin(destDir, tt.name)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("safeJoin(%q) = %q, want an error", tt.name, got)
+			}
+			continue
+		}
+		if want := filepath.Join(destDir, tt.want); err != nil || got != want {
+			t.Errorf("safeJoin(%q) = %q, %v, want %q", tt.name, got, err, want)
+		}
+	}
+}
+
+func TestExtractTar(t *testing.T) {
+	tests := []struct {
+		desc    string
+		entries []archiveEntry
+		ok      bool
+	}{
+		{"plain files", []archiveEntry{{name: "bin/"}, {name: "bin/emulator", body: "elf"}, {name: "README.txt", body: "hi"}}, true},
+		{"link inside", []archiveEntry{{name: "bin/emulator", body: "elf"}, {name: "emulator", link: "bin/emulator"}}, true},
+		{"link up and back in", []archiveEntry{{name: "bin/emulator", body: "elf"}, {name: "lib/emulator", link: "../bin/emulator"}}, true},
+		{"hard link inside", []archiveEntry{{name: "bin/emulator", body: "elf"}, {name: "emulator", link: "bin/emulator", hard: true}}, true},
+		{"dot-dot name", []archiveEntry{{name: "../evil.sh", body: "evil"}}, false},
+		{"nested dot-dot name", []archiveEntry{{name: "bin/../../evil.sh", body: "evil"}}, false},
+		{"backslash dot-dot name", []archiveEntry{{name: `..\evil.sh`, body: "evil"}}, false},
+		{"absolute name", []archiveEntry{{name: "/tmp/evil.sh", body: "evil"}}, false},
+		{"drive letter name", []archiveEntry{{name: "C:/evil.exe", body: "evil"}}, false},
+		{"drive-relative name", []archiveEntry{{name: "C:evil.exe", body: "evil"}}, false},
+		{"absolute link", []archiveEntry{{name: "etc", link: "/etc"}}, false},
+		{"dot-dot link", []archiveEntry{{name: "etc", link: "../../etc"}}, false},
+		{"link through a name", []archiveEntry{{name: "bin/"}, {name: "evil", link: "bin/../../etc"}}, false},
+		{"drive letter link", []archiveEntry{{name: "windows", link: `C:\Windows`}}, false},
+		{"backslash dot-dot link", []archiveEntry{{name: "etc", link: `..\..\etc`}}, false},
+		{"hard link outside", []archiveEntry{{name: "passwd", link: "../../etc/passwd", hard: true}}, false},
+	}
+	for _, tt := range tests {
+		root := t.TempDir()
+		destDir := filepath.Join(root, "dest")
+		os.MkdirAll(destDir, 0755)
+
+		err := extractTar(writeTar(t, tt.entries), destDir)
+		if tt.ok && err != nil {
+			t.Errorf("%s: %v", tt.desc, err)
+		}
+		if !tt.ok && err == nil {
+			t.Errorf("%s: extracted, want an error", tt.desc)
+		}
+		if fileExists(filepath.Join(root, "evil.sh")) {
+			t.Errorf("%s: wrote outside the destination folder", tt.desc)
+		}
+	}
+}
+
+func TestExtractTarLinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks in archives are left out on Windows")
+	}
+	destDir := t.TempDir()
+	entries := []archiveEntry{{name: "bin/emulator", body: "elf"}, {name: "emulator", link: "bin/emulator"}}
+	if err := extractTar(writeTar(t, entries), destDir); err != nil {
+		t.Fatal(err)
+	}
+	if target, err := os.Readlink(filepath.Join(destDir, "emulator")); err != nil || target != "bin/emulator" {
+		t.Errorf("link = %q, %v, want bin/emulator", target, err)
+	}
+
+	// A file can't be written through a linked folder, even one inside destDir
+	entries = []archiveEntry{{name: "real/"}, {name: "lib", link: "real"}, {name: "lib/evil.sh", body: "evil"}}
+	if err := extractTar(writeTar(t, entries), t.TempDir()); err == nil {
+		t.Error("extracted a file through a linked folder, want an error")
+	}
+}
+
+func TestExtractZipRefusesEscapingEntries(t *testing.T) {
+	tests := []struct {
+		desc    string
+		entries []archiveEntry
+	}{
+		{"dot-dot name", []archiveEntry{{name: "emulator.exe", body: "exe"}, {name: "../evil.sh", body: "evil"}}},
+		{"backslash dot-dot name", []archiveEntry{{name: "emulator.exe", body: "exe"}, {name: `..\evil.sh`, body: "evil"}}},
+		{"absolute name", []archiveEntry{{name: "emulator.exe", body: "exe"}, {name: "/tmp/evil.sh", body: "evil"}}},
+		{"drive letter name", []archiveEntry{{name: "emulator.exe", body: "exe"}, {name: `C:\evil.exe`, body: "evil"}}},
+		{"absolute link", []archiveEntry{{name: "emulator.exe", body: "exe"}, {name: "etc", link: "/etc"}}},
+		{"dot-dot link", []archiveEntry{{name: "emulator.exe", body: "exe"}, {name: "etc", link: "../../etc"}}},
+	}
+	for _, tt := range tests {
+		root := t.TempDir()
+		destDir := filepath.Join(root, "dest")
+
+		if err := extractZip(writeZip(t, tt.entries), destDir); err == nil {
+			t.Errorf("%s: extracted, want an error", tt.desc)
+		}
+		if fileExists(filepath.Join(root, "evil.sh")) {
+			t.Errorf("%s: wrote outside the destination folder", tt.desc)
+		}
+	}
+}
@@ -2,4 +2,9 @@ module emubuddy-installer
 
 go 1.19
 
-require github.com/ulikunitz/xz v0.5.12
+require (
+	github.com/emubuddy/download v0.0.0
+	github.com/ulikunitz/xz v0.5.12
+)
+
+replace github.com/emubuddy/download => ../pkg/download
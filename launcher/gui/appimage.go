@@ -0,0 +1,118 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// AppImage launch modes for EmulatorConfig.AppImageLaunch
+const (
+	appImageLaunchAuto          = ""                // direct, with extract-and-run when FUSE is missing
+	appImageLaunchDirect        = "direct"          // always exec the AppImage directly
+	appImageLaunchExtractAndRun = "extract-and-run" // always pass --appimage-extract-and-run
+)
+
+const appImageExtractAndRunFlag = "--appimage-extract-and-run"
+
+// fuseErrorMarkers are printed by the AppImage runtime when it can't mount itself
+var fuseErrorMarkers = []string{
+	"libfuse.so.2",
+	"AppImages require FUSE",
+	"Cannot mount AppImage",
+	"/dev/fuse",
+}
+
+func isAppImage(path string) bool {
+	return strings.HasSuffix(strings.ToLower(path), ".appimage")
+}
+
+// appImageLaunchMode returns the configured launch mode for an emulator path
+// as written in systems.json (before platform resolution)
+func appImageLaunchMode(emuPath string) string {
+	for _, config := range systems {
+		if config.Emulator.Path == emuPath {
+			return config.Emulator.AppImageLaunch
+		}
+		if config.StandaloneEmulator != nil && config.StandaloneEmulator.Path == emuPath {
+			return config.StandaloneEmulator.AppImageLaunch
+		}
+	}
+	return appImageLaunchAuto
+}
+
+var (
+	fuseOnce  sync.Once
+	fuseFound bool
+)
+
+// fuseAvailable reports whether AppImages can mount themselves: /dev/fuse has
+// to exist and a fusermount helper has to be on PATH
+func fuseAvailable() bool {
+	fuseOnce.Do(func() {
+		if _, err := os.Stat("/dev/fuse"); err != nil {
+			return
+		}
+		for _, helper := range []string{"fusermount", "fusermount3"} {
+			if _, err := exec.LookPath(helper); err == nil {
+				fuseFound = true
+				return
+			}
+		}
+	})
+	return fuseFound
+}
+
+// useExtractAndRun decides up front whether an AppImage should be started
+// with --appimage-extract-and-run
+func useExtractAndRun(mode string) bool {
+	switch mode {
+	case appImageLaunchExtractAndRun:
+		return true
+	case appImageLaunchDirect:
+		return false
+	default:
+		return !fuseAvailable()
+	}
+}
+
+// withExtractAndRun prepends the extract-and-run flag, which the AppImage
+// runtime consumes before the emulator sees its arguments
+func withExtractAndRun(args []string) []string {
+	return append([]string{appImageExtractAndRunFlag}, args...)
+}
+
+// fuseErrorWatcher is an io.Writer placed on an AppImage's stderr that notes
+// whether the runtime complained about FUSE
+type fuseErrorWatcher struct {
+	mu   sync.Mutex
+	tail string
+	seen bool
+}
+
+func (w *fuseErrorWatcher) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.seen {
+		// Keep a little of the previous write so markers split across writes still match
+		text := w.tail + string(p)
+		for _, marker := range fuseErrorMarkers {
+			if strings.Contains(text, marker) {
+				w.seen = true
+				break
+			}
+		}
+		if len(text) > 64 {
+			text = text[len(text)-64:]
+		}
+		w.tail = text
+	}
+	return len(p), nil
+}
+
+func (w *fuseErrorWatcher) failed() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.seen
+}
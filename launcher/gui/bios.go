@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bufio"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"fyne.io/fyne/v2/dialog"
+)
+
+// BIOS files an emulator or core can't run without are listed in systems.json
+// ("bios" on a core or a standalone emulator). They're checked before a
+// launch, so a missing one is reported by name instead of as an emulator
+// error. RetroArch cores look in RetroArch's system_directory; standalone
+// emulators in their "biosDir".
+
+// BiosFile is one required BIOS file
+type BiosFile struct {
+	// File is relative to the BIOS folder and may be a glob, e.g.
+	// "scph550[0-2].bin" when any of several regions will do
+	File string `json:"file"`
+	// MD5 lists the accepted dumps; empty accepts any file of that name
+	MD5 []string `json:"md5,omitempty"`
+}
+
+// coreArg returns the core path given with -L, if any
+func coreArg(emuArgs []string) string {
+	for i, arg := range emuArgs {
+		if arg == "-L" && i+1 < len(emuArgs) {
+			return emuArgs[i+1]
+		}
+	}
+	return ""
+}
+
+// biosRequirements returns the BIOS files needed to launch with emuPath and
+// emuArgs (one of the system's emulator choices) and the folder they belong in
+func biosRequirements(config SystemConfig, emuPath string, emuArgs []string) ([]BiosFile, string) {
+	core := coreArg(emuArgs)
+	for _, emu := range []*EmulatorConfig{&config.Emulator, config.StandaloneEmulator} {
+		if emu == nil || emu.Path != emuPath {
+			continue
+		}
+		target := resolveEmulator(emuPath)
+		if core == "" {
+			if len(emu.Bios) == 0 || emu.BiosDir == "" {
+				return nil, ""
+			}
+			dir := filepath.FromSlash(emu.BiosDir)
+			if !filepath.IsAbs(dir) {
+				if target.Style == launchFlatpak {
+					// The app keeps its files in its own sandbox
+					return nil, ""
+				}
+				dir = filepath.Join(emulatorFolder(target.Dir), dir)
+			}
+			return emu.Bios, dir
+		}
+		for _, c := range emu.Cores {
+			if c.GetCorePath() == core {
+				if len(c.Bios) == 0 {
+					return nil, ""
+				}
+				return c.Bios, retroArchSystemDir(target)
+			}
+		}
+	}
+	return nil, ""
+}
+
+// emulatorFolder is the folder an emulator is installed in: the one holding
+// its executable, or for a macOS app bundle, the one holding the bundle
+func emulatorFolder(exeDir string) string {
+	for dir := exeDir; dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if strings.HasSuffix(strings.ToLower(dir), ".app") {
+			return filepath.Dir(dir)
+		}
+	}
+	return exeDir
+}
+
+// missingBios checks the BIOS files for a launch, returning the folder they
+// belong in and a line for each one that's missing or the wrong dump
+func missingBios(config SystemConfig, emuPath string, emuArgs []string) (string, []string) {
+	files, dir := biosRequirements(config, emuPath, emuArgs)
+	var problems []string
+	for _, file := range files {
+		if problem := checkBiosFile(dir, file); problem != "" {
+			problems = append(problems, problem)
+		}
+	}
+	return dir, problems
+}
+
+func checkBiosFile(dir string, file BiosFile) string {
+	matches := globFold(filepath.Join(dir, filepath.FromSlash(file.File)))
+	if len(matches) == 0 {
+		return file.File + " - missing"
+	}
+	if len(file.MD5) == 0 {
+		return ""
+	}
+	for _, path := range matches {
+		sum, err := fileMD5(path)
+		if err != nil {
+			continue
+		}
+		for _, want := range file.MD5 {
+			if strings.EqualFold(sum, want) {
+				return ""
+			}
+		}
+	}
+	return file.File + " - wrong version (MD5 doesn't match a known dump)"
+}
+
+func fileMD5(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := md5.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// retroArchSystemDir is RetroArch's BIOS folder
+func retroArchSystemDir(target emulatorTarget) string {
+	return retroArchDir(target, "system_directory", "system")
+}
+
+// retroArchDir is one of RetroArch's folders: key from its retroarch.cfg (the
+// installer sets system_directory), or the fallback folder in its own folder
+func retroArchDir(target emulatorTarget, key, fallback string) string {
+	home, _ := os.UserHomeDir()
+	if target.Style == launchFlatpak {
+		return filepath.Join(home, ".var", "app", target.FlatpakID, "config", "retroarch", fallback)
+	}
+
+	folder := emulatorFolder(target.Dir)
+	configs := []string{filepath.Join(folder, "retroarch.cfg")}
+	if runtime.GOOS == "darwin" {
+		configs = append(configs, filepath.Join(home, "Library", "Application Support", "RetroArch", "config", "retroarch.cfg"))
+	}
+	for _, path := range configs {
+		value := retroArchSetting(path, key)
+		switch {
+		case value == "" || value == "default":
+			continue
+		case strings.HasPrefix(value, ":"):
+			// Relative to RetroArch's own folder
+			return filepath.Join(target.Dir, strings.TrimLeft(value[1:], `/\`))
+		case value == "~" || strings.HasPrefix(value, "~/"):
+			return filepath.Join(home, value[1:])
+		}
+		return filepath.FromSlash(value)
+	}
+	return filepath.Join(folder, fallback)
+}
+
+// retroArchSetting reads one key from a retroarch.cfg, "" if it's not set
+func retroArchSetting(path, key string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		name, value, ok := strings.Cut(scanner.Text(), "=")
+		if ok && strings.TrimSpace(name) == key {
+			return strings.Trim(strings.TrimSpace(value), `"`)
+		}
+	}
+	return ""
+}
+
+// checkBios runs launch if the emulator's BIOS files are in place, and
+// otherwise names the missing ones and where they go, offering to launch
+// anyway
+func (a *App) checkBios(sysID, emuPath string, emuArgs []string, launch func()) {
+	dir, problems := missingBios(systems[sysID], emuPath, emuArgs)
+	if len(problems) == 0 {
+		launch()
+		return
+	}
+	logWarn("BIOS check for %s failed in %s: %s", sysID, dir, strings.Join(problems, "; "))
+
+	message := fmt.Sprintf("%s needs BIOS files that aren't in place:\n\n  %s\n\n"+
+		"Put them in this folder (the file names matter):\n%s\n\n"+
+		"See SYSTEMS_CONFIG_GUIDE.md for the files each system needs.",
+		systems[sysID].Name, strings.Join(problems, "\n  "), dir)
+	a.dialogOpen = true
+	d := dialog.NewConfirm("BIOS Missing", message, func(ok bool) {
+		a.dialogOpen = false
+		if ok {
+			launch()
+		}
+	}, a.window)
+	d.SetConfirmText("Launch anyway")
+	d.SetDismissText("Cancel")
+	d.Show()
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+)
+
+// Box art thumbnails in the game list
+const (
+	boxArtSize         = 40 // thumbnail edge in the game list, in pixels
+	boxArtDir          = "boxart"
+	boxArtFetchTimeout = 30 * time.Second
+	maxBoxArtFetches   = 2 // background art downloads at once
+)
+
+var (
+	boxArtClient = newHTTPClient(boxArtFetchTimeout)
+	boxArtSlots  = make(chan struct{}, maxBoxArtFetches)
+
+	// boxArtFetching holds paths being downloaded or that failed this session,
+	// so scrolling past a game doesn't start the same download again
+	boxArtMu       sync.Mutex
+	boxArtFetching = make(map[string]bool)
+)
+
+// boxArtPath is where a game's art lives: <rom dir>/boxart/<name without extension>.png
+func boxArtPath(game ROM) string {
+	config := systems[game.System]
+	name := strings.TrimSuffix(game.Name, filepath.Ext(game.Name))
+	return filepath.Join(romsDir, config.Dir, boxArtDir, sanitizeFileName(name)+".png")
+}
+
+// newBoxArtImage creates the thumbnail slot for a game list row
+func newBoxArtImage() *canvas.Image {
+	img := &canvas.Image{FillMode: canvas.ImageFillContain}
+	img.SetMinSize(fyne.NewSize(boxArtSize, boxArtSize))
+	img.Hide()
+	return img
+}
+
+// updateBoxArt shows a game's art in a row's thumbnail slot, or hides the slot
+// so the row falls back to the text layout. Missing art with an imageUrl is
+// fetched in the background and the list refreshed once it arrives.
+func (a *App) updateBoxArt(img *canvas.Image, game ROM) {
+	if settings.HideBoxArt {
+		img.Hide()
+		return
+	}
+
+	path := boxArtPath(game)
+	if fileExists(path) {
+		if img.File != path {
+			img.File = path
+			img.Refresh()
+		}
+		img.Show()
+		return
+	}
+
+	img.File = ""
+	img.Hide()
+	if game.ImageURL != "" {
+		a.fetchBoxArt(game.ImageURL, path)
+	}
+}
+
+func (a *App) fetchBoxArt(url, path string) {
+	boxArtMu.Lock()
+	if boxArtFetching[path] {
+		boxArtMu.Unlock()
+		return
+	}
+	boxArtFetching[path] = true
+	boxArtMu.Unlock()
+
+	go func() {
+		boxArtSlots <- struct{}{}
+		defer func() { <-boxArtSlots }()
+
+		if err := downloadBoxArt(url, path); err != nil {
+			// Leave it marked so it isn't retried until the next session
+			logDebug("Box art download failed for %s: %v", url, err)
+			return
+		}
+
+		boxArtMu.Lock()
+		delete(boxArtFetching, path)
+		boxArtMu.Unlock()
+		runOnEvents(a.refreshGameView)
+	}()
+}
+
+// downloadBoxArt saves an image to path, via a temporary file so a partial
+// download is never shown
+func downloadBoxArt(url, path string) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", userAgentFor(url))
+
+	resp, err := boxArtClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmpPath := path + ".tmp"
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, resp.Body); err != nil {
+		out.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
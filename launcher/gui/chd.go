@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Disc images can be compressed to CHD with MAME's chdman after downloading
+// (settings.json compressToCHD), for systems whose emulators read CHD - those
+// listing ".chd" in fileExtensions. Dolphin can't, so GameCube and Wii games
+// are left as they are.
+
+// chdPercent matches chdman's "Compressing, 42.1% complete... (ratio=51.3%)"
+var chdPercent = regexp.MustCompile(`Compressing, (\d+(?:\.\d+)?)% complete`)
+
+// bundledChdmanPath is where chdman is looked for first, next to the other
+// bundled tools
+func bundledChdmanPath() string {
+	name := "chdman"
+	if runtime.GOOS == "windows" {
+		name = "chdman.exe"
+	}
+	return filepath.Join(baseDir, "Tools", "chdman", name)
+}
+
+// findChdman returns the bundled chdman, or one on PATH, or "" if there's none
+func findChdman() string {
+	if p := bundledChdmanPath(); fileExists(p) {
+		return p
+	}
+	if p, err := exec.LookPath("chdman"); err == nil {
+		return p
+	}
+	return ""
+}
+
+// wantsCHD reports whether a system's downloads should be compressed to CHD
+func wantsCHD(config SystemConfig) bool {
+	if !settings.CompressToCHD || !config.NeedsExtract {
+		return false
+	}
+	for _, ext := range config.FileExtensions {
+		if ext == ".chd" {
+			return true
+		}
+	}
+	return false
+}
+
+// chdSource returns the disc image chdman should read for an extracted game:
+// its .cue or .gdi sheet if it has one (the extracted path may be one of the
+// tracks), otherwise an .iso. It returns "" for anything else.
+func chdSource(romDir string, game ROM, extractedPath string) string {
+	if extractedPath == "" {
+		return ""
+	}
+	bases := []string{
+		filepath.Join(romDir, trimArchiveExt(game.Name)),
+		strings.TrimSuffix(extractedPath, filepath.Ext(extractedPath)),
+	}
+	for _, base := range bases {
+		for _, ext := range []string{".cue", ".gdi"} {
+			if fileExists(base + ext) {
+				return base + ext
+			}
+		}
+	}
+	switch strings.ToLower(filepath.Ext(extractedPath)) {
+	case ".cue", ".gdi", ".iso":
+		return extractedPath
+	}
+	return ""
+}
+
+// discImageFiles returns the source and the track files its sheet lists
+func discImageFiles(src string) []string {
+	files := []string{src}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return files
+	}
+	dir := filepath.Dir(src)
+	isGDI := strings.EqualFold(filepath.Ext(src), ".gdi")
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		var name string
+		switch {
+		case isGDI:
+			// "1 0 4 2352 track01.bin 0", with quotes if the name has spaces
+			if fields := strings.Fields(line); len(fields) >= 6 {
+				if i := strings.Index(line, `"`); i >= 0 {
+					name, _, _ = strings.Cut(line[i+1:], `"`)
+				} else {
+					name = fields[4]
+				}
+			}
+		case strings.HasPrefix(strings.ToUpper(line), "FILE "):
+			// FILE "Game (Track 1).bin" BINARY
+			rest := strings.TrimSpace(line[len("FILE "):])
+			if strings.HasPrefix(rest, `"`) {
+				name, _, _ = strings.Cut(rest[1:], `"`)
+			} else if fields := strings.Fields(rest); len(fields) > 0 {
+				name = fields[0]
+			}
+		}
+		if name != "" {
+			files = append(files, filepath.Join(dir, filepath.Base(name)))
+		}
+	}
+	return files
+}
+
+// compressToCHD converts a disc image to a .chd next to it and removes the
+// original files, returning the .chd's path. DVD images (PS2 ISOs) use
+// createdvd, everything else createcd. progress (may be nil) gets 0-1. If ctx
+// is cancelled or chdman fails, the partial .chd is removed and the original
+// kept.
+func compressToCHD(ctx context.Context, sysID, src string, progress func(float64)) (string, error) {
+	exe := findChdman()
+	if exe == "" {
+		return "", errors.New("chdman not found")
+	}
+
+	mode := "createcd"
+	if sysID == "ps2" && strings.EqualFold(filepath.Ext(src), ".iso") {
+		mode = "createdvd"
+	}
+	dst := strings.TrimSuffix(src, filepath.Ext(src)) + ".chd"
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, exe, mode, "-i", src, "-o", dst, "-f")
+	out, err := cmd.StderrPipe()
+	if err != nil {
+		return "", err
+	}
+	if err := cmd.Start(); err != nil {
+		return "", err
+	}
+
+	// Progress lines end in \r, so split on that as well as \n
+	scanner := bufio.NewScanner(out)
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+			return i + 1, data[:i], nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	})
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := chdPercent.FindStringSubmatch(line); m != nil {
+			if pct, err := strconv.ParseFloat(m[1], 64); err == nil && progress != nil {
+				progress(pct / 100)
+			}
+			continue
+		}
+		if strings.TrimSpace(line) != "" {
+			stderr.WriteString(line + "\n")
+		}
+	}
+	err = cmd.Wait()
+
+	if err != nil || ctx.Err() != nil {
+		os.Remove(dst)
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		msg := strings.TrimSpace(stderr.String())
+		logError("chdman failed on %s: %v: %s", src, err, msg)
+		if msg != "" {
+			return "", fmt.Errorf("compressing %s: %s", filepath.Base(src), lastLine(msg))
+		}
+		return "", fmt.Errorf("compressing %s: %w", filepath.Base(src), err)
+	}
+
+	for _, f := range discImageFiles(src) {
+		os.Remove(f)
+	}
+	if progress != nil {
+		progress(1)
+	}
+	return dst, nil
+}
+
+// lastLine returns the last line of chdman's output, which holds the error
+func lastLine(s string) string {
+	if i := strings.LastIndex(s, "\n"); i >= 0 {
+		return s[i+1:]
+	}
+	return s
+}
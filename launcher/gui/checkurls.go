@@ -0,0 +1,237 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// URL check configuration (keep it gentle - these are shared mirrors)
+const (
+	checkURLWorkers = 4
+	checkURLDelay   = 250 * time.Millisecond // per-worker pause between requests
+	checkURLTimeout = 30 * time.Second
+)
+
+// urlCheckResult is the outcome of probing a single game's URL
+type urlCheckResult struct {
+	Game       ROM
+	Status     string // ok, redirect, 403, 404, error, ...
+	RemoteSize int64  // -1 if the server didn't report one
+	Err        error
+}
+
+// loadSystemROMs reads the ROM list JSON for a system
+func loadSystemROMs(sysID string) ([]ROM, error) {
+	config, exists := systems[sysID]
+	if !exists {
+		return nil, fmt.Errorf("unknown system '%s'", sysID)
+	}
+	data, err := readDataFile(filepath.Join("1g1rsets", config.RomJsonFile))
+	if err != nil {
+		return nil, err
+	}
+	var games []ROM
+	if err := json.Unmarshal(data, &games); err != nil {
+		return nil, err
+	}
+	return games, nil
+}
+
+// parseROMSize converts a size string from the set JSON ("101.4 MiB", "2 GB", "512 B")
+// into bytes. Returns -1 for "Unknown" or anything that doesn't parse.
+func parseROMSize(size string) int64 {
+	fields := strings.Fields(size)
+	if len(fields) == 0 {
+		return -1
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return -1
+	}
+	unit := "B"
+	if len(fields) > 1 {
+		unit = fields[1]
+	}
+	multipliers := map[string]float64{
+		"B":   1,
+		"KiB": 1 << 10,
+		"MiB": 1 << 20,
+		"GiB": 1 << 30,
+		"KB":  1e3,
+		"MB":  1e6,
+		"GB":  1e9,
+	}
+	mult, ok := multipliers[unit]
+	if !ok {
+		return -1
+	}
+	return int64(value * mult)
+}
+
+// checkURL probes a URL with a HEAD request, falling back to a 1 KB ranged GET
+// for servers that don't answer HEAD properly
+func checkURL(client *http.Client, url string) (string, int64, error) {
+	status, size, err := probeURL(client, "HEAD", url)
+	if err == nil && status != http.StatusMethodNotAllowed && status != http.StatusNotImplemented {
+		return describeStatus(status), size, nil
+	}
+	status, size, err = probeURL(client, "GET", url)
+	if err != nil {
+		return "error", -1, err
+	}
+	return describeStatus(status), size, nil
+}
+
+func probeURL(client *http.Client, method, url string) (int, int64, error) {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return 0, -1, err
+	}
+	// The same headers as a download, so a host that needs a Referer or a
+	// token isn't reported as broken
+	setDownloadHeaders(req)
+	if method == "GET" {
+		req.Header.Set("Range", "bytes=0-1023")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, -1, err
+	}
+	resp.Body.Close()
+
+	size := resp.ContentLength
+	if resp.StatusCode == http.StatusPartialContent {
+		// Content-Range: bytes 0-1023/123456
+		size = -1
+		if cr := resp.Header.Get("Content-Range"); cr != "" {
+			if idx := strings.LastIndex(cr, "/"); idx >= 0 {
+				if n, err := strconv.ParseInt(cr[idx+1:], 10, 64); err == nil {
+					size = n
+				}
+			}
+		}
+	}
+	return resp.StatusCode, size, nil
+}
+
+func describeStatus(code int) string {
+	switch {
+	case code == http.StatusOK || code == http.StatusPartialContent:
+		return "ok"
+	case code >= 300 && code < 400:
+		return "redirect"
+	default:
+		return strconv.Itoa(code)
+	}
+}
+
+// checkSystemURLsHeadless validates every URL in a system's set JSON and prints a report.
+// Usage: --check-urls <system>
+func checkSystemURLsHeadless(sysID string) {
+	if sysID == "" {
+		fmt.Printf("Usage: %s --check-urls <system>\n", os.Args[0])
+		fmt.Println("Available systems:", allSystemsList)
+		os.Exit(1)
+	}
+
+	games, err := loadSystemROMs(sysID)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		if _, exists := systems[sysID]; !exists {
+			fmt.Println("Available systems:", allSystemsList)
+		}
+		os.Exit(1)
+	}
+
+	// Don't follow redirects so they can be reported as such
+	client := newHTTPClient(checkURLTimeout)
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	fmt.Printf("Checking %d URLs for %s...\n", len(games), systems[sysID].Name)
+
+	jobs := make(chan ROM)
+	results := make(chan urlCheckResult)
+	var wg sync.WaitGroup
+	for i := 0; i < checkURLWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for game := range jobs {
+				status, size, err := checkURL(client, game.URL)
+				results <- urlCheckResult{Game: game, Status: status, RemoteSize: size, Err: err}
+				time.Sleep(checkURLDelay)
+			}
+		}()
+	}
+
+	go func() {
+		for _, game := range games {
+			// Wii U entries are fetched from NUS by title ID and have no URL
+			if game.URL == "" {
+				continue
+			}
+			jobs <- game
+		}
+		close(jobs)
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	counts := make(map[string]int)
+	checked := 0
+	sizeMismatches := 0
+	for r := range results {
+		counts[r.Status]++
+		checked++
+
+		line := fmt.Sprintf("[%-8s] %s", r.Status, r.Game.Name)
+		if r.Err != nil {
+			line += fmt.Sprintf(" (%v)", r.Err)
+		}
+		expected := parseROMSize(r.Game.Size)
+		if r.Status == "ok" && expected > 0 && r.RemoteSize > 0 {
+			// The JSON sizes are rounded to one decimal, so allow some slack
+			diff := r.RemoteSize - expected
+			if diff < 0 {
+				diff = -diff
+			}
+			if diff > expected/100+1024 {
+				sizeMismatches++
+				line += fmt.Sprintf(" size mismatch: json=%s remote=%.1f MiB", r.Game.Size, float64(r.RemoteSize)/1024/1024)
+			}
+		}
+		fmt.Println(line)
+	}
+
+	fmt.Println()
+	fmt.Println("Summary:")
+	statuses := make([]string, 0, len(counts))
+	for status := range counts {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	for _, status := range statuses {
+		fmt.Printf("  %-8s %d\n", status, counts[status])
+	}
+	if sizeMismatches > 0 {
+		fmt.Printf("  size mismatches: %d\n", sizeMismatches)
+	}
+
+	if counts["ok"]+counts["redirect"] != checked {
+		os.Exit(1)
+	}
+}
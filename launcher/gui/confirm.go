@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Operations at or above either limit must be confirmed by typing confirmWord
+const (
+	typeToConfirmFiles = 10
+	typeToConfirmBytes = 5 * 1024 * 1024 * 1024 // 5 GiB
+	confirmWord        = "DELETE"
+)
+
+// formatBytes renders a byte count the way the set JSONs do ("101.4 MiB")
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
+// pathSize returns the size of a file, or the total size of a directory tree
+func pathSize(path string) int64 {
+	var total int64
+	filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total
+}
+
+// confirmDestructive shows every path an operation will remove or modify along
+// with the total size, and only calls onConfirm once the user agrees. Large
+// operations additionally require typing confirmWord. The full list is written
+// to the debug log either way.
+func (a *App) confirmDestructive(title, verb string, paths []string, onConfirm func()) {
+	if len(paths) == 0 {
+		return
+	}
+
+	var total int64
+	lines := make([]string, 0, len(paths))
+	for _, p := range paths {
+		size := pathSize(p)
+		total += size
+		rel, err := filepath.Rel(baseDir, p)
+		if err != nil {
+			rel = p
+		}
+		lines = append(lines, fmt.Sprintf("%s  (%s)", rel, formatBytes(size)))
+	}
+
+	logDebug("%s: %d item(s), %s pending confirmation", title, len(paths), formatBytes(total))
+	for _, p := range paths {
+		logDebug("  %s", p)
+	}
+
+	summary := widget.NewLabel(fmt.Sprintf("%s %d item(s), %s total:", verb, len(paths), formatBytes(total)))
+	list := widget.NewLabel(strings.Join(lines, "\n"))
+	scroll := container.NewVScroll(list)
+	scroll.SetMinSize(fyne.NewSize(500, 200))
+	content := container.NewBorder(summary, nil, nil, nil, scroll)
+
+	needsTyping := len(paths) >= typeToConfirmFiles || total >= typeToConfirmBytes
+	var confirmEntry *widget.Entry
+	if needsTyping {
+		confirmEntry = widget.NewEntry()
+		confirmEntry.SetPlaceHolder(fmt.Sprintf("Type %s to confirm", confirmWord))
+		content = container.NewBorder(summary, confirmEntry, nil, nil, scroll)
+	}
+
+	a.dialogOpen = true
+	d := dialog.NewCustomConfirm(title, verb, "Cancel", content, func(ok bool) {
+		a.dialogOpen = false
+		if !ok {
+			logDebug("%s: cancelled", title)
+			return
+		}
+		if needsTyping && strings.TrimSpace(confirmEntry.Text) != confirmWord {
+			logDebug("%s: cancelled (confirmation text did not match)", title)
+			a.statusBar.SetText(fmt.Sprintf("Cancelled - type %s to confirm", confirmWord))
+			return
+		}
+		logInfo("%s: confirmed", title)
+		onConfirm()
+	}, a.window)
+	d.Show()
+}
+
+// localGamePaths returns the files/directories on disk that belong to a game
+func localGamePaths(sysID string, game ROM) []string {
+	config := systems[sysID]
+	romDir := filepath.Join(romsDir, config.Dir)
+
+	if config.isWiiU() {
+		dir := filepath.Join(romDir, sanitizeFileName(game.Name))
+		if fileExists(dir) {
+			return []string{dir}
+		}
+		return nil
+	}
+
+	var paths []string
+	seen := make(map[string]bool)
+	add := func(p string) {
+		if !seen[p] && fileExists(p) {
+			seen[p] = true
+			paths = append(paths, p)
+		}
+	}
+
+	// A multi-disc set is every disc, and its playlist
+	if len(game.Discs) > 0 {
+		for _, disc := range game.Discs {
+			for _, p := range localGamePaths(sysID, disc) {
+				add(p)
+			}
+		}
+		add(discPlaylistPath(sysID, game))
+		return paths
+	}
+
+	add(filepath.Join(romDir, game.Name))
+	baseName := trimArchiveExt(game.Name)
+	for _, ext := range config.FileExtensions {
+		add(filepath.Join(romDir, baseName+ext))
+	}
+	if len(paths) == 0 {
+		// Sorted into a subfolder
+		if p := findNestedROM(config, romDir, game); p != "" {
+			add(p)
+		}
+	}
+	return paths
+}
+
+// sanitizeFileName replaces characters that aren't allowed in file names
+func sanitizeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' || r == '*' || r == '?' || r == '"' || r == '<' || r == '>' || r == '|' {
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+// deleteSelected removes the selected game's downloaded files after confirmation
+func (a *App) deleteSelected() {
+	game, ok := a.selectedGame()
+	if !ok {
+		return
+	}
+	sysID := game.System
+
+	paths := localGamePaths(sysID, game)
+	if len(paths) == 0 {
+		a.statusBar.SetText("Not downloaded: " + game.Name)
+		return
+	}
+
+	a.confirmDestructive("Delete Game", "Delete", paths, func() {
+		for _, p := range paths {
+			if err := os.RemoveAll(p); err != nil {
+				logError("Delete failed for %s: %v", p, err)
+				dialog.ShowError(err, a.window)
+				a.refreshLibraryUsage()
+				return
+			}
+			logInfo("Deleted %s", p)
+		}
+		if a.setDownloaded(game, false) {
+			a.refilterAfterDownloadChange()
+			a.updateLaunchButton()
+		}
+		a.statusBar.SetText("Deleted: " + game.Name)
+		a.refreshLibraryUsage()
+	})
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// buildDebugPanel creates the resolved-paths panel. It stays hidden unless
+// showDebugPanel is set in settings.json.
+func (a *App) buildDebugPanel() fyne.CanvasObject {
+	a.debugLabel = widget.NewLabel("")
+	a.debugLabel.TextStyle = fyne.TextStyle{Monospace: true}
+	scroll := container.NewVScroll(a.debugLabel)
+	scroll.SetMinSize(fyne.NewSize(0, 120))
+	a.debugPanel = scroll
+	if !settings.ShowDebugPanel {
+		a.debugPanel.Hide()
+	}
+	return a.debugPanel
+}
+
+// updateDebugPanel shows how the selected system's emulator and core paths resolve
+func (a *App) updateDebugPanel() {
+	if a.debugLabel == nil || !settings.ShowDebugPanel {
+		return
+	}
+	config, ok := systems[a.currentSystem]
+	if !ok {
+		a.debugLabel.SetText("No system selected")
+		return
+	}
+
+	var lines []string
+	lines = append(lines, fmt.Sprintf("System: %s (%s)", config.Name, config.ID))
+	lines = append(lines, describeEmulatorPaths("Emulator", config.Emulator)...)
+	if config.StandaloneEmulator != nil {
+		lines = append(lines, describeEmulatorPaths("Standalone", *config.StandaloneEmulator)...)
+	}
+	a.debugLabel.SetText(strings.Join(lines, "\n"))
+}
+
+// describeEmulatorPaths resolves an emulator's paths the same way launchWithEmulator does
+func describeEmulatorPaths(label string, emu EmulatorConfig) []string {
+	if emu.Path == "" {
+		return []string{label + ": (none)"}
+	}
+
+	target := resolveEmulator(emu.Path)
+	raw := emu.Path
+	if emu.ID != "" {
+		raw = fmt.Sprintf("%s (id %s)", emu.Path, emu.ID)
+	}
+	lines := []string{fmt.Sprintf("%s raw:      %s", label, raw)}
+
+	if target.Style == launchFlatpak {
+		lines = append(lines, fmt.Sprintf("%s resolved: flatpak:%s (flatpak)", label, target.FlatpakID))
+		return lines
+	}
+
+	emuPath, emuDir := target.Exe, target.Dir
+	lines = append(lines, fmt.Sprintf("%s resolved: %s %s", label, emuPath, existsMark(emuPath)))
+
+	for _, core := range emu.Cores {
+		corePath := resolveCoreArg(emuDir, core.GetCorePath())
+		lines = append(lines, fmt.Sprintf("  core %s: %s %s", core.Name, corePath, existsMark(corePath)))
+	}
+	for _, arg := range emu.Args {
+		if strings.Contains(arg, "/") || strings.Contains(arg, "\\") {
+			argPath := resolveCoreArg(emuDir, arg)
+			lines = append(lines, fmt.Sprintf("  arg: %s %s", argPath, existsMark(argPath)))
+		}
+	}
+	return lines
+}
+
+func existsMark(path string) string {
+	if fileExists(path) {
+		return "[OK]"
+	}
+	return "[MISSING]"
+}
@@ -0,0 +1,158 @@
+package main
+
+import "fmt"
+
+// defaultEmulatorLabel returns the emulator choice a game launches with
+// without asking: its own default if it has one, otherwise its system's.
+// forGame reports which one it is.
+func defaultEmulatorLabel(game ROM) (label string, forGame bool) {
+	if override, ok := gameOverride(game.System, game.Name); ok && override.Emulator != "" {
+		return override.Emulator, true
+	}
+	return settings.DefaultEmulators[game.System], false
+}
+
+// defaultEmulatorIdx returns the index in emulatorChoices of the game's
+// default emulator, or -1 if it has none or the saved label no longer exists
+func (a *App) defaultEmulatorIdx(game ROM) int {
+	label, _ := defaultEmulatorLabel(game)
+	if label == "" {
+		return -1
+	}
+	for i, choice := range a.emulatorChoices {
+		if choice == label {
+			return i
+		}
+	}
+	return -1
+}
+
+// setSelectedEmulatorDefault saves the highlighted emulator as the default
+// for the pending game, or for its whole system
+func (a *App) setSelectedEmulatorDefault(forGame bool) {
+	if a.selectedEmulatorIdx < 0 || a.selectedEmulatorIdx >= len(a.emulatorChoices) {
+		return
+	}
+	game := a.pendingGame
+	sysID := game.System
+	label := a.emulatorChoices[a.selectedEmulatorIdx]
+	if forGame {
+		setGameEmulator(sysID, game.Name, label)
+	} else {
+		if settings.DefaultEmulators == nil {
+			settings.DefaultEmulators = make(map[string]string)
+		}
+		settings.DefaultEmulators[sysID] = label
+	}
+	saveSettings()
+
+	a.updateDefaultEmulatorButtons()
+	a.emulatorList.Refresh()
+	if forGame {
+		a.statusBar.SetText(fmt.Sprintf("%s will launch with %s (E / Back to choose)", trimArchiveExt(game.Name), label))
+	} else {
+		a.statusBar.SetText(fmt.Sprintf("%s will launch with %s (E / Back to choose)", systems[sysID].Name, label))
+	}
+}
+
+// clearEmulatorDefault removes the default the pending game launches with:
+// its own if it has one, otherwise its system's. With neither, the chooser is
+// shown on every launch.
+func (a *App) clearEmulatorDefault() {
+	game := a.pendingGame
+	sysID := game.System
+	label, forGame := defaultEmulatorLabel(game)
+	if label == "" {
+		return
+	}
+	if forGame {
+		setGameEmulator(sysID, game.Name, "")
+	} else {
+		delete(settings.DefaultEmulators, sysID)
+	}
+	saveSettings()
+
+	a.updateDefaultEmulatorButtons()
+	a.emulatorList.Refresh()
+	if forGame {
+		a.statusBar.SetText(fmt.Sprintf("Cleared default emulator for %s", trimArchiveExt(game.Name)))
+	} else {
+		a.statusBar.SetText(fmt.Sprintf("Cleared default emulator for %s", systems[sysID].Name))
+	}
+}
+
+// setGameEmulator saves (or with "" removes) a game's default emulator,
+// dropping its override once nothing is left in it
+func setGameEmulator(sysID, gameName, label string) {
+	override, _ := gameOverride(sysID, gameName)
+	override.Emulator = label
+	if settings.GameOverrides == nil {
+		settings.GameOverrides = make(map[string]map[string]GameOverride)
+	}
+	if settings.GameOverrides[sysID] == nil {
+		settings.GameOverrides[sysID] = make(map[string]GameOverride)
+	}
+	if override == (GameOverride{}) {
+		delete(settings.GameOverrides[sysID], gameName)
+		if len(settings.GameOverrides[sysID]) == 0 {
+			delete(settings.GameOverrides, sysID)
+		}
+		return
+	}
+	settings.GameOverrides[sysID][gameName] = override
+}
+
+// hasEmulatorDefaults reports whether any system or game has a default emulator
+func hasEmulatorDefaults() bool {
+	if len(settings.DefaultEmulators) > 0 {
+		return true
+	}
+	for _, games := range settings.GameOverrides {
+		for _, override := range games {
+			if override.Emulator != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// clearEmulatorDefaults removes every saved system and game default emulator
+func clearEmulatorDefaults() {
+	settings.DefaultEmulators = nil
+	for sysID, games := range settings.GameOverrides {
+		for name := range games {
+			setGameEmulator(sysID, name, "")
+		}
+	}
+}
+
+// emulatorChoiceLabel is how a choice is listed in the chooser, marked when
+// it's the game's or the system's default
+func emulatorChoiceLabel(game ROM, choice string) string {
+	if override, ok := gameOverride(game.System, game.Name); ok && override.Emulator == choice {
+		return choice + " (default for this game)"
+	}
+	if settings.DefaultEmulators[game.System] == choice {
+		return choice + " (default)"
+	}
+	return choice
+}
+
+// updateDefaultEmulatorButtons labels the default buttons for the pending
+// game and only offers "Clear default" when there is one, naming which
+func (a *App) updateDefaultEmulatorButtons() {
+	game := a.pendingGame
+	a.emulatorDefaultBtn.SetText(fmt.Sprintf("Set as default for %s", systems[game.System].Name))
+	label, forGame := defaultEmulatorLabel(game)
+	switch {
+	case label == "":
+		a.emulatorClearDefaultBtn.Hide()
+	case forGame:
+		a.emulatorClearDefaultBtn.SetText("Clear game default")
+		a.emulatorClearDefaultBtn.Show()
+	default:
+		a.emulatorClearDefaultBtn.SetText("Clear default")
+		a.emulatorClearDefaultBtn.Show()
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !windows && !darwin && !linux
+
+package main
+
+import (
+	"errors"
+	"syscall"
+)
+
+// diskFree isn't implemented on this platform
+func diskFree(path string) (int64, error) {
+	return 0, errors.New("free space not supported on this platform")
+}
+
+// isDiskFull reports whether a write failed because the drive is full
+func isDiskFull(err error) bool {
+	return errors.Is(err, syscall.ENOSPC)
+}
@@ -0,0 +1,22 @@
+//go:build linux || darwin
+
+package main
+
+import (
+	"errors"
+	"syscall"
+)
+
+// diskFree returns the bytes available to the user on the drive holding path
+func diskFree(path string) (int64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	return int64(uint64(st.Bavail) * uint64(st.Bsize)), nil
+}
+
+// isDiskFull reports whether a write failed because the drive is full
+func isDiskFull(err error) bool {
+	return errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.EDQUOT)
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2/dialog"
+)
+
+// spaceNeeded estimates the most disk space a game's download takes at once,
+// or -1 if the set doesn't give its size. For systems that extract, the
+// archive and the extracted files exist together until the archive is removed;
+// the extracted size isn't known, so it's taken to be the archive's.
+func spaceNeeded(game ROM, config SystemConfig) int64 {
+	size := parseROMSize(game.Size)
+	if size <= 0 {
+		return -1
+	}
+	if config.NeedsExtract && archiveExt(game.Name) != "" {
+		return size * 2
+	}
+	return size
+}
+
+// sizeTotal adds up the sizes the set gives for games, counting the games
+// without one
+func sizeTotal(games []ROM) (total int64, unknown int) {
+	for _, game := range games {
+		if size := parseROMSize(game.Size); size > 0 {
+			total += size
+		} else {
+			unknown++
+		}
+	}
+	return total, unknown
+}
+
+// formatSizeTotal shows a sizeTotal, e.g. "48.2 GB (+2 of unknown size)"
+func formatSizeTotal(total int64, unknown int) string {
+	text := formatBytes(total)
+	if unknown > 0 {
+		text += fmt.Sprintf(" (+%d of unknown size)", unknown)
+	}
+	return text
+}
+
+// freeSpaceNote warns when needed bytes won't fit in the free space of the
+// drive holding the ROMs folder, and is "" otherwise (or if it can't be read)
+func freeSpaceNote(needed int64) string {
+	free, err := diskFree(existingDir(romsDir))
+	if err != nil || needed <= free {
+		return ""
+	}
+	return fmt.Sprintf(" - more than the %s free!", formatBytes(free))
+}
+
+// existingDir returns dir, or its nearest parent that exists, so the free
+// space of a ROM folder can be checked before it's created
+func existingDir(dir string) string {
+	for {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// pendingDownloadBytes adds up the space needed by queued downloads that
+// haven't started, which will come out of the same free space
+func (a *App) pendingDownloadBytes() int64 {
+	a.queueMu.Lock()
+	defer a.queueMu.Unlock()
+	var total int64
+	for _, item := range a.queue {
+		if item.State != queuePending {
+			continue
+		}
+		if n := spaceNeeded(item.Game, systems[item.System]); n > 0 {
+			total += n
+		}
+	}
+	return total
+}
+
+// confirmDiskSpace calls start if the drive the game downloads to has room
+// for it (and the downloads queued before it); otherwise it asks first.
+// Games without a known size, or drives whose free space can't be read,
+// aren't checked.
+func (a *App) confirmDiskSpace(game ROM, start func()) {
+	config := systems[game.System]
+	needed := spaceNeeded(game, config)
+	if needed < 0 {
+		start()
+		return
+	}
+
+	dir := existingDir(filepath.Join(romsDir, config.Dir))
+	free, err := diskFree(dir)
+	if err != nil {
+		logWarn("Free space unavailable for %s: %v", dir, err)
+		start()
+		return
+	}
+	queued := a.pendingDownloadBytes()
+	if needed+queued <= free {
+		start()
+		return
+	}
+
+	logWarn("Low disk space for %s: need %s (+%s queued), %s free on %s", game.Name, formatBytes(needed), formatBytes(queued), formatBytes(free), dir)
+	message := fmt.Sprintf("%s needs about %s, but only %s is free on the drive holding\n%s", trimArchiveExt(game.Name), formatBytes(needed), formatBytes(free), dir)
+	if queued > 0 {
+		message += fmt.Sprintf("\n\nDownloads already queued need another %s.", formatBytes(queued))
+	}
+	if needed > parseROMSize(game.Size) {
+		message += "\n\nThis includes room to extract the archive before it's deleted."
+	}
+	message += "\n\nDownload anyway?"
+
+	a.dialogOpen = true
+	d := dialog.NewConfirm("Not Enough Disk Space", message, func(ok bool) {
+		a.dialogOpen = false
+		if ok {
+			start()
+		} else {
+			a.statusBar.SetText("Download skipped: not enough disk space")
+		}
+	}, a.window)
+	d.Show()
+}
+
+// confirmDiskSpaceAll is confirmDiskSpace for several games at once, as
+// queued by a bulk download. Their sizes are added up per drive, and it asks
+// once, listing every drive that's short.
+func (a *App) confirmDiskSpaceAll(games []ROM, start func()) {
+	needed := make(map[string]int64)
+	var dirs []string
+	for _, game := range games {
+		config := systems[game.System]
+		n := spaceNeeded(game, config)
+		if n < 0 {
+			continue
+		}
+		dir := existingDir(filepath.Join(romsDir, config.Dir))
+		if _, ok := needed[dir]; !ok {
+			dirs = append(dirs, dir)
+		}
+		needed[dir] += n
+	}
+
+	queued := a.pendingDownloadBytes()
+	var short []string
+	for _, dir := range dirs {
+		free, err := diskFree(dir)
+		if err != nil {
+			logWarn("Free space unavailable for %s: %v", dir, err)
+			continue
+		}
+		if needed[dir]+queued <= free {
+			continue
+		}
+		logWarn("Low disk space for %d games: need %s (+%s queued), %s free on %s", len(games), formatBytes(needed[dir]), formatBytes(queued), formatBytes(free), dir)
+		short = append(short, fmt.Sprintf("%s needed, %s free on the drive holding\n%s", formatBytes(needed[dir]), formatBytes(free), dir))
+	}
+	if len(short) == 0 {
+		start()
+		return
+	}
+
+	message := fmt.Sprintf("The %d selected games need more space than is free:\n\n%s", len(games), strings.Join(short, "\n\n"))
+	if queued > 0 {
+		message += fmt.Sprintf("\n\nDownloads already queued need another %s.", formatBytes(queued))
+	}
+	message += "\n\nDownload anyway?"
+
+	a.dialogOpen = true
+	d := dialog.NewConfirm("Not Enough Disk Space", message, func(ok bool) {
+		a.dialogOpen = false
+		if ok {
+			start()
+		} else {
+			a.statusBar.SetText("Download skipped: not enough disk space")
+		}
+	}, a.window)
+	d.Show()
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// Double-click interval (settings.json doubleClickMs)
+const (
+	defaultDoubleClickMs = 400
+	minDoubleClickMs     = 100
+	maxDoubleClickMs     = 2000
+)
+
+// doubleClickInterval is how close together two clicks or taps on the same
+// item must be to count as a double-click
+func doubleClickInterval() time.Duration {
+	ms := settings.DoubleClickMs
+	if ms <= 0 {
+		ms = defaultDoubleClickMs
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// tapTracker spots double-clicks and double-taps on list rows and grid tiles.
+// It's keyed by item rather than kept per widget, because lists reuse their
+// row widgets for other items as they scroll: two clicks on different games
+// are never a double-click.
+type tapTracker struct {
+	mu  sync.Mutex
+	key string
+	at  time.Time
+}
+
+// doubleTaps is shared by the game list, the grid and the emulator chooser,
+// so they all go by the same interval
+var doubleTaps tapTracker
+
+// tap records a click on the item identified by key and reports whether it
+// completes a double-click. A third click starts over.
+func (t *tapTracker) tap(key string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	if key == t.key && now.Sub(t.at) < doubleClickInterval() {
+		t.key = ""
+		return true
+	}
+	t.key = key
+	t.at = now
+	return false
+}
+
+// launchOnDoubleTap is what a double-click does on a game in the list or the
+// grid: the game is selected again first, so the launch can't go to another
+// game if the first click's selection didn't stick
+func (a *App) launchOnDoubleTap(idx int) {
+	a.focusOnGames = true
+	a.selectGame(idx)
+	if a.selectedIndex() != idx {
+		return
+	}
+	a.launchSelected()
+}
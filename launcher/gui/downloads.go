@@ -0,0 +1,233 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// defaultMaxDownloadAttempts is used when settings.json doesn't set maxDownloadAttempts
+const defaultMaxDownloadAttempts = 3
+
+// errDownloadCancelled is reported to download callbacks when the user cancels
+var errDownloadCancelled = errors.New("download cancelled")
+
+// failedDownload records a download that didn't complete
+type failedDownload struct {
+	System   string
+	Game     ROM
+	Attempts int
+	LastErr  error
+}
+
+// maxDownloadWorkers caps the per-download connection count from settings.json
+const maxDownloadWorkers = 16
+
+// defaultHostDownloadWorkers holds built-in per-host worker counts, used when
+// settings.json sets neither downloadWorkers nor an entry for the host.
+// Myrient rate-limits aggressively, so it stays at the long-standing default.
+var defaultHostDownloadWorkers = map[string]int{
+	"myrient.erista.me": defaultDownloadWorkers,
+}
+
+// downloadWorkersFor returns how many parallel connections to use for a URL.
+// A host entry also applies to its subdomains; the most specific one wins.
+func downloadWorkersFor(rawURL string) int {
+	host := hostName(rawURL)
+
+	// User per-host setting, then user global setting, then built-in per-host default
+	workers := 0
+	if n, ok := lookupHostWorkers(settings.HostDownloadWorkers, host); ok {
+		workers = n
+	} else if settings.DownloadWorkers > 0 {
+		workers = settings.DownloadWorkers
+	} else if n, ok := lookupHostWorkers(defaultHostDownloadWorkers, host); ok {
+		workers = n
+	}
+
+	if workers < 1 {
+		return defaultDownloadWorkers
+	}
+	if workers > maxDownloadWorkers {
+		return maxDownloadWorkers
+	}
+	return workers
+}
+
+func lookupHostWorkers(hosts map[string]int, host string) (int, bool) {
+	for _, h := range hostAndParents(host) {
+		if n, ok := hosts[h]; ok {
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+// hostName is rawURL's host in lower case, without a port, as per-host
+// settings are keyed
+func hostName(rawURL string) string {
+	host := strings.ToLower(urlHost(rawURL))
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return host
+}
+
+// hostAndParents returns host followed by each of its parent domains, e.g.
+// "cdn.example.org", "example.org", "org", so a per-host setting also
+// covers subdomains
+func hostAndParents(host string) []string {
+	var hosts []string
+	for h := host; h != ""; {
+		hosts = append(hosts, h)
+		idx := strings.Index(h, ".")
+		if idx < 0 {
+			break
+		}
+		h = h[idx+1:]
+	}
+	return hosts
+}
+
+// minChunkSize is the smallest range a parallel download is split into
+func minChunkSize() int64 {
+	if settings.MinChunkSize > 0 {
+		return settings.MinChunkSize
+	}
+	return defaultMinChunkSize
+}
+
+func maxDownloadAttempts() int {
+	if settings.MaxDownloadAttempts > 0 {
+		return settings.MaxDownloadAttempts
+	}
+	return defaultMaxDownloadAttempts
+}
+
+// permanentlyFailed reports whether a failure has used up all its attempts
+func (f *failedDownload) permanentlyFailed() bool {
+	return f.Attempts >= maxDownloadAttempts()
+}
+
+// recordDownloadResult keeps the failed list up to date as downloads finish
+func (a *App) recordDownloadResult(sysID string, game ROM, err error) {
+	a.failedMu.Lock()
+	idx := -1
+	for i, f := range a.failedDownloads {
+		if f.System == sysID && f.Game.Name == game.Name {
+			idx = i
+			break
+		}
+	}
+
+	switch {
+	case err == nil:
+		if idx >= 0 {
+			a.failedDownloads = append(a.failedDownloads[:idx], a.failedDownloads[idx+1:]...)
+		}
+	case errors.Is(err, errDownloadCancelled):
+		// Cancelling isn't a failure, but keep any earlier attempts on record
+	default:
+		if idx < 0 {
+			a.failedDownloads = append(a.failedDownloads, &failedDownload{System: sysID, Game: game})
+			idx = len(a.failedDownloads) - 1
+		}
+		f := a.failedDownloads[idx]
+		f.Attempts++
+		f.LastErr = err
+		logWarn("Download failed (attempt %d/%d): %s: %v", f.Attempts, maxDownloadAttempts(), game.Name, err)
+	}
+	a.failedMu.Unlock()
+	a.updateRetryButton()
+}
+
+// retryableFailures returns copies of the current system's failures that
+// still have attempts left
+func (a *App) retryableFailures() []failedDownload {
+	a.failedMu.Lock()
+	defer a.failedMu.Unlock()
+	var retry []failedDownload
+	for _, f := range a.failedDownloads {
+		if f.System == a.currentSystem && !f.permanentlyFailed() {
+			retry = append(retry, *f)
+		}
+	}
+	return retry
+}
+
+// permanentFailures counts the current system's failures that have used up
+// their attempts
+func (a *App) permanentFailures() int {
+	a.failedMu.Lock()
+	defer a.failedMu.Unlock()
+	n := 0
+	for _, f := range a.failedDownloads {
+		if f.System == a.currentSystem && f.permanentlyFailed() {
+			n++
+		}
+	}
+	return n
+}
+
+func (a *App) updateRetryButton() {
+	if a.retryFailedBtn == nil {
+		return
+	}
+	if n := len(a.retryableFailures()); n > 0 {
+		a.retryFailedBtn.SetText(fmt.Sprintf("Retry failed (%d)", n))
+		a.retryFailedBtn.Show()
+	} else {
+		a.retryFailedBtn.Hide()
+	}
+}
+
+// retryFailedDownloads re-runs every retryable failure for the current system,
+// one after another, waiting a little longer before each attempt so a
+// rate-limited mirror gets a chance to recover
+func (a *App) retryFailedDownloads() {
+	pending := a.retryableFailures()
+	if len(pending) == 0 {
+		a.statusBar.SetText("No failed downloads to retry")
+		return
+	}
+	a.retryFailedBtn.Hide()
+
+	var next func(i int)
+	next = func(i int) {
+		if i >= len(pending) {
+			a.finishRetry()
+			return
+		}
+		f := pending[i]
+		delay := time.Duration(f.Attempts) * 2 * time.Second
+		a.statusBar.SetText(fmt.Sprintf("Retrying %d/%d: %s", i+1, len(pending), f.Game.Name))
+		time.AfterFunc(delay, func() {
+			runOnEvents(func() {
+				queued := a.enqueueDownload(f.System, f.Game, func(err error) {
+					if errors.Is(err, errDownloadCancelled) {
+						a.finishRetry()
+						return
+					}
+					next(i + 1)
+				})
+				if !queued {
+					// Already downloading from the queue; its result is recorded
+					// as usual, so move on to the next one
+					next(i + 1)
+				}
+			})
+		})
+	}
+	next(0)
+}
+
+func (a *App) finishRetry() {
+	if permanent := a.permanentFailures(); permanent > 0 {
+		a.statusBar.SetText(fmt.Sprintf("%d download(s) failed permanently after %d attempts", permanent, maxDownloadAttempts()))
+	} else {
+		a.statusBar.SetText("Retry finished")
+	}
+	a.updateRetryButton()
+}
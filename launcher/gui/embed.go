@@ -0,0 +1,53 @@
+package main
+
+import (
+	"embed"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// bundleFS holds the optional embedded systems.json and set files (see bundle/README.md)
+//
+//go:embed bundle
+var bundleFS embed.FS
+
+// readDataFile reads a data file (relative to baseDir, e.g. "systems.json" or
+// "1g1rsets/nes.json"). Files on disk take priority; the embedded bundle is
+// only used when the external file doesn't exist.
+func readDataFile(rel string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(baseDir, rel))
+	if err == nil || !errors.Is(err, fs.ErrNotExist) {
+		return data, err
+	}
+
+	embedded, embedErr := bundleFS.ReadFile(path.Join("bundle", filepath.ToSlash(rel)))
+	if embedErr != nil {
+		// Report the original error - it names the path the user expects
+		return nil, err
+	}
+	logInfo("Using embedded %s", rel)
+	return embedded, nil
+}
+
+// openDataFile is readDataFile for files read as a stream, like the large
+// set files
+func openDataFile(rel string) (io.ReadCloser, error) {
+	f, err := os.Open(filepath.Join(baseDir, rel))
+	if err == nil {
+		return f, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	embedded, embedErr := bundleFS.Open(path.Join("bundle", filepath.ToSlash(rel)))
+	if embedErr != nil {
+		return nil, err
+	}
+	logInfo("Using embedded %s", rel)
+	return embedded, nil
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	slashpath "path"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Emulators are defined once in systems.json's "emulators" section, by ID,
+// with where to find the executable on each OS. A system's emulator refers to
+// one by "id"; adding or updating an emulator is then a change to that data
+// rather than to the launcher. An emulator given only by "path" is launched
+// from that path as-is, as before.
+
+// EmulatorPlatform says where an emulator is installed on one OS
+type EmulatorPlatform struct {
+	// Paths are tried in order, relative to the EmuBuddy folder (Emulators/
+	// follows the emulatorsDir setting). They may be glob patterns, e.g.
+	// "Emulators/mGBA/*/mGBA.exe" or "Emulators/PCSX2/*.AppImage"; the file
+	// name part is matched ignoring case, and of several matches the last
+	// (usually the newest version) wins.
+	Paths []string `json:"paths,omitempty"`
+	// Flatpak is an app ID run with "flatpak run" when no path exists (Linux)
+	Flatpak string `json:"flatpak,omitempty"`
+}
+
+// EmulatorDef is one entry in systems.json's "emulators" section
+type EmulatorDef struct {
+	Windows *EmulatorPlatform `json:"windows,omitempty"`
+	Linux   *EmulatorPlatform `json:"linux,omitempty"`
+	Darwin  *EmulatorPlatform `json:"darwin,omitempty"`
+}
+
+// current returns the definition for this OS, or nil if it has none
+func (d EmulatorDef) current() *EmulatorPlatform {
+	switch runtime.GOOS {
+	case "windows":
+		return d.Windows
+	case "linux":
+		return d.Linux
+	case "darwin":
+		return d.Darwin
+	}
+	return nil
+}
+
+// keyPath is the path an emulator by ID is known by in the launcher (its
+// choices, fullscreen flags and AppImage mode are looked up by path): the
+// first Windows path, which is what systems.json used to spell out
+func (d EmulatorDef) keyPath() string {
+	for _, p := range []*EmulatorPlatform{d.Windows, d.current()} {
+		if p != nil && len(p.Paths) > 0 {
+			return p.Paths[0]
+		}
+	}
+	return ""
+}
+
+var (
+	emulatorDefs map[string]EmulatorDef
+	// emulatorIDs maps an EmulatorConfig.Path to the ID it was defined by
+	emulatorIDs map[string]string
+)
+
+// knownBy reports whether path is one of the emulator's Windows paths, as a
+// systems.json written before IDs would give it
+func (d EmulatorDef) knownBy(path string) bool {
+	if d.Windows == nil || path == "" {
+		return false
+	}
+	for _, pattern := range d.Windows.Paths {
+		if ok, _ := slashpath.Match(pattern, filepath.ToSlash(path)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeEmulator links a system's emulator to its definition. An emulator
+// with an id gets its key path; one with only a path an emulator is known by
+// gets that emulator's id, so an older systems.json still finds it on every OS.
+func normalizeEmulator(emu *EmulatorConfig) error {
+	if emu.ID == "" {
+		for id, def := range emulatorDefs {
+			if def.knownBy(emu.Path) {
+				emu.ID = id
+				break
+			}
+		}
+		if emu.ID == "" {
+			return nil
+		}
+	}
+	def, ok := emulatorDefs[emu.ID]
+	if !ok {
+		return fmt.Errorf("unknown emulator id %q", emu.ID)
+	}
+	if emu.Path == "" {
+		emu.Path = def.keyPath()
+	}
+	emulatorIDs[emu.Path] = emu.ID
+	return nil
+}
+
+// launchStyle is how an emulator's executable is started
+type launchStyle int
+
+const (
+	launchNative launchStyle = iota
+	launchAppImage
+	launchFlatpak
+)
+
+// emulatorTarget is an emulator resolved for this machine
+type emulatorTarget struct {
+	Exe   string // full path, or "flatpak" for launchFlatpak
+	Dir   string // relative core paths are joined to this
+	Style launchStyle
+	// FlatpakID is the app ID passed to "flatpak run"
+	FlatpakID string
+	// AppImageMode is the emulator's appImageLaunch setting
+	AppImageMode string
+}
+
+// resolveEmulator finds the executable for an emulator path from systems.json
+// and how to start it. Both the GUI and headless launches go through here.
+func resolveEmulator(path string) emulatorTarget {
+	target := emulatorTarget{AppImageMode: appImageLaunchMode(path)}
+
+	exe := path
+	if def, ok := emulatorDefs[emulatorIDs[path]]; ok {
+		if p := def.current(); p != nil {
+			found, ok := p.find()
+			switch {
+			case ok:
+				exe = found
+			case p.Flatpak != "":
+				exe = "flatpak:" + p.Flatpak
+			default:
+				exe = p.fallback()
+			}
+		}
+	}
+
+	if strings.HasPrefix(exe, "flatpak:") {
+		target.Exe, target.Style, target.FlatpakID = "flatpak", launchFlatpak, strings.TrimPrefix(exe, "flatpak:")
+	} else {
+		target.Exe = emulatorPath(exe)
+		if runtime.GOOS == "linux" && isAppImage(target.Exe) {
+			target.Style = launchAppImage
+		}
+	}
+	target.Dir = filepath.Dir(target.Exe)
+	return target
+}
+
+// find returns the first of the paths that exists
+func (p *EmulatorPlatform) find() (string, bool) {
+	for _, pattern := range p.Paths {
+		full := emulatorPath(filepath.FromSlash(pattern))
+		if !strings.ContainsAny(pattern, "*?[") {
+			if fileExists(full) {
+				return full, true
+			}
+			continue
+		}
+		if matches := globFold(full); len(matches) > 0 {
+			return matches[len(matches)-1], true
+		}
+	}
+	return "", false
+}
+
+// runnable reports whether the emulator is installed well enough to start:
+// a path that's a non-empty file (so not a leftover folder from an
+// interrupted install), or its Flatpak
+func (p *EmulatorPlatform) runnable() bool {
+	if exe, ok := p.find(); ok {
+		info, err := os.Stat(exe)
+		return err == nil && info.Mode().IsRegular() && info.Size() > 0
+	}
+	if p.Flatpak != "" && runtime.GOOS == "linux" {
+		if _, err := exec.LookPath("flatpak"); err == nil {
+			return exec.Command("flatpak", "info", p.Flatpak).Run() == nil
+		}
+	}
+	return false
+}
+
+// fallback is the path reported when nothing is installed: the first one
+// without wildcards, so the error names a real file
+func (p *EmulatorPlatform) fallback() string {
+	for _, pattern := range p.Paths {
+		if !strings.ContainsAny(pattern, "*?[") {
+			return pattern
+		}
+	}
+	if len(p.Paths) > 0 {
+		return p.Paths[0]
+	}
+	return ""
+}
+
+// globFold is filepath.Glob with the last element matched ignoring case, as
+// release files mix "AppImage" and "appimage"
+func globFold(pattern string) []string {
+	dirs, err := filepath.Glob(filepath.Dir(pattern))
+	if err != nil {
+		return nil
+	}
+	base := strings.ToLower(filepath.Base(pattern))
+	var matches []string
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if ok, _ := filepath.Match(base, strings.ToLower(entry.Name())); ok {
+				matches = append(matches, filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+	return matches
+}
+
+// resolveCorePath converts a RetroArch core path from systems.json for this
+// OS: Linux cores sit next to the AppImage, and macOS RetroArch keeps its
+// cores in Application Support. Other args are returned unchanged.
+func resolveCorePath(arg string) string {
+	path := filepath.ToSlash(arg)
+	switch runtime.GOOS {
+	case "darwin":
+		// Path arrives as .dylib already (converted by GetCorePath())
+		if strings.Contains(path, "cores/") && strings.HasSuffix(path, ".dylib") {
+			homeDir, _ := os.UserHomeDir()
+			return filepath.Join(homeDir, "Library/Application Support/RetroArch/cores", filepath.Base(path))
+		}
+	case "linux":
+		if strings.Contains(path, "cores/") && strings.HasSuffix(path, ".dll") {
+			path = strings.TrimSuffix(path, ".dll") + ".so"
+			return strings.Replace(path, "RetroArch-Win64", "RetroArch-Linux-x86_64", 1)
+		}
+	}
+	return arg
+}
+
+// resolveCoreArg resolves a core path the way a launch does: for this OS,
+// and from the emulator's folder when it's relative
+func resolveCoreArg(emuDir, arg string) string {
+	resolved := resolveCorePath(arg)
+	if filepath.IsAbs(resolved) {
+		return resolved
+	}
+	return filepath.Join(emuDir, resolved)
+}
+
+// emulatorUnavailable returns why an emulator, with core if it's a RetroArch
+// core, can't be launched here, or "" if its executable and core file are on
+// disk. A Flatpak is taken as installed: asking flatpak is too slow for every
+// launch, and its cores live inside it.
+func emulatorUnavailable(emuPath string, core *CoreConfig) string {
+	target := resolveEmulator(emuPath)
+	if target.Style == launchFlatpak {
+		return ""
+	}
+	if !fileExists(target.Exe) {
+		return filepath.Base(target.Exe) + " not installed"
+	}
+	if core != nil {
+		if path := resolveCoreArg(target.Dir, core.GetCorePath()); !fileExists(path) {
+			return filepath.Base(path) + " not installed"
+		}
+	}
+	return ""
+}
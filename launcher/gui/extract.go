@@ -0,0 +1,219 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// archiveExts are the archive formats downloads are extracted from for
+// systems with needsExtract
+var archiveExts = []string{".zip", ".7z", ".rar"}
+
+// archiveExt returns the lower-case archive extension of a file name, or ""
+// if it isn't an archive the launcher can extract
+func archiveExt(name string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, e := range archiveExts {
+		if ext == e {
+			return ext
+		}
+	}
+	return ""
+}
+
+// trimArchiveExt strips a .zip/.7z/.rar extension: "Game (USA).7z" -> "Game (USA)"
+func trimArchiveExt(name string) string {
+	if ext := archiveExt(name); ext != "" {
+		return name[:len(name)-len(ext)]
+	}
+	return name
+}
+
+// extractArchive extracts a downloaded archive into destDir, picking the
+// extractor by extension. See extractZip for the return value and progress.
+func extractArchive(ctx context.Context, archivePath, destDir string, progress func(done, total int64)) (string, error) {
+	switch archiveExt(archivePath) {
+	case ".zip":
+		return extractZip(ctx, archivePath, destDir, progress)
+	case ".7z", ".rar":
+		return extractWith7Zip(ctx, archivePath, destDir, progress)
+	}
+	return "", fmt.Errorf("unsupported archive: %s", filepath.Base(archivePath))
+}
+
+// errDiskFull is returned when an extraction runs out of disk space
+var errDiskFull = errors.New("not enough disk space")
+
+// extractProgressStep is how many bytes are written between progress reports
+const extractProgressStep = 4 * 1024 * 1024
+
+// extractZip extracts every file in a zip into destDir and returns the path of
+// the last file written. progress (may be nil) gets bytes written against the
+// archive's total uncompressed size. If ctx is cancelled or a write fails, the
+// files extracted so far are removed so no half-extracted ROM is left behind.
+func extractZip(ctx context.Context, zipPath, destDir string, progress func(done, total int64)) (string, error) {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	// Refuse what archive/zip can't read before writing anything
+	if err := checkZipEntries(r.File); err != nil {
+		return "", fmt.Errorf("%s: %w", filepath.Base(zipPath), err)
+	}
+
+	var total int64
+	for _, f := range r.File {
+		total += int64(f.UncompressedSize64)
+	}
+
+	// Fail up front rather than after writing most of a multi-GB image
+	if free, err := diskFree(destDir); err == nil && free < total {
+		return "", fmt.Errorf("%w to extract %s: needs %s, %s free", errDiskFull, filepath.Base(zipPath), formatBytes(total), formatBytes(free))
+	}
+
+	var created []string
+	cleanup := func() {
+		for i := len(created) - 1; i >= 0; i-- {
+			os.Remove(created[i])
+		}
+	}
+
+	out := &extractWriter{ctx: ctx, total: total, progress: progress}
+	var extractedFile string
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		// A ROM archive has no use for links, and one could point anywhere
+		if f.Mode()&os.ModeSymlink != 0 {
+			logWarn("Skipping symlink in %s: %s", filepath.Base(zipPath), f.Name)
+			continue
+		}
+
+		destPath, err := safeJoin(destDir, f.Name)
+		if err != nil {
+			cleanup()
+			return "", fmt.Errorf("%s: %w", filepath.Base(zipPath), err)
+		}
+		os.MkdirAll(filepath.Dir(destPath), 0755)
+
+		if err := out.extractFile(f, destPath); err != nil {
+			if fileExists(destPath) {
+				created = append(created, destPath)
+			}
+			cleanup()
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			if isDiskFull(err) {
+				return "", fmt.Errorf("%w to extract %s (%s)", errDiskFull, filepath.Base(zipPath), formatBytes(total))
+			}
+			return "", fmt.Errorf("extracting %s: %w", f.Name, err)
+		}
+		created = append(created, destPath)
+		extractedFile = destPath
+	}
+	if progress != nil {
+		progress(total, total)
+	}
+	return extractedFile, nil
+}
+
+// safeJoin returns where the archive entry name goes under destDir, refusing
+// names that lead outside it: "../" components, absolute paths, or a drive
+// letter. Archives made on Windows may use backslashes and drive letters, so
+// names are read the Windows way on every OS.
+func safeJoin(destDir, name string) (string, error) {
+	slashed := strings.ReplaceAll(name, `\`, "/")
+	local := filepath.FromSlash(slashed)
+	target := filepath.Join(destDir, local)
+	rel, err := filepath.Rel(destDir, target)
+	if hasDriveLetter(slashed) || strings.HasPrefix(slashed, "/") || filepath.IsAbs(local) || filepath.VolumeName(local) != "" ||
+		err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(os.PathSeparator)) {
+		return "", fmt.Errorf("archive entry %q is outside the destination folder", name)
+	}
+	return target, nil
+}
+
+// hasDriveLetter reports whether a slash-separated name starts with a
+// Windows drive letter, as in "C:/Windows" or "C:file"
+func hasDriveLetter(name string) bool {
+	return len(name) >= 2 && name[1] == ':' && (name[0]|0x20 >= 'a' && name[0]|0x20 <= 'z')
+}
+
+// zipMethodAES is the compression method WinZip's AES encryption records in
+// place of the real one
+const zipMethodAES = 99
+
+// errEncryptedZip is returned for archives with password-protected entries.
+// archive/zip can't read AES entries and would turn ZipCrypto ones into
+// garbage, so they're refused rather than half extracted.
+var errEncryptedZip = errors.New("archive is password protected")
+
+// checkZipEntries returns an error for the first entry that can't be
+// extracted: an encrypted one, or one compressed with something other than
+// store or deflate (LZMA, bzip2, zstd). Zip64 entries are fine.
+func checkZipEntries(files []*zip.File) error {
+	for _, f := range files {
+		switch {
+		case f.Method == zipMethodAES:
+			return fmt.Errorf("%w (AES encryption): %s", errEncryptedZip, f.Name)
+		case f.Flags&0x1 != 0:
+			return fmt.Errorf("%w (ZipCrypto encryption): %s", errEncryptedZip, f.Name)
+		case f.Method != zip.Store && f.Method != zip.Deflate:
+			return fmt.Errorf("unsupported compression method %d: %s", f.Method, f.Name)
+		}
+	}
+	return nil
+}
+
+// extractWriter copies zip entries to disk, checking for cancellation and
+// reporting progress as it goes
+type extractWriter struct {
+	ctx      context.Context
+	out      io.Writer
+	done     int64
+	reported int64
+	total    int64
+	progress func(done, total int64)
+}
+
+func (w *extractWriter) extractFile(f *zip.File, destPath string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	outFile, err := os.Create(destPath)
+	if err != nil {
+		return err
+	}
+	w.out = outFile
+	_, err = io.Copy(w, rc)
+	if closeErr := outFile.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (w *extractWriter) Write(p []byte) (int, error) {
+	if err := w.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := w.out.Write(p)
+	w.done += int64(n)
+	if w.progress != nil && w.done-w.reported >= extractProgressStep {
+		w.reported = w.done
+		w.progress(w.done, w.total)
+	}
+	return n, err
+}
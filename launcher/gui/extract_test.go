@@ -0,0 +1,266 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// useTempBaseDir points the launcher's folder, and so its log, at a temp
+// folder for the test
+func useTempBaseDir(t *testing.T) {
+	t.Helper()
+	old := baseDir
+	baseDir = t.TempDir()
+	t.Cleanup(func() { baseDir = old })
+}
+
+// encryptedZip returns a zip holding one entry as an encryptor would write
+// it: AES ones with method 99 and the AE-x extra field, ZipCrypto ones with
+// only the encrypted flag. The contents are random bytes, which is all the
+// check needs.
+func encryptedZip(t *testing.T, aes bool) []byte {
+	t.Helper()
+	header := &zip.FileHeader{Name: "game.iso", Method: zip.Deflate, Flags: 0x1, CompressedSize64: 16, UncompressedSize64: 16}
+	if aes {
+		// AE-2, AES-256, the real method deflate
+		header.Method = zipMethodAES
+		header.Extra = []byte{0x01, 0x99, 7, 0, 2, 0, 'A', 'E', 3, byte(zip.Deflate), 0}
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.CreateRaw(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(bytes.Repeat([]byte{0x5a}, 16))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// zip64Zip returns a zip holding one stored entry whose sizes and offset are
+// in a Zip64 extra field, as they are for entries over 4GB. archive/zip only
+// writes that for files that size, so it's put together by hand.
+func zip64Zip(name, body string) []byte {
+	var buf bytes.Buffer
+	le := binary.LittleEndian
+	crc := crc32.ChecksumIEEE([]byte(body))
+	size := uint64(len(body))
+	extra := func(offset bool) []byte {
+		b := []byte{0x01, 0x00, 16, 0}
+		b = le.AppendUint64(le.AppendUint64(b, size), size)
+		if offset {
+			b[2] = 24
+			b = le.AppendUint64(b, 0)
+		}
+		return b
+	}
+
+	// Local file header
+	local := extra(false)
+	for _, v := range []interface{}{uint32(0x04034b50), uint16(45), uint16(0), uint16(zip.Store), uint16(0), uint16(0x21),
+		crc, uint32(0xffffffff), uint32(0xffffffff), uint16(len(name)), uint16(len(local))} {
+		binary.Write(&buf, le, v)
+	}
+	buf.WriteString(name)
+	buf.Write(local)
+	buf.WriteString(body)
+
+	// Central directory
+	dirOffset := buf.Len()
+	central := extra(true)
+	for _, v := range []interface{}{uint32(0x02014b50), uint16(45), uint16(45), uint16(0), uint16(zip.Store), uint16(0), uint16(0x21),
+		crc, uint32(0xffffffff), uint32(0xffffffff), uint16(len(name)), uint16(len(central)), uint16(0), uint16(0), uint16(0), uint32(0), uint32(0xffffffff)} {
+		binary.Write(&buf, le, v)
+	}
+	buf.WriteString(name)
+	buf.Write(central)
+	dirSize := buf.Len() - dirOffset
+
+	// End of central directory
+	for _, v := range []interface{}{uint32(0x06054b50), uint16(0), uint16(0), uint16(1), uint16(1), uint32(dirSize), uint32(dirOffset), uint16(0)} {
+		binary.Write(&buf, le, v)
+	}
+	return buf.Bytes()
+}
+
+func TestCheckZipEntries(t *testing.T) {
+	tests := []struct {
+		desc    string
+		archive []byte
+		want    string // "" when the archive can be extracted
+	}{
+		{"AES", encryptedZip(t, true), "archive is password protected (AES encryption): game.iso"},
+		{"ZipCrypto", encryptedZip(t, false), "archive is password protected (ZipCrypto encryption): game.iso"},
+		{"Zip64", zip64Zip("game.iso", "rom"), ""},
+	}
+	for _, tt := range tests {
+		r, err := zip.NewReader(bytes.NewReader(tt.archive), int64(len(tt.archive)))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.desc, err)
+		}
+		err = checkZipEntries(r.File)
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("%s: %v", tt.desc, err)
+			}
+			continue
+		}
+		if !errors.Is(err, errEncryptedZip) || err.Error() != tt.want {
+			t.Errorf("%s: got %v, want %q", tt.desc, err, tt.want)
+		}
+	}
+}
+
+func TestExtractZipEncryptedAndZip64(t *testing.T) {
+	useTempBaseDir(t)
+	for _, aes := range []bool{true, false} {
+		zipPath := filepath.Join(t.TempDir(), "game.zip")
+		os.WriteFile(zipPath, encryptedZip(t, aes), 0644)
+		destDir := t.TempDir()
+
+		_, err := extractZip(context.Background(), zipPath, destDir, nil)
+		if !errors.Is(err, errEncryptedZip) || !strings.HasPrefix(err.Error(), "game.zip: archive is password protected") {
+			t.Errorf("extractZip of an encrypted zip (AES %v): got %v, want the password protected error", aes, err)
+		}
+		if entries, _ := os.ReadDir(destDir); len(entries) > 0 {
+			t.Errorf("extractZip of an encrypted zip (AES %v) wrote %s", aes, entries[0].Name())
+		}
+	}
+
+	zipPath := filepath.Join(t.TempDir(), "game.zip")
+	os.WriteFile(zipPath, zip64Zip("game.iso", "rom"), 0644)
+	destDir := t.TempDir()
+	got, err := extractZip(context.Background(), zipPath, destDir, nil)
+	if err != nil {
+		t.Fatalf("extractZip of a Zip64 zip: %v", err)
+	}
+	if data, err := os.ReadFile(got); err != nil || string(data) != "rom" {
+		t.Errorf("extractZip of a Zip64 zip wrote %q, %v, want \"rom\"", data, err)
+	}
+}
+
+// zipEntry is one file written by writeZip; a non-empty link makes it a
+// symlink to that target
+type zipEntry struct {
+	name, body, link string
+}
+
+// writeZip writes entries to a zip in a temp folder and returns its path
+func writeZip(t *testing.T, entries []zipEntry) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for _, e := range entries {
+		header := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		body := e.body
+		if e.link != "" {
+			header.SetMode(os.ModeSymlink | 0777)
+			body = e.link
+		}
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(body))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSafeJoin(t *testing.T) {
+	destDir := filepath.Join(t.TempDir(), "dest")
+	tests := []struct {
+		name string
+		want string // "" when the name must be refused
+	}{
+		{"game.bin", "game.bin"},
+		{"disc/track01.bin", filepath.Join("disc", "track01.bin")},
+		{`disc\track02.bin`, filepath.Join("disc", "track02.bin")},
+		{"disc/../game.cue", "game.cue"},
+		{"../evil.bin", ""},
+		{"disc/../../evil.bin", ""},
+		{`..\evil.bin`, ""},
+		{`disc\..\..\evil.bin`, ""},
+		{"..", ""},
+		{"/etc/passwd", ""},
+		{`\Windows\evil.dll`, ""},
+		{"C:/Windows/evil.dll", ""},
+		{`C:\Windows\evil.dll`, ""},
+		{"C:evil.bin", ""},
+		{"//server/share/evil.bin", ""},
+	}
+	for _, tt := range tests {
+		got, err := safeJoin(destDir, tt.name)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("safeJoin(%q) = %q, want an error", tt.name, got)
+			}
+			continue
+		}
+		if want := filepath.Join(destDir, tt.want); err != nil || got != want {
+			t.Errorf("safeJoin(%q) = %q, %v, want %q", tt.name, got, err, want)
+		}
+	}
+}
+
+func TestExtractZipRefusesEscapingEntries(t *testing.T) {
+	useTempBaseDir(t)
+	for _, name := range []string{"../evil.bin", "disc/../../evil.bin", `..\evil.bin`, "/tmp/evil.bin", "C:/evil.bin", `C:\evil.bin`} {
+		root := t.TempDir()
+		destDir := filepath.Join(root, "dest")
+		os.MkdirAll(destDir, 0755)
+		zipPath := writeZip(t, []zipEntry{
+			{name: "game.bin", body: "rom"},
+			{name: name, body: "evil"},
+		})
+
+		if _, err := extractZip(context.Background(), zipPath, destDir, nil); err == nil {
+			t.Errorf("extractZip with entry %q succeeded, want an error", name)
+		}
+		if fileExists(filepath.Join(root, "evil.bin")) {
+			t.Errorf("entry %q was written outside the destination folder", name)
+		}
+		if fileExists(filepath.Join(destDir, "game.bin")) {
+			t.Errorf("entry %q: game.bin was left behind after the failed extraction", name)
+		}
+	}
+}
+
+func TestExtractZipSkipsSymlinks(t *testing.T) {
+	useTempBaseDir(t)
+	for _, target := range []string{"../../etc", "/etc/passwd", "game.bin"} {
+		destDir := t.TempDir()
+		zipPath := writeZip(t, []zipEntry{
+			{name: "link", link: target},
+			{name: "game.bin", body: "rom"},
+		})
+
+		got, err := extractZip(context.Background(), zipPath, destDir, nil)
+		if err != nil {
+			t.Fatalf("extractZip with a link to %q: %v", target, err)
+		}
+		if want := filepath.Join(destDir, "game.bin"); got != want {
+			t.Errorf("extractZip with a link to %q returned %q, want %q", target, got, want)
+		}
+		if _, err := os.Lstat(filepath.Join(destDir, "link")); err == nil {
+			t.Errorf("the link to %q was extracted", target)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import "sort"
+
+// favoritesSystemID is the sidebar entry listing the favorites of every
+// system. Like Recently Played it isn't in systems.json, and each of its
+// games keeps its real System for launching and downloading.
+const (
+	favoritesSystemID   = "_favorites"
+	favoritesSystemName = "All Favorites"
+)
+
+// isVirtualSystem reports whether a sidebar entry lists games from several
+// systems instead of being a system from systems.json
+func isVirtualSystem(sysID string) bool {
+	return sysID == recentSystemID || sysID == favoritesSystemID
+}
+
+// favoriteGames returns every system's favorites, grouped by system in
+// systems.json order and sorted by name within each. Games are looked up in
+// their system's set as in recentGames.
+func favoriteGames() []ROM {
+	var games []ROM
+	for _, sysID := range allSystemsList {
+		names := favoriteNames(sysID)
+		if len(names) == 0 {
+			continue
+		}
+		sort.Strings(names)
+
+		set := loadSetByName(sysID)
+		for _, name := range names {
+			game, ok := set[name]
+			if !ok {
+				game = ROM{Name: name}
+			}
+			game.System = sysID
+			games = append(games, game)
+		}
+	}
+	return games
+}
+
+// selectFavoriteGames shows the All Favorites list in the game browser
+func (a *App) selectFavoriteGames() {
+	games := favoriteGames()
+	logDebug("All Favorites: %d games", len(games))
+	a.showMixedGames(games)
+}
+
+// setFavoritesOnly turns the Favorites Only filter on or off. With
+// favoritesOnlyAllSystems set it switches to All Favorites instead, and back
+// to the system it came from when it's turned off there.
+func (a *App) setFavoritesOnly(on bool) {
+	a.showFavsOnly = on
+	if settings.FavoritesOnlyAllSystems {
+		switch {
+		case on && a.currentSystem != favoritesSystemID:
+			back := a.currentSystem
+			if a.selectSystemByID(favoritesSystemID) {
+				a.favoritesReturnSystem = back
+				return
+			}
+		case !on && a.currentSystem == favoritesSystemID && a.favoritesReturnSystem != "":
+			if a.selectSystemByID(a.favoritesReturnSystem) {
+				return
+			}
+		}
+	}
+	a.filterGames()
+}
+
+// selectSystemByID selects a system in the sidebar, if it's shown there
+func (a *App) selectSystemByID(sysID string) bool {
+	for i, id := range systemsList {
+		if id == sysID {
+			a.systemList.Select(i)
+			return true
+		}
+	}
+	return false
+}
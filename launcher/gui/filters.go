@@ -0,0 +1,57 @@
+package main
+
+import "sort"
+
+// Download-state filter options for the game list
+const (
+	filterAll           = "All games"
+	filterDownloaded    = "Downloaded"
+	filterNotDownloaded = "Not downloaded"
+)
+
+var downloadFilterOptions = []string{filterAll, filterDownloaded, filterNotDownloaded}
+
+// matchesDownloadFilter reports whether a game passes the download-state filter
+func (a *App) matchesDownloadFilter(game ROM) bool {
+	switch a.downloadFilter {
+	case filterDownloaded:
+		return a.isDownloaded(game)
+	case filterNotDownloaded:
+		return !a.isDownloaded(game)
+	}
+	return true
+}
+
+// orderFavoritesFirst floats favorites to the top of games when
+// favoritesFirst is on. The sort is stable, so both groups keep the order
+// sortGames gave them.
+func (a *App) orderFavoritesFirst(games []ROM) {
+	if !settings.FavoritesFirst || a.showFavsOnly {
+		return
+	}
+	sort.SliceStable(games, func(i, j int) bool {
+		return a.isFavorite(games[i]) && !a.isFavorite(games[j])
+	})
+}
+
+// refilterAfterDownloadChange re-applies the download-state filter after a game
+// was downloaded or deleted, keeping the selected game selected if it's still listed
+func (a *App) refilterAfterDownloadChange() {
+	if a.downloadFilter == "" || a.downloadFilter == filterAll {
+		a.refreshGameView()
+		return
+	}
+
+	selected := ""
+	if game, ok := a.selectedGame(); ok {
+		selected = game.Name
+	}
+	a.filterGames()
+	for i, game := range a.shownGames() {
+		if game.Name == selected {
+			a.selectGame(i)
+			a.gameList.ScrollTo(i)
+			return
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// Values for settings.ControllerInput
+const (
+	controllerInputAlways  = "always"
+	controllerInputFocused = "focused"
+)
+
+// focusCheckInterval limits how often the window focus is looked up; on
+// Linux each check runs an external tool
+const focusCheckInterval = 500 * time.Millisecond
+
+var unknownControllerInputOnce sync.Once
+
+// controllerNeedsFocus reports whether controller input should be ignored
+// while another window is active
+func controllerNeedsFocus() bool {
+	switch settings.ControllerInput {
+	case controllerInputFocused:
+		return true
+	case "", controllerInputAlways:
+		return false
+	default:
+		unknownControllerInputOnce.Do(func() {
+			logWarn("Unknown controllerInput %q, using %q", settings.ControllerInput, controllerInputAlways)
+		})
+		return false
+	}
+}
+
+// hasWindowFocus reports whether the launcher window is focused, refreshing
+// the cached answer at most every focusCheckInterval. Only the controller
+// goroutine calls it.
+func (a *App) hasWindowFocus() bool {
+	if time.Since(a.focusCheckedAt) >= focusCheckInterval {
+		a.windowFocused = isWindowFocused("EmuBuddy")
+		a.focusCheckedAt = time.Now()
+	}
+	return a.windowFocused
+}
@@ -0,0 +1,72 @@
+package main
+
+import "strings"
+
+// fullscreenFlags maps an emulator, matched by a substring of its name or
+// executable path (lower case), to the argument that starts it fullscreen.
+// RetroArch comes first so its cores (e.g. mGBA) don't match the standalone.
+var fullscreenFlags = []struct {
+	match string
+	args  []string
+}{
+	{"retroarch", []string{"-f"}},
+	{"ppsspp", []string{"--fullscreen"}},
+	{"pcsx2", []string{"-fullscreen"}},
+	{"mgba", []string{"-f"}},
+	{"dolphin", []string{"--config=Dolphin.Display.Fullscreen=True"}},
+	{"melonds", []string{"-f"}},
+	{"azahar", []string{"-f"}},
+	{"cemu", []string{"-f"}},
+	{"duckstation", []string{"-fullscreen"}},
+}
+
+// fullscreenEnabled reports whether games of a system should open fullscreen:
+// a per-system entry in settings wins over the global toggle
+func fullscreenEnabled(sysID string) bool {
+	if on, ok := settings.FullscreenSystems[sysID]; ok {
+		return on
+	}
+	return settings.LaunchFullscreen
+}
+
+// emulatorNameForPath returns the systems.json name of the emulator at path
+func emulatorNameForPath(config SystemConfig, path string) string {
+	if config.Emulator.Path == path {
+		return config.Emulator.Name
+	}
+	if config.StandaloneEmulator != nil && config.StandaloneEmulator.Path == path {
+		return config.StandaloneEmulator.Name
+	}
+	return ""
+}
+
+// fullscreenArgsFor returns the fullscreen flag for an emulator, or nil if
+// it isn't one we know
+func fullscreenArgsFor(name, path string) []string {
+	key := strings.ToLower(name + " " + path)
+	for _, f := range fullscreenFlags {
+		if strings.Contains(key, f.match) {
+			return f.args
+		}
+	}
+	return nil
+}
+
+// withFullscreen prepends the emulator's fullscreen flag to its args when
+// fullscreen is on for the system. Args that already have it are unchanged.
+func withFullscreen(sysID, emuPath string, args []string) []string {
+	if !fullscreenEnabled(sysID) {
+		return args
+	}
+	flags := fullscreenArgsFor(emulatorNameForPath(systems[sysID], emuPath), emuPath)
+	if len(flags) == 0 {
+		logWarn("No known fullscreen flag for %s", emuPath)
+		return args
+	}
+	for _, arg := range args {
+		if arg == flags[0] {
+			return args
+		}
+	}
+	return append(append([]string{}, flags...), args...)
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// A system's set is read on a goroutine so switching to one with tens of
+// thousands of games doesn't freeze the window. The list fills in as entries
+// are decoded, and search and filters work on what's loaded so far; the
+// downloaded badges arrive with the last batch, once the roms folder has been
+// scanned.
+
+// gameLoadBatch is how many set entries are decoded between list updates
+const gameLoadBatch = 2000
+
+// streamROMs decodes a set file entry by entry, passing everything decoded so
+// far to partial every gameLoadBatch entries. Each slice it passes is never
+// appended to in place, so it may be published as is.
+func streamROMs(r io.Reader, partial func([]ROM)) ([]ROM, error) {
+	dec := json.NewDecoder(r)
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return nil, errors.New("expected a JSON array of games")
+	}
+	var games []ROM
+	for dec.More() {
+		var game ROM
+		if err := dec.Decode(&game); err != nil {
+			return nil, err
+		}
+		games = append(games, game)
+		if len(games)%gameLoadBatch == 0 {
+			partial(games[:len(games):len(games)])
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return games, nil
+}
+
+// prepareGames turns decoded set entries into the list shown for a system:
+// entries that can't be saved as a file are dropped (and logged when warn is
+// set, so a partial batch doesn't log them twice), discs are grouped, and
+// the lower-case names are worked out once here rather than on every search
+// keystroke
+func prepareGames(sysID string, loaded []ROM, warn bool) ([]ROM, []string) {
+	games := make([]ROM, 0, len(loaded))
+	for _, game := range loaded {
+		if !validROMName(game.Name) {
+			if warn {
+				logWarn("Skipping set entry with invalid name %q in %s", game.Name, systems[sysID].RomJsonFile)
+			}
+			continue
+		}
+		game.System = sysID
+		games = append(games, game)
+	}
+	games = groupDiscs(games)
+
+	lowerNames := make([]string, len(games))
+	for i, game := range games {
+		lowerNames[i] = strings.ToLower(game.Name)
+	}
+	return games, lowerNames
+}
+
+// loadSystemGames reads a system's set and scans its roms folder. It
+// publishes the games and the downloaded cache itself, under their locks, and
+// leaves filtering and selecting to the event goroutine with runOnEvents.
+// load is the gamesLoad value it was started for; if another system is
+// selected meanwhile, the results are dropped.
+func (a *App) loadSystemGames(load uint64, sysID string) {
+	config := systems[sysID]
+	rel := filepath.Join("1g1rsets", config.RomJsonFile)
+	current := func() bool { return a.gamesLoad.Load() == load }
+
+	f, err := openDataFile(rel)
+	var loaded []ROM
+	if err == nil {
+		loaded, err = streamROMs(f, func(partial []ROM) {
+			if !current() {
+				return
+			}
+			games, lowerNames := prepareGames(sysID, partial, false)
+			if !a.publishLoadedGames(load, games, lowerNames) {
+				return
+			}
+			runOnEvents(func() {
+				if !current() {
+					return
+				}
+				a.refilterKeepingSelection()
+				a.statusBar.SetText(fmt.Sprintf("Loading %s... %d games", config.Name, len(games)))
+			})
+		})
+		f.Close()
+	}
+	if err != nil {
+		runOnEvents(func() {
+			if !current() {
+				return
+			}
+			a.statusBar.SetText(fmt.Sprintf("Error: %v", err))
+			logError("Reading %s: %v", rel, err)
+			a.restoreGame = ""
+			a.filterGames()
+			a.showSetError(config, err)
+		})
+		return
+	}
+	if !current() {
+		return
+	}
+
+	games, lowerNames := prepareGames(sysID, loaded, true)
+	romCache := make(map[string]bool)
+	romBadges := make(map[string]string)
+	scanROMs(sysID, games, romCache, romBadges)
+	logDebug("Loaded %d games for %s", len(games), sysID)
+	if !a.publishLoadedGames(load, games, lowerNames) || !a.publishROMCache(load, sysID, romCache, romBadges) {
+		return
+	}
+
+	runOnEvents(func() {
+		if !current() {
+			return
+		}
+		a.refilterKeepingSelection()
+		if name := a.restoreGame; name != "" {
+			a.restoreGame = ""
+			for i, game := range a.shownGames() {
+				if game.Name == name {
+					a.selectGame(i)
+					break
+				}
+			}
+			a.rememberSelection()
+		}
+		a.updateRetryButton()
+		a.updateDebugPanel()
+		a.updateLibraryLabel()
+	})
+}
+
+// refilterKeepingSelection re-runs the search and filters over the loaded
+// games, staying on the selected game if it's still listed
+func (a *App) refilterKeepingSelection() {
+	selected, ok := a.selectedGame()
+	a.filterGames()
+	if !ok {
+		return
+	}
+	for i, game := range a.shownGames() {
+		if game.Name == selected.Name {
+			if i != a.selectedIndex() {
+				a.selectGame(i)
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+// The game lists are changed on Fyne's event goroutine (see runOnEvents) and
+// by loadSystemGames as a set is read, and read by Fyne's list callbacks,
+// which also run on its render goroutine when the window is redrawn. They're
+// only touched through these methods, under gamesMu. A published slice is never modified again - filtering and loading
+// build a new one and swap it in - so a caller may keep using the slice it
+// got after the lock is released.
+
+// loadedGames returns the current system's games and their lower-case names
+func (a *App) loadedGames() (games []ROM, lowerNames []string) {
+	a.gamesMu.RLock()
+	defer a.gamesMu.RUnlock()
+	return a.allGames, a.lowerNames
+}
+
+// setLoadedGames replaces the current system's games
+func (a *App) setLoadedGames(games []ROM, lowerNames []string) {
+	a.gamesMu.Lock()
+	a.allGames = games
+	a.lowerNames = lowerNames
+	a.gamesMu.Unlock()
+}
+
+// publishLoadedGames replaces the current system's games with those read by
+// the loader started for load. It reports false, and leaves them be, if
+// another system has been selected since.
+func (a *App) publishLoadedGames(load uint64, games []ROM, lowerNames []string) bool {
+	a.gamesMu.Lock()
+	defer a.gamesMu.Unlock()
+	if a.gamesLoad.Load() != load {
+		return false
+	}
+	a.allGames = games
+	a.lowerNames = lowerNames
+	return true
+}
+
+// shownGames returns the games listed in the browser, after search and filters
+func (a *App) shownGames() []ROM {
+	a.gamesMu.RLock()
+	defer a.gamesMu.RUnlock()
+	return a.filteredGames
+}
+
+// setShownGames replaces the games listed in the browser and their groups of
+// revisions, together so the list never draws one with the other's labels
+func (a *App) setShownGames(games []ROM, revisions revisionGroups) {
+	a.gamesMu.Lock()
+	a.filteredGames = games
+	a.revisions = revisions
+	a.gamesMu.Unlock()
+}
+
+// selectedIndex returns the index of the selected game in shownGames
+func (a *App) selectedIndex() int {
+	a.gamesMu.RLock()
+	defer a.gamesMu.RUnlock()
+	return a.selectedGameIdx
+}
+
+func (a *App) setSelectedIndex(idx int) {
+	a.gamesMu.Lock()
+	a.selectedGameIdx = idx
+	a.gamesMu.Unlock()
+}
+
+// selectedGame returns the selected game, if one is selected
+func (a *App) selectedGame() (ROM, bool) {
+	a.gamesMu.RLock()
+	defer a.gamesMu.RUnlock()
+	if a.selectedGameIdx < 0 || a.selectedGameIdx >= len(a.filteredGames) {
+		return ROM{}, false
+	}
+	return a.filteredGames[a.selectedGameIdx], true
+}
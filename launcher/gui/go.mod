@@ -5,6 +5,8 @@ go 1.19
 require (
 	fyne.io/fyne/v2 v2.2.0
 	github.com/0xcafed00d/joystick v1.0.1
+	github.com/emubuddy/download v0.0.0
+	github.com/fsnotify/fsnotify v1.7.0
 	github.com/jbenet/go-context v0.0.0-20150711004518-d14ea06fba99
 	golang.org/x/crypto v0.23.0
 	golang.org/x/sync v0.7.0
@@ -14,7 +16,6 @@ require (
 	fyne.io/systray v1.11.0 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/fredbi/uri v1.1.0 // indirect
-	github.com/fsnotify/fsnotify v1.7.0 // indirect
 	github.com/fyne-io/gl-js v0.2.0 // indirect
 	github.com/fyne-io/glfw-js v0.0.0-20241126112943-313d8a0fe1d0 // indirect
 	github.com/fyne-io/image v0.0.0-20220602074514-4956b0afb3d2 // indirect
@@ -38,3 +39,5 @@ require (
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 	honnef.co/go/js/dom v0.0.0-20210725211120-f030747120f2 // indirect
 )
+
+replace github.com/emubuddy/download => ../../pkg/download
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Grid (cover) view layout
+const (
+	gridTileWidth  = 150
+	gridTileHeight = 190
+	gridCoverSize  = 130
+	gridPageRows   = 12 // rows of tiles built at once; the page follows the selection
+)
+
+// gameTile is one cover in the grid view. Like TappableListItem, a tap
+// selects and a double-tap launches.
+type gameTile struct {
+	widget.BaseWidget
+	cover     *canvas.Image
+	name      *canvas.Text
+	status    *canvas.Text
+	highlight *canvas.Rectangle
+
+	index       int
+	onTap       func(int)
+	onDoubleTap func(int)
+}
+
+func newGameTile(onTap, onDoubleTap func(int)) *gameTile {
+	t := &gameTile{
+		cover:       &canvas.Image{FillMode: canvas.ImageFillContain},
+		name:        canvas.NewText("", theme.ForegroundColor()),
+		status:      canvas.NewText("", theme.ForegroundColor()),
+		highlight:   &canvas.Rectangle{FillColor: color.Transparent, StrokeWidth: 2},
+		onTap:       onTap,
+		onDoubleTap: onDoubleTap,
+	}
+	t.cover.SetMinSize(fyne.NewSize(gridCoverSize, gridCoverSize))
+	t.name.TextSize = 12
+	t.name.Alignment = fyne.TextAlignCenter
+	t.status.TextSize = 11
+	t.status.Alignment = fyne.TextAlignCenter
+	t.ExtendBaseWidget(t)
+	return t
+}
+
+func (t *gameTile) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(container.NewMax(
+		t.highlight,
+		container.NewBorder(nil, container.NewVBox(t.name, t.status), nil, nil, t.cover),
+	))
+}
+
+// Tapped selects the tile on every click; the second click of a double-click
+// (see doubleTaps) also calls onDoubleTap
+func (t *gameTile) Tapped(e *fyne.PointEvent) {
+	if t.onTap != nil {
+		t.onTap(t.index)
+	}
+	if doubleTaps.tap(fmt.Sprintf("grid/%d", t.index)) && t.onDoubleTap != nil {
+		t.onDoubleTap(t.index)
+	}
+}
+
+func (t *gameTile) TappedSecondary(e *fyne.PointEvent) {}
+
+// buildGameGrid creates the grid view. Only one page of tiles exists at a
+// time so large systems stay responsive.
+func (a *App) buildGameGrid() fyne.CanvasObject {
+	a.gameGrid = container.NewGridWrap(fyne.NewSize(gridTileWidth, gridTileHeight))
+	a.gridScroll = container.NewVScroll(a.gameGrid)
+	a.gridPageStart = -1
+	return a.gridScroll
+}
+
+// setGridMode switches the game panel between the list and the grid
+func (a *App) setGridMode(grid bool) {
+	a.gridMode = grid
+	if grid {
+		a.gameList.Hide()
+		a.gridScroll.Show()
+	} else {
+		a.gridScroll.Hide()
+		a.gameList.Show()
+	}
+	a.gridPageStart = -1
+	a.selectGame(a.selectedIndex())
+	a.refreshGameView()
+}
+
+// gridColumns is how many tiles fit across the grid at its current width
+func (a *App) gridColumns() int {
+	width := a.gridScroll.Size().Width
+	cols := int((width + theme.Padding()) / (gridTileWidth + theme.Padding()))
+	if cols < 1 {
+		return 1
+	}
+	return cols
+}
+
+// refreshGameView redraws whichever game view is showing
+func (a *App) refreshGameView() {
+	a.gameList.Refresh()
+	if a.gridMode {
+		a.refreshGameGrid()
+	}
+}
+
+// refreshGameGrid fills the current page of tiles and keeps the selected
+// tile scrolled into view
+func (a *App) refreshGameGrid() {
+	games := a.shownGames()
+	selected := a.selectedIndex()
+	cols := a.gridColumns()
+	pageSize := cols * gridPageRows
+	pageStart := 0
+	if selected > 0 && selected < len(games) {
+		pageStart = selected / pageSize * pageSize
+	}
+	pageEnd := pageStart + pageSize
+	if pageEnd > len(games) {
+		pageEnd = len(games)
+	}
+
+	for len(a.gridTiles) < pageEnd-pageStart {
+		a.gridTiles = append(a.gridTiles, newGameTile(
+			func(idx int) {
+				a.focusOnGames = true
+				a.selectGame(idx)
+				a.systemList.Refresh()
+			},
+			a.launchOnDoubleTap,
+		))
+	}
+
+	objects := make([]fyne.CanvasObject, 0, pageEnd-pageStart)
+	for i := pageStart; i < pageEnd; i++ {
+		tile := a.gridTiles[i-pageStart]
+		a.updateGameTile(tile, games[i], i, i == selected)
+		objects = append(objects, tile)
+	}
+	a.gameGrid.Objects = objects
+	a.gameGrid.Refresh()
+
+	if pageStart != a.gridPageStart {
+		a.gridPageStart = pageStart
+		a.gridScroll.Offset = fyne.NewPos(0, 0)
+	}
+
+	// Keep the selected row visible
+	if selected >= pageStart && selected < pageEnd {
+		rowHeight := float32(gridTileHeight) + theme.Padding()
+		top := float32((selected-pageStart)/cols) * rowHeight
+		view := a.gridScroll.Size().Height
+		if top < a.gridScroll.Offset.Y {
+			a.gridScroll.Offset.Y = top
+		} else if top+rowHeight > a.gridScroll.Offset.Y+view {
+			a.gridScroll.Offset.Y = top + rowHeight - view
+		}
+	}
+	a.gridScroll.Refresh()
+}
+
+func (a *App) updateGameTile(tile *gameTile, game ROM, idx int, selected bool) {
+	tile.index = idx
+
+	name := gameDisplayName(game)
+	name = strings.TrimSuffix(name, ".chd")
+	if idx := strings.Index(name, " ("); idx > 0 {
+		name = name[:idx] // tags don't fit under a cover
+	}
+	if a.isFavorite(game) {
+		name = "[FAV] " + name
+	}
+	if a.isChecked(game) {
+		name = "✓ " + name
+	}
+	if len(name) > 22 {
+		name = name[:19] + "..."
+	}
+	tile.name.Text = name
+	tile.name.Color = theme.ForegroundColor()
+	tile.name.Refresh()
+
+	if a.isDownloaded(game) {
+		tile.status.Text = "[Ready] " + game.Size
+	} else {
+		tile.status.Text = "[DL] " + game.Size
+	}
+	if label := a.revisionLabel(game); label != "" {
+		tile.status.Text += " " + label
+	}
+	tile.status.Color = theme.ForegroundColor()
+	tile.status.Refresh()
+
+	a.updateBoxArt(tile.cover, game)
+	if !tile.cover.Visible() {
+		// No art: keep the tile's shape so the grid stays aligned
+		tile.cover.Show()
+	}
+
+	if a.focusOnGames && selected {
+		tile.highlight.StrokeColor = theme.PrimaryColor()
+	} else {
+		tile.highlight.StrokeColor = color.Transparent
+	}
+	tile.highlight.Refresh()
+}
+
+// selectGame moves the shared game selection, in whichever view is showing
+func (a *App) selectGame(idx int) {
+	if idx < 0 || idx >= len(a.shownGames()) {
+		return
+	}
+	if a.gridMode {
+		a.setSelectedIndex(idx)
+		a.rememberSelection()
+		a.updateStatus()
+		a.updateLaunchButton()
+		a.refreshGameGrid()
+		return
+	}
+	a.gameList.Select(idx)
+}
+
+// moveGameSelection moves the selection by rows and columns. The list has a
+// single column, so only rows apply there. It reports whether the selection
+// moved, which it doesn't at either end of the list.
+func (a *App) moveGameSelection(dx, dy int) bool {
+	count := len(a.shownGames())
+	if count == 0 {
+		return false
+	}
+	step := dy
+	if a.gridMode {
+		step = dy*a.gridColumns() + dx
+	}
+	current := a.selectedIndex()
+	idx := current + step
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= count {
+		idx = count - 1
+	}
+	if idx == current {
+		return false
+	}
+	a.selectGame(idx)
+	return true
+}
+
+// atGridLeftEdge reports whether the selection is in the grid's first column,
+// where Left moves focus back to the systems list as it does in the list view
+func (a *App) atGridLeftEdge() bool {
+	return a.selectedIndex()%a.gridColumns() == 0
+}
+
+// moveHorizontal handles left (dx < 0) and right from the arrow keys and
+// D-pad. Left focuses the systems list and right the games; in the grid they
+// move between columns, and left only leaves the grid from its first column.
+func (a *App) moveHorizontal(dx int) {
+	switch {
+	case a.focusOnGames && a.gridMode && (dx > 0 || !a.atGridLeftEdge()):
+		a.moveGameSelection(dx, 0)
+	case dx < 0 && a.focusOnGames:
+		a.focusOnGames = false
+		a.systemList.Refresh()
+		a.refreshGameView()
+	case dx > 0 && !a.focusOnGames:
+		a.focusOnGames = true
+		if len(a.shownGames()) > 0 && a.selectedIndex() < 0 {
+			a.selectGame(0)
+		}
+		a.systemList.Refresh()
+		a.refreshGameView()
+	}
+}
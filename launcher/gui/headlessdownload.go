@@ -0,0 +1,302 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/emubuddy/gui/wiiu"
+)
+
+// consoleBarWidth is how many characters the stderr progress bar takes
+const consoleBarWidth = 30
+
+// downloadROMHeadless downloads one game without the GUI, for use over SSH.
+// The game is given by name (exact, or a part of it that only one game has)
+// or by its position in the set, starting at 1. Progress goes to stderr and
+// the downloaded file's path to stdout.
+// Usage: --download <system> <game-name-or-index>
+func downloadROMHeadless(sysID, query string) {
+	if sysID == "" || query == "" {
+		fmt.Fprintf(os.Stderr, "Usage: %s --download <system> <game-name-or-index>\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "Available systems:", allSystemsList)
+		os.Exit(1)
+	}
+	config, exists := systems[sysID]
+	if !exists {
+		fmt.Fprintf(os.Stderr, "Error: unknown system '%s'\n", sysID)
+		fmt.Fprintln(os.Stderr, "Available systems:", allSystemsList)
+		os.Exit(1)
+	}
+
+	games, err := loadSystemROMs(sysID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: can't read %s: %v\n", config.RomJsonFile, err)
+		os.Exit(1)
+	}
+	game, err := findHeadlessGame(games, query)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	game.System = sysID
+
+	// Ctrl+C stops the download; the .part file is kept so running the same
+	// command again resumes it
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	path, err := fetchGameHeadless(ctx, config, game)
+	if ctx.Err() != nil {
+		fmt.Fprintln(os.Stderr, "\nCancelled; run the same command again to resume")
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "\nError: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(path)
+}
+
+// findHeadlessGame picks the game a --download query names
+func findHeadlessGame(games []ROM, query string) (ROM, error) {
+	if n, err := strconv.Atoi(query); err == nil {
+		if n < 1 || n > len(games) {
+			return ROM{}, fmt.Errorf("game %d is out of range, the set has %d games", n, len(games))
+		}
+		return games[n-1], nil
+	}
+
+	lower := strings.ToLower(query)
+	var matches []int
+	for i, game := range games {
+		name := strings.ToLower(game.Name)
+		if name == lower || strings.ToLower(trimArchiveExt(game.Name)) == lower {
+			return game, nil
+		}
+		if strings.Contains(name, lower) {
+			matches = append(matches, i)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return ROM{}, fmt.Errorf("no game matches %q", query)
+	case 1:
+		return games[matches[0]], nil
+	}
+
+	lines := []string{fmt.Sprintf("%d games match %q, give the full name or its number:", len(matches), query)}
+	for i, idx := range matches {
+		if i == 10 {
+			lines = append(lines, fmt.Sprintf("  ... and %d more", len(matches)-i))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("  %d  %s", idx+1, games[idx].Name))
+	}
+	return ROM{}, errors.New(strings.Join(lines, "\n"))
+}
+
+// fetchGameHeadless downloads, extracts and verifies a game like the queue
+// does, and returns the path of the result. A game that's already on disk
+// isn't downloaded again.
+func fetchGameHeadless(ctx context.Context, config SystemConfig, game ROM) (string, error) {
+	romDir := filepath.Join(romsDir, config.Dir)
+	os.MkdirAll(romDir, 0755)
+
+	if config.isWiiU() && game.TitleID != "" {
+		return fetchWiiUHeadless(ctx, config, game)
+	}
+	if path := findROMFile(config, romDir, game); path != "" {
+		fmt.Fprintln(os.Stderr, "Already downloaded")
+		return path, nil
+	}
+	if game.URL == "" {
+		return "", fmt.Errorf("%s has no download URL in %s", game.Name, config.RomJsonFile)
+	}
+
+	fmt.Fprintf(os.Stderr, "Downloading %s\n", game.Name)
+	outputPath := filepath.Join(romDir, game.Name)
+	meter := newTransferMeter()
+	bar := &consoleProgress{}
+	err := downloadFromMirrors(ctx, downloadURLs(game, config), outputPath, func(downloaded, total int64) {
+		meter.update(downloaded)
+		if total > 0 {
+			bar.show(float64(downloaded)/float64(total), progressText(downloaded, total, meter.speed()), downloaded >= total)
+		}
+	}, func(url string) {
+		bar.note("Trying mirror " + urlHost(url))
+	})
+	bar.done()
+	if err != nil {
+		return "", err
+	}
+
+	path := outputPath
+	if config.NeedsExtract && archiveExt(game.Name) != "" {
+		fmt.Fprintln(os.Stderr, "Extracting...")
+		bar = &consoleProgress{}
+		extractedPath, err := extractArchive(ctx, outputPath, romDir, func(done, total int64) {
+			if total > 0 {
+				bar.show(float64(done)/float64(total), fmt.Sprintf("%s / %s", formatBytes(done), formatBytes(total)), done >= total)
+			}
+		})
+		bar.done()
+		os.Remove(outputPath)
+		if err != nil {
+			return "", err
+		}
+		path = extractedPath
+	}
+
+	if path != "" && game.hasHashes() && !settings.SkipHashVerification {
+		fmt.Fprintln(os.Stderr, "Verifying...")
+		if err := verifyROMFile(path, game); err != nil {
+			os.Remove(path)
+			return "", fmt.Errorf("%w: %v", errVerifyFailed, err)
+		}
+	}
+	if found := findROMFile(config, romDir, game); found != "" {
+		path = found
+	}
+	return path, nil
+}
+
+// fetchWiiUHeadless downloads and decrypts a Wii U title into its folder
+func fetchWiiUHeadless(ctx context.Context, config SystemConfig, game ROM) (string, error) {
+	titleDir := filepath.Join(romsDir, config.Dir, sanitizeFileName(game.Name))
+	os.MkdirAll(titleDir, 0755)
+
+	reporter := newConsoleWiiUReporter()
+
+	fmt.Fprintf(os.Stderr, "Downloading %s from Nintendo CDN\n", game.Name)
+	err := wiiu.DownloadTitle(ctx, game.TitleID, titleDir, true, reporter, true, newHTTPClient(0))
+	reporter.bar.done()
+	if err != nil {
+		return "", err
+	}
+	return titleDir, nil
+}
+
+// consoleProgress draws a progress bar on one stderr line
+type consoleProgress struct {
+	throttle uiThrottle
+	mu       sync.Mutex
+	drawn    bool
+}
+
+func (p *consoleProgress) show(fraction float64, text string, force bool) {
+	if !p.throttle.ready(force) {
+		return
+	}
+	if fraction > 1 {
+		fraction = 1
+	}
+	filled := int(fraction * consoleBarWidth)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(os.Stderr, "\r[%s%s] %5.1f%% %s\033[K", strings.Repeat("#", filled), strings.Repeat("-", consoleBarWidth-filled), fraction*100, text)
+	p.drawn = true
+}
+
+// note prints a message on its own line, below the bar
+func (p *consoleProgress) note(text string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.drawn {
+		fmt.Fprintln(os.Stderr)
+		p.drawn = false
+	}
+	fmt.Fprintln(os.Stderr, text)
+}
+
+// done ends the bar's line
+func (p *consoleProgress) done() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.drawn {
+		fmt.Fprintln(os.Stderr)
+		p.drawn = false
+	}
+}
+
+// consoleWiiUReporter is the wiiu.ProgressReporter for headless downloads,
+// counting bytes the same way WiiUProgressReporter does
+type consoleWiiUReporter struct {
+	bar          consoleProgress
+	mu           sync.Mutex
+	downloadSize int64
+	fileProgress map[string]int64
+	doneFiles    map[string]bool
+}
+
+func newConsoleWiiUReporter() *consoleWiiUReporter {
+	return &consoleWiiUReporter{
+		fileProgress: make(map[string]int64),
+		doneFiles:    make(map[string]bool),
+	}
+}
+
+func (r *consoleWiiUReporter) SetGameTitle(title string) {}
+
+func (r *consoleWiiUReporter) UpdateDownloadProgress(downloaded int64, filename string) {
+	r.mu.Lock()
+	if !r.doneFiles[filename] {
+		r.fileProgress[filename] = downloaded
+	}
+	r.mu.Unlock()
+	r.showDownloadProgress(false)
+}
+
+func (r *consoleWiiUReporter) showDownloadProgress(force bool) {
+	r.mu.Lock()
+	var total int64
+	for _, v := range r.fileProgress {
+		total += v
+	}
+	size, done := r.downloadSize, len(r.doneFiles)
+	r.mu.Unlock()
+	if size > 0 {
+		text := fmt.Sprintf("%.1f MB / %.1f MB (%d files done)", float64(total)/1024/1024, float64(size)/1024/1024, done)
+		r.bar.show(float64(total)/float64(size), text, force)
+	}
+}
+
+func (r *consoleWiiUReporter) UpdateDecryptionProgress(progress float64) {
+	r.bar.show(progress, "Decrypting", progress >= 1)
+}
+
+func (r *consoleWiiUReporter) SetDownloadSize(size int64) {
+	r.mu.Lock()
+	r.downloadSize = size
+	r.mu.Unlock()
+}
+
+func (r *consoleWiiUReporter) ResetTotals() {
+	r.mu.Lock()
+	r.fileProgress = make(map[string]int64)
+	r.doneFiles = make(map[string]bool)
+	r.mu.Unlock()
+}
+
+func (r *consoleWiiUReporter) MarkFileAsDone(filename string) {
+	r.mu.Lock()
+	r.doneFiles[filename] = true
+	r.mu.Unlock()
+	r.showDownloadProgress(true)
+}
+
+func (r *consoleWiiUReporter) SetTotalDownloadedForFile(filename string, downloaded int64) {
+	r.mu.Lock()
+	r.fileProgress[filename] = downloaded
+	delete(r.doneFiles, filename)
+	r.mu.Unlock()
+}
+
+func (r *consoleWiiUReporter) SetStartTime(startTime time.Time) {}
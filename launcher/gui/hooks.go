@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Launch hooks are shell commands run before an emulator starts and after it
+// exits, e.g. to switch a cabinet's display mode. They're set globally in
+// settings.json (preLaunch/postLaunch) or per system (systemHooks), and may
+// use these placeholders, which are quoted for the shell.
+const (
+	hookROM      = "{rom}"      // full path to the launched file
+	hookSystem   = "{system}"   // system ID, e.g. "ps2"
+	hookEmulator = "{emulator}" // resolved emulator path
+	hookGame     = "{game}"     // game name as listed in the set
+
+	defaultHookTimeout = 10 * time.Second
+)
+
+// LaunchHooks are the hook commands for one system; an empty one falls back
+// to the global setting
+type LaunchHooks struct {
+	PreLaunch  string `json:"preLaunch,omitempty"`
+	PostLaunch string `json:"postLaunch,omitempty"`
+}
+
+// launchHooks returns the pre- and post-launch commands for a system
+func launchHooks(sysID string) LaunchHooks {
+	hooks := LaunchHooks{PreLaunch: settings.PreLaunch, PostLaunch: settings.PostLaunch}
+	if sys, ok := settings.SystemHooks[sysID]; ok {
+		if sys.PreLaunch != "" {
+			hooks.PreLaunch = sys.PreLaunch
+		}
+		if sys.PostLaunch != "" {
+			hooks.PostLaunch = sys.PostLaunch
+		}
+	}
+	return hooks
+}
+
+func hookTimeout() time.Duration {
+	if settings.HookTimeoutSeconds > 0 {
+		return time.Duration(settings.HookTimeoutSeconds) * time.Second
+	}
+	return defaultHookTimeout
+}
+
+// shellQuote quotes a value so the shell passes it as one argument
+func shellQuote(s string) string {
+	if runtime.GOOS == "windows" {
+		return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// runLaunchHook runs a hook command through the shell, waiting for it up to
+// the hook timeout. Its output goes to the debug log. An empty command does
+// nothing.
+func runLaunchHook(name, command, sysID string, game ROM, romPath, emuPath string) error {
+	if strings.TrimSpace(command) == "" {
+		return nil
+	}
+	command = strings.NewReplacer(
+		hookROM, shellQuote(romPath),
+		hookSystem, shellQuote(sysID),
+		hookEmulator, shellQuote(emuPath),
+		hookGame, shellQuote(game.Name),
+	).Replace(command)
+
+	ctx, cancel := context.WithTimeout(context.Background(), hookTimeout())
+	defer cancel()
+	cmd := shellCommand(ctx, command)
+	logInfo("%s hook: %s", name, command)
+	out, err := cmd.CombinedOutput()
+	if len(out) > 0 {
+		logDebug("%s hook output: %s", name, strings.TrimSpace(string(out)))
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %s", hookTimeout())
+	}
+	if err != nil {
+		logWarn("%s hook failed: %v", name, err)
+		return fmt.Errorf("%s hook failed: %w", name, err)
+	}
+	return nil
+}
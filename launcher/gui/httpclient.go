@@ -0,0 +1,49 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/emubuddy/download"
+)
+
+// proxyFromSettings is the Proxy of the launcher's own HTTP clients: the
+// proxy setting if there is one, otherwise the environment's HTTP_PROXY,
+// HTTPS_PROXY and NO_PROXY. It reads the setting on every request, so a
+// change applies without restarting.
+func proxyFromSettings(req *http.Request) (*url.URL, error) {
+	proxy, err := download.ProxyFunc(settings.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	return proxy(req)
+}
+
+// newHTTPClient returns a client for requests made outside the download
+// package (box art, Wii U titles, URL checks) that honours the proxy and
+// hostHeaders settings
+func newHTTPClient(timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxyFromSettings
+	return &http.Client{Timeout: timeout, Transport: hostHeaderTransport{transport}}
+}
+
+// hostHeaderTransport adds the hostHeaders set for a request's host,
+// replacing any the caller set
+type hostHeaderTransport struct {
+	next http.RoundTripper
+}
+
+func (t hostHeaderTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	header := hostHeadersFor(req.URL.String())
+	if len(header) == 0 {
+		return t.next.RoundTrip(req)
+	}
+	// A RoundTripper mustn't change the caller's request
+	req = req.Clone(req.Context())
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,68 @@
+package main
+
+// rememberSelection records the current system, game and search in settings.
+// They're written to settings.json when the system changes and on exit, so
+// scrolling through games doesn't rewrite the file on every step.
+func (a *App) rememberSelection() {
+	if a.restoringSelection || a.restoreGame != "" {
+		return
+	}
+	settings.LastSystem = a.currentSystem
+	settings.LastSearch = a.searchQuery
+	settings.LastGame = ""
+	if game, ok := a.selectedGame(); ok {
+		settings.LastGame = game.Name
+	}
+}
+
+// restoreSelection reopens the system, search and game the launcher was
+// closed on. A system that's gone or hidden falls back to the first one,
+// and a game that's no longer listed to the top of the list.
+func (a *App) restoreSelection() {
+	if len(systemsList) == 0 {
+		return
+	}
+
+	sysIdx := -1
+	for i, id := range systemsList {
+		if id == settings.LastSystem {
+			sysIdx = i
+			break
+		}
+	}
+	if sysIdx < 0 {
+		if settings.LastSystem != "" {
+			logInfo("Last system %q is no longer shown, starting on %s", settings.LastSystem, systemsList[0])
+		}
+		a.systemList.Select(0)
+		return
+	}
+
+	lastGame := settings.LastGame
+	a.restoringSelection = true
+	if settings.LastSearch != "" {
+		// Set the box without its OnChanged, whose debounced filter would
+		// reset the game selection after it's been restored
+		onChanged := a.searchEntry.OnChanged
+		a.searchEntry.OnChanged = nil
+		a.searchEntry.SetText(settings.LastSearch)
+		a.searchEntry.OnChanged = onChanged
+		a.searchQuery = settings.LastSearch
+	}
+	a.systemList.Select(sysIdx)
+	a.restoringSelection = false
+
+	// The set loads in the background; the game is selected once it's in.
+	// Recently Played is listed straight away.
+	a.restoreGame = lastGame
+	if len(a.shownGames()) > 0 || lastGame == "" {
+		a.restoreGame = ""
+		for i, game := range a.shownGames() {
+			if game.Name == lastGame {
+				a.selectGame(i)
+				break
+			}
+		}
+		a.rememberSelection()
+	}
+}
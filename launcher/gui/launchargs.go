@@ -0,0 +1,46 @@
+package main
+
+import "strings"
+
+// Placeholders emulator and core args in systems.json may contain. They're
+// replaced when a game is launched, e.g. ["{rom}", "--fullscreen"].
+const (
+	argROM       = "{rom}"        // full path to the game file (or Wii U .rpx)
+	argCore      = "{core}"       // resolved path of the RetroArch core passed with -L
+	argSystemDir = "{system_dir}" // the system's ROM folder, roms/<dir>
+)
+
+// hasArgPlaceholder reports whether an arg uses a launch placeholder. Such
+// args are passed through as-is instead of being resolved as paths.
+func hasArgPlaceholder(arg string) bool {
+	return strings.Contains(arg, argROM) || strings.Contains(arg, argCore) || strings.Contains(arg, argSystemDir)
+}
+
+// expandLaunchArgs substitutes the placeholders in an emulator command line.
+// If no arg contains {rom}, the ROM is appended last, as for plain args.
+func expandLaunchArgs(args []string, romPath, systemDir string) []string {
+	corePath := ""
+	for i, arg := range args {
+		if arg == "-L" && i+1 < len(args) {
+			corePath = args[i+1]
+			break
+		}
+	}
+
+	replacer := strings.NewReplacer(argROM, romPath, argCore, corePath, argSystemDir, systemDir)
+	expanded := make([]string, 0, len(args)+1)
+	hasROM := false
+	for _, arg := range args {
+		if strings.Contains(arg, argROM) {
+			hasROM = true
+		}
+		if hasArgPlaceholder(arg) {
+			arg = replacer.Replace(arg)
+		}
+		expanded = append(expanded, arg)
+	}
+	if !hasROM {
+		expanded = append(expanded, romPath)
+	}
+	return expanded
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// launchDebounce is how long after starting an emulator further launches are
+// ignored, so mashing A or a double-click that registers twice can't start a
+// second one even if the first exits straight away
+const launchDebounce = 2 * time.Second
+
+// launchedEmulator is the emulator process the launcher last started
+type launchedEmulator struct {
+	game      string // what it's running, "" once it has exited
+	pid       int
+	startedAt time.Time
+}
+
+// launchBlocked reports whether a launch has to wait, saying why in the
+// status bar: while the last emulator started is still running (its window is
+// brought to the front instead, where that's possible), or within
+// launchDebounce of starting it. Two copies of a game share its saves, which
+// PCSX2 and Dolphin memory cards don't survive.
+func (a *App) launchBlocked() bool {
+	last := a.lastLaunch
+	if last.game != "" {
+		if focusProcessWindow(last.pid) {
+			a.statusBar.SetText(fmt.Sprintf("%s is already running", last.game))
+		} else {
+			a.statusBar.SetText(fmt.Sprintf("%s is still running; close it before launching again", last.game))
+		}
+		logDebug("Launch refused: %s still running (pid %d)", last.game, last.pid)
+		return true
+	}
+	if time.Since(last.startedAt) < launchDebounce {
+		logDebug("Launch ignored: the last one started %s ago", time.Since(last.startedAt).Round(time.Millisecond))
+		return true
+	}
+	return false
+}
+
+// trackLaunch records the emulator just started for game
+func (a *App) trackLaunch(game ROM, pid int) {
+	a.lastLaunch = launchedEmulator{game: trimArchiveExt(game.Name), pid: pid, startedAt: time.Now()}
+}
+
+// launchExited lets launches through again once the emulator started with
+// pid has exited
+func (a *App) launchExited(pid int) {
+	if a.lastLaunch.pid == pid {
+		a.lastLaunch.game = ""
+	}
+}
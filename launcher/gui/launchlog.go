@@ -0,0 +1,249 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Every launch writes the emulator's output to its own file in
+// emulator_logs, whatever the log level, so a launch that fails straight
+// away can show why. Only the newest keptLaunchLogs files are kept.
+const (
+	launchLogDirName = "emulator_logs"
+	keptLaunchLogs   = 10
+
+	// quickExitTime is how soon an emulator must exit with an error for the
+	// launch to count as failed rather than the game having been played
+	quickExitTime = 2 * time.Second
+
+	launchLogTailLines = 15   // output lines shown when a launch fails
+	launchLogTailBytes = 4096 // output kept in memory for them
+)
+
+func launchLogDir() string {
+	return filepath.Join(baseDir, launchLogDirName)
+}
+
+// launchLog is one launch's emulator output: the file it's written to and
+// the end of it, kept for the failure dialog
+type launchLog struct {
+	mu   sync.Mutex
+	file *os.File
+	path string
+	tail []byte
+}
+
+// newLaunchLog starts the log for a launch with the command being run. If
+// the file can't be created the output is still kept in memory.
+func newLaunchLog(sysID string, game ROM, command string) *launchLog {
+	l := &launchLog{}
+	dir := launchLogDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		logWarn("Emulator log folder %s: %v", dir, err)
+		return l
+	}
+	pruneLaunchLogs(dir)
+
+	l.path = filepath.Join(dir, time.Now().Format("20060102-150405")+"-"+sysID+".log")
+	f, err := os.Create(l.path)
+	if err != nil {
+		logWarn("Emulator log %s: %v", l.path, err)
+		l.path = ""
+		return l
+	}
+	l.file = f
+	fmt.Fprintf(f, "Game: %s\nCommand: %s\n\n", game.Name, command)
+	return l
+}
+
+// pruneLaunchLogs deletes the oldest logs so that, with the one about to be
+// created, keptLaunchLogs are left. The names start with the launch time, so
+// they sort oldest first.
+func pruneLaunchLogs(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	var logs []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".log") {
+			logs = append(logs, entry.Name())
+		}
+	}
+	sort.Strings(logs)
+	for len(logs) >= keptLaunchLogs {
+		os.Remove(filepath.Join(dir, logs[0]))
+		logs = logs[1:]
+	}
+}
+
+// Write saves emulator output. Like the debug log it never fails, so a full
+// disk can't stop the emulator.
+func (l *launchLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file != nil {
+		l.file.Write(p)
+	}
+	l.tail = append(l.tail, p...)
+	if len(l.tail) > launchLogTailBytes {
+		l.tail = append([]byte{}, l.tail[len(l.tail)-launchLogTailBytes:]...)
+	}
+	return len(p), nil
+}
+
+// lastLines returns up to n of the last non-empty lines of output
+func (l *launchLog) lastLines(n int) []string {
+	l.mu.Lock()
+	text := string(l.tail)
+	l.mu.Unlock()
+
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r", "\n"), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
+
+func (l *launchLog) Close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file != nil {
+		l.file.Close()
+		l.file = nil
+	}
+}
+
+// commandLine is a launch command as it could be typed in a terminal
+func commandLine(exe string, args []string) string {
+	parts := []string{exe}
+	parts = append(parts, args...)
+	for i, part := range parts {
+		if part == "" || strings.ContainsAny(part, " \t\"'") {
+			parts[i] = strconv.Quote(part)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Markers in emulator output that point to a common cause, matched in
+// lower case
+var (
+	libraryErrorMarkers = []string{"error while loading shared libraries", "library not loaded", "vcruntime", "msvcp"}
+	coreErrorMarkers    = []string{"failed to open libretro core", "failed to load libretro core", "dynamic libretro cores, but path is not set", "could not load core"}
+	contentErrorMarkers = []string{"failed to load content", "could not load content", "content could not be loaded", "invalid rom", "bad dump", "corrupt", "unsupported file", "failed to open rom", "could not open rom"}
+)
+
+// launchHints suggests what to do about a failed launch, from the error, the
+// emulator's output and what's missing on disk. emuPath and emuArgs are the
+// emulator as configured in systems.json, args the resolved command line.
+func launchHints(sysID, emuPath string, emuArgs, args []string, err error, output []string) []string {
+	var hints []string
+	text := strings.ToLower(strings.Join(output, "\n"))
+	containsAny := func(markers []string) bool {
+		for _, marker := range markers {
+			if strings.Contains(text, marker) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	case errors.Is(err, exec.ErrNotFound), errors.Is(err, os.ErrNotExist):
+		hints = append(hints, "The emulator isn't installed where systems.json says: run EmuBuddySetup to install it.")
+	case errors.Is(err, os.ErrPermission):
+		hints = append(hints, "The emulator isn't allowed to run: check it's executable and not blocked by antivirus.")
+	}
+	if containsAny(libraryErrorMarkers) {
+		hints = append(hints, "A library the emulator needs isn't installed; the output names it.")
+	}
+
+	coreMissing := false
+	for _, arg := range args {
+		switch strings.ToLower(filepath.Ext(arg)) {
+		case ".so", ".dll", ".dylib":
+			if !fileExists(arg) {
+				coreMissing = true
+				hints = append(hints, fmt.Sprintf("The core %s isn't installed: run EmuBuddySetup again, or press E to pick another emulator.", filepath.Base(arg)))
+			}
+		}
+	}
+	if containsAny(coreErrorMarkers) && !coreMissing {
+		hints = append(hints, "RetroArch couldn't load the core: run EmuBuddySetup again, or press E to pick another emulator.")
+	}
+
+	if dir, problems := missingBios(systems[sysID], emuPath, emuArgs); len(problems) > 0 {
+		hints = append(hints, fmt.Sprintf("BIOS files are missing (%s); put them in %s.", strings.Join(problems, ", "), dir))
+	} else if strings.Contains(text, "bios") {
+		hints = append(hints, "The emulator mentions its BIOS: check it has the files it needs (see SYSTEMS_CONFIG_GUIDE.md).")
+	}
+
+	if containsAny(contentErrorMarkers) {
+		hints = append(hints, "The game file may be damaged or in a format this emulator doesn't read: delete it and download it again, or press E to try another emulator.")
+	}
+
+	if len(hints) == 0 {
+		hints = append(hints, "Run the command above in a terminal to see the emulator's own error.")
+	}
+	return hints
+}
+
+// showLaunchFailure explains a launch that couldn't start, or whose emulator
+// exited with an error within quickExitTime: what went wrong, the command,
+// the end of the emulator's output and hints for fixing it
+func (a *App) showLaunchFailure(game ROM, summary, command string, output []string, logPath string, hints []string) {
+	heading := widget.NewLabel(summary)
+	heading.Wrapping = fyne.TextWrapWord
+
+	commandLabel := widget.NewLabel(command)
+	commandLabel.TextStyle = fyne.TextStyle{Monospace: true}
+	commandLabel.Wrapping = fyne.TextWrapBreak
+
+	outputText := "(no output)"
+	if len(output) > 0 {
+		outputText = strings.Join(output, "\n")
+	}
+	outputLabel := widget.NewLabel(outputText)
+	outputLabel.TextStyle = fyne.TextStyle{Monospace: true}
+	outputLabel.Wrapping = fyne.TextWrapBreak
+	outputScroll := container.NewVScroll(outputLabel)
+	outputScroll.SetMinSize(fyne.NewSize(560, 160))
+
+	hintsLabel := widget.NewLabel("• " + strings.Join(hints, "\n• "))
+	hintsLabel.Wrapping = fyne.TextWrapWord
+
+	content := container.NewVBox(heading, widget.NewLabel("Command:"), commandLabel,
+		widget.NewLabel("Emulator output:"), outputScroll, widget.NewSeparator(), hintsLabel)
+	if logPath != "" {
+		logLabel := widget.NewLabel("Full output: " + logPath)
+		logLabel.Wrapping = fyne.TextWrapBreak
+		content.Add(logLabel)
+	}
+
+	a.dialogOpen = true
+	d := dialog.NewCustom("Launch Failed: "+trimArchiveExt(game.Name), "Close", container.NewVScroll(content), a.window)
+	d.SetOnClosed(func() {
+		a.dialogOpen = false
+	})
+	d.Resize(fyne.NewSize(640, 520))
+	d.Show()
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// letterJumpKeys are the quick-jump panel's entries: "#" for names that
+// don't start with a letter, then A-Z, laid out letterJumpColumns a row
+var letterJumpKeys = strings.Split("#ABCDEFGHIJKLMNOPQRSTUVWXYZ", "")
+
+const letterJumpColumns = 9
+
+// gameInitial is the quick-jump entry a game is listed under
+func gameInitial(game ROM) string {
+	name := strings.ToUpper(gameDisplayName(game))
+	if name != "" && name[0] >= 'A' && name[0] <= 'Z' {
+		return name[:1]
+	}
+	return "#"
+}
+
+// buildLetterPanel creates the A-Z quick-jump panel, shown in place of the
+// game list by RB on a controller (see showLetterJump)
+func (a *App) buildLetterPanel() {
+	header := widget.NewLabel("JUMP TO LETTER")
+	header.TextStyle = fyne.TextStyle{Bold: true}
+	hint := widget.NewLabel("D-pad/stick to choose, A to jump, B or RB to go back")
+
+	grid := container.NewGridWithColumns(letterJumpColumns)
+	a.letterButtons = make([]*widget.Button, len(letterJumpKeys))
+	for i, key := range letterJumpKeys {
+		i := i
+		a.letterButtons[i] = widget.NewButton(key, func() {
+			a.letterJumpIdx = i
+			a.confirmLetterJump()
+		})
+		grid.Add(a.letterButtons[i])
+	}
+	a.letterPanel = container.NewBorder(header, hint, nil, nil, container.NewCenter(grid))
+}
+
+// showLetterJump shows the quick-jump panel for the games in the list, in
+// their current sort and filter: letters no shown game starts with are
+// greyed out, and the one the selected game starts with is highlighted.
+func (a *App) showLetterJump() {
+	games := a.shownGames()
+	if a.choosingEmulator || a.choosingLetter || len(games) == 0 {
+		return
+	}
+
+	a.letterPositions = make(map[string]int)
+	for i, game := range games {
+		initial := gameInitial(game)
+		if _, ok := a.letterPositions[initial]; !ok {
+			a.letterPositions[initial] = i
+		}
+	}
+	for i, key := range letterJumpKeys {
+		if _, ok := a.letterPositions[key]; ok {
+			a.letterButtons[i].Enable()
+		} else {
+			a.letterButtons[i].Disable()
+		}
+	}
+
+	a.letterJumpIdx = 0
+	if game, ok := a.selectedGame(); ok {
+		for i, key := range letterJumpKeys {
+			if key == gameInitial(game) {
+				a.letterJumpIdx = i
+			}
+		}
+	}
+	a.choosingLetter = true
+	a.highlightLetter()
+	a.rightPanel.Objects = []fyne.CanvasObject{a.letterPanel}
+	a.rightPanel.Refresh()
+	a.statusBar.SetText(fmt.Sprintf("Jump to a letter in %d games", len(games)))
+}
+
+// moveLetterJump moves the highlighted letter by columns and rows, skipping
+// letters without games. It stays put at the edges.
+func (a *App) moveLetterJump(dx, dy int) {
+	step := dx + dy*letterJumpColumns
+	if step == 0 {
+		return
+	}
+	for idx := a.letterJumpIdx + step; idx >= 0 && idx < len(letterJumpKeys); idx += step {
+		if _, ok := a.letterPositions[letterJumpKeys[idx]]; ok {
+			a.letterJumpIdx = idx
+			a.highlightLetter()
+			return
+		}
+	}
+	a.bumpListEnd()
+}
+
+func (a *App) highlightLetter() {
+	for i, btn := range a.letterButtons {
+		importance := widget.MediumImportance
+		if i == a.letterJumpIdx {
+			importance = widget.HighImportance
+		}
+		if btn.Importance != importance {
+			btn.Importance = importance
+			btn.Refresh()
+		}
+	}
+}
+
+// confirmLetterJump selects the first shown game starting with the
+// highlighted letter and goes back to the game list
+func (a *App) confirmLetterJump() {
+	idx, ok := a.letterPositions[letterJumpKeys[a.letterJumpIdx]]
+	a.closeLetterJump()
+	if !ok || idx >= len(a.shownGames()) {
+		return
+	}
+	a.focusOnGames = true
+	a.selectGame(idx)
+	if !a.gridMode {
+		a.gameList.ScrollTo(idx)
+	}
+	a.systemList.Refresh()
+	a.refreshGameView()
+}
+
+// closeLetterJump goes back to the game list without moving the selection
+func (a *App) closeLetterJump() {
+	if !a.choosingLetter {
+		return
+	}
+	a.choosingLetter = false
+	a.rightPanel.Objects = []fyne.CanvasObject{a.gamePanel}
+	a.rightPanel.Refresh()
+	a.updateStatus()
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// systemUsage is how much disk space one system's roms directory takes
+type systemUsage struct {
+	Bytes int64
+	Files int
+}
+
+// libraryUsage is the result of walking every system's roms directory
+type libraryUsage struct {
+	Systems map[string]systemUsage
+	Bytes   int64
+	Files   int
+	// Free is the space available on the drive holding romsDir, or -1 if unknown
+	Free int64
+}
+
+// libraryScan makes sure only one walk runs at a time; a refresh requested
+// during a walk runs once more when it finishes
+type libraryScan struct {
+	mu      sync.Mutex
+	running bool
+	again   bool
+	usage   *libraryUsage
+}
+
+// scanLibraryUsage walks each system's roms directory, recursing into
+// subdirectories such as Wii U titles. Box art isn't counted.
+func scanLibraryUsage() *libraryUsage {
+	usage := &libraryUsage{Systems: make(map[string]systemUsage), Free: -1}
+
+	// Several systems can share a directory; count it once
+	seenDirs := make(map[string]bool)
+	for _, sysID := range allSystemsList {
+		dir := systems[sysID].Dir
+		if dir == "" || seenDirs[dir] {
+			continue
+		}
+		seenDirs[dir] = true
+
+		var su systemUsage
+		filepath.WalkDir(filepath.Join(romsDir, dir), func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				if d.Name() == "boxart" {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			su.Bytes += info.Size()
+			su.Files++
+			return nil
+		})
+		usage.Systems[sysID] = su
+		usage.Bytes += su.Bytes
+		usage.Files += su.Files
+	}
+
+	// roms/ may not exist yet on a fresh install; the drive is the same either way
+	freeDir := romsDir
+	if !fileExists(freeDir) {
+		freeDir = baseDir
+	}
+	if free, err := diskFree(freeDir); err == nil {
+		usage.Free = free
+	} else {
+		logWarn("Free space unavailable for %s: %v", freeDir, err)
+	}
+	return usage
+}
+
+// refreshLibraryUsage rescans the library in the background and updates the
+// summary in the bottom bar when it's done
+func (a *App) refreshLibraryUsage() {
+	a.library.mu.Lock()
+	if a.library.running {
+		a.library.again = true
+		a.library.mu.Unlock()
+		return
+	}
+	a.library.running = true
+	a.library.mu.Unlock()
+
+	go func() {
+		for {
+			usage := scanLibraryUsage()
+			logDebug("Library scan: %s in %d files, %s free", formatBytes(usage.Bytes), usage.Files, formatFree(usage.Free))
+
+			a.library.mu.Lock()
+			a.library.usage = usage
+			again := a.library.again
+			a.library.again = false
+			if !again {
+				a.library.running = false
+			}
+			a.library.mu.Unlock()
+
+			runOnEvents(a.updateLibraryLabel)
+			if !again {
+				return
+			}
+		}
+	}()
+}
+
+// updateLibraryLabel shows the last scan's totals, plus the current system's share
+func (a *App) updateLibraryLabel() {
+	a.library.mu.Lock()
+	usage := a.library.usage
+	a.library.mu.Unlock()
+	if usage == nil {
+		a.libraryLabel.SetText("Library: scanning...")
+		return
+	}
+
+	text := fmt.Sprintf("Library: %s across %s files", formatBytes(usage.Bytes), formatCount(usage.Files))
+	if su, ok := usage.Systems[a.currentSystem]; ok && su.Files > 0 {
+		text += fmt.Sprintf(" (%s: %s)", systems[a.currentSystem].Name, formatBytes(su.Bytes))
+	}
+	text += ", " + formatFree(usage.Free) + " free"
+	a.libraryLabel.SetText(text)
+}
+
+func formatFree(free int64) string {
+	if free < 0 {
+		return "unknown"
+	}
+	return formatBytes(free)
+}
+
+// formatCount adds thousands separators: 1203 -> "1,203"
+func formatCount(n int) string {
+	if n < 0 {
+		return "-" + formatCount(-n)
+	}
+	s := strconv.Itoa(n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Everything the launcher logs goes to launcher_debug.log through one logger.
+// Lines below the level set in settings.json (logLevel) are dropped, and the
+// file is rotated to launcher_debug.log.1 (then .2) when it passes
+// maxLogSize, so it can't grow forever.
+
+type logLevel int
+
+const (
+	levelDebug logLevel = iota
+	levelInfo
+	levelWarn
+	levelError
+)
+
+const (
+	logFileName  = "launcher_debug.log"
+	maxLogSize   = 5 << 20 // bytes before the file is rotated
+	keptLogFiles = 2       // rotated copies kept
+)
+
+var logLevelNames = map[string]logLevel{
+	"debug": levelDebug,
+	"info":  levelInfo,
+	"warn":  levelWarn,
+	"error": levelError,
+}
+
+func (l logLevel) String() string {
+	switch l {
+	case levelDebug:
+		return "DEBUG"
+	case levelInfo:
+		return "INFO"
+	case levelWarn:
+		return "WARN"
+	default:
+		return "ERROR"
+	}
+}
+
+// currentLogLevel is the lowest level written. Before settings are loaded
+// (or with an unknown logLevel) it's info.
+func currentLogLevel() logLevel {
+	if l, ok := logLevelNames[strings.ToLower(settings.LogLevel)]; ok {
+		return l
+	}
+	return levelInfo
+}
+
+// rotatingLog is the log file, reopened after each rotation
+type rotatingLog struct {
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+var debugLog = &rotatingLog{}
+
+func logPath() string {
+	return filepath.Join(baseDir, logFileName)
+}
+
+// open opens the log file, rotating it first if it's already full; must be
+// called with mu held
+func (l *rotatingLog) open() error {
+	if l.file != nil {
+		return nil
+	}
+	if info, err := os.Stat(logPath()); err == nil && info.Size() >= maxLogSize {
+		rotateLogFiles()
+	}
+	f, err := os.OpenFile(logPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	l.file, l.size = f, info.Size()
+	return nil
+}
+
+// rotateLogFiles shifts launcher_debug.log to .1, .1 to .2 and so on,
+// dropping the oldest
+func rotateLogFiles() {
+	path := logPath()
+	os.Remove(path + "." + strconv.Itoa(keptLogFiles))
+	for i := keptLogFiles - 1; i >= 1; i-- {
+		os.Rename(path+"."+strconv.Itoa(i), path+"."+strconv.Itoa(i+1))
+	}
+	os.Rename(path, path+".1")
+}
+
+// Write appends to the log as-is, rotating when it's full. It's used directly
+// for emulator output and never fails, so a broken log can't stop a launch.
+func (l *rotatingLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file != nil && l.size+int64(len(p)) > maxLogSize {
+		l.file.Close()
+		l.file = nil
+		rotateLogFiles()
+	}
+	if err := l.open(); err != nil {
+		return len(p), nil
+	}
+	n, _ := l.file.Write(p)
+	l.size += int64(n)
+	return len(p), nil
+}
+
+func logAt(level logLevel, format string, args ...interface{}) {
+	if level < currentLogLevel() {
+		return
+	}
+	msg := fmt.Sprintf("[%s] %-5s %s\n", time.Now().Format("2006-01-02 15:04:05.000"), level, fmt.Sprintf(format, args...))
+	debugLog.Write([]byte(msg))
+}
+
+// logDebug logs detail that's only wanted when troubleshooting
+func logDebug(format string, args ...interface{}) { logAt(levelDebug, format, args...) }
+
+// logInfo logs what the launcher did: launches, downloads, deletions
+func logInfo(format string, args ...interface{}) { logAt(levelInfo, format, args...) }
+
+// logWarn logs problems the launcher worked around
+func logWarn(format string, args ...interface{}) { logAt(levelWarn, format, args...) }
+
+// logError logs failures the user sees
+func logError(format string, args ...interface{}) { logAt(levelError, format, args...) }
+
+// emulatorOutput is where an emulator's stdout/stderr go: the log when
+// debugging, otherwise nowhere
+func emulatorOutput() io.Writer {
+	if currentLogLevel() > levelDebug {
+		return nil
+	}
+	return debugLog
+}
@@ -1,18 +1,18 @@
 package main
 
 import (
-	"archive/zip"
-	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"fyne.io/fyne/v2"
@@ -20,29 +20,15 @@ import (
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"github.com/0xcafed00d/joystick"
 
+	"github.com/emubuddy/download"
 	"github.com/emubuddy/gui/wiiu"
 )
 
-// Debug logging
-var debugLog *os.File
-
-func logDebug(format string, args ...interface{}) {
-	if debugLog == nil {
-		var err error
-		debugLog, err = os.OpenFile(filepath.Join(baseDir, "launcher_debug.log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-		if err != nil {
-			return
-		}
-	}
-	msg := fmt.Sprintf("[%s] %s\n", time.Now().Format("15:04:05.000"), fmt.Sprintf(format, args...))
-	debugLog.WriteString(msg)
-	debugLog.Sync()
-}
-
 // FixedSizeWrapper wraps a widget and returns a constant MinSize
 // This prevents the wrapped widget from causing window resizes
 type FixedSizeWrapper struct {
@@ -127,7 +113,8 @@ type TappableListItem struct {
 	list          *widget.List
 	itemID        widget.ListItemID
 	onDoubleTap   func(widget.ListItemID)
-	lastTapTime   time.Time
+	// onSecondaryTap is called after a right-click selects the item
+	onSecondaryTap func(widget.ListItemID)
 }
 
 func NewTappableListItem(content fyne.CanvasObject) *TappableListItem {
@@ -148,29 +135,29 @@ func (t *TappableListItem) CreateRenderer() fyne.WidgetRenderer {
 	return widget.NewSimpleRenderer(t.Content)
 }
 
+// Tapped selects the item on every click; the second click of a
+// double-click (see doubleTaps) also calls onDoubleTap
 func (t *TappableListItem) Tapped(e *fyne.PointEvent) {
-	now := time.Now()
-	
-	// Check for double-tap
-	if now.Sub(t.lastTapTime) < 400*time.Millisecond {
+	if t.list != nil {
+		t.list.Select(t.itemID)
+	}
+	if doubleTaps.tap(fmt.Sprintf("%p/%d", t.list, t.itemID)) {
 		logDebug("Double-tap on item %d!", t.itemID)
 		if t.onDoubleTap != nil {
 			t.onDoubleTap(t.itemID)
 		}
-		t.lastTapTime = time.Time{}
-		return
 	}
-	
-	t.lastTapTime = now
-	
-	// Select this item in the list
+}
+
+func (t *TappableListItem) TappedSecondary(e *fyne.PointEvent) {
 	if t.list != nil {
 		t.list.Select(t.itemID)
 	}
+	if t.onSecondaryTap != nil {
+		t.onSecondaryTap(t.itemID)
+	}
 }
 
-func (t *TappableListItem) TappedSecondary(e *fyne.PointEvent) {}
-
 type ROM struct {
 	Name    string `json:"name"`
 	URL     string `json:"url"`
@@ -178,13 +165,47 @@ type ROM struct {
 	Date    string `json:"date"`
 	TitleID string `json:"titleId,omitempty"` // For Wii U games
 	Region  string `json:"region,omitempty"`  // For Wii U games
+	CRC32   string `json:"crc32,omitempty"`   // Optional hashes for verifying downloads
+	SHA1    string `json:"sha1,omitempty"`
+	MD5     string `json:"md5,omitempty"`
+
+	ImageURL string `json:"imageUrl,omitempty"` // Optional box art, cached under boxart/
+
+	// Mirrors are other URLs for the same file, tried in order when URL fails
+	Mirrors []string `json:"mirrors,omitempty"`
+
+	// DiscSet names the multi-disc set the game is a disc of, for sets whose
+	// names have no "(Disc N)" tag. See multidisc.go.
+	DiscSet string `json:"discSet,omitempty"`
+
+	// System is the ID of the system the game was loaded for. Downloads and
+	// launches use it rather than whichever system is selected by then.
+	System string `json:"-"`
+
+	// Discs holds every disc, in order, when the game is a multi-disc set
+	// shown as one entry
+	Discs []ROM `json:"-"`
 }
 
 type CoreConfig struct {
-	Name   string `json:"name"`
-	Dll    string `json:"dll"`              // Windows .dll (also used as fallback)
-	So     string `json:"so,omitempty"`     // Linux .so override
-	Dylib  string `json:"dylib,omitempty"`  // macOS .dylib override
+	Name   string   `json:"name"`
+	Dll    string   `json:"dll"`              // Windows .dll (also used as fallback)
+	So     string   `json:"so,omitempty"`     // Linux .so override
+	Dylib  string   `json:"dylib,omitempty"`  // macOS .dylib override
+	Config string   `json:"config,omitempty"` // RetroArch config overrides, passed with --appendconfig
+	Args   []string `json:"args,omitempty"`   // Extra RetroArch arguments for this core
+	// Bios lists files the core needs in RetroArch's system directory
+	Bios []BiosFile `json:"bios,omitempty"`
+}
+
+// LaunchArgs returns the RetroArch arguments that load this core, plus any
+// per-core config/args. Without extras this is just "-L <core>".
+func (c *CoreConfig) LaunchArgs() []string {
+	args := []string{"-L", c.GetCorePath()}
+	if c.Config != "" {
+		args = append(args, "--appendconfig", c.Config)
+	}
+	return append(args, c.Args...)
 }
 
 // GetCorePath returns the appropriate core path for the current OS
@@ -208,10 +229,19 @@ func (c *CoreConfig) GetCorePath() string {
 }
 
 type EmulatorConfig struct {
+	// ID names an emulator from systems.json's "emulators" section, which
+	// says where it's installed on each OS; Path is used as-is without one
+	ID    string       `json:"id,omitempty"`
 	Path  string       `json:"path"`
 	Args  []string     `json:"args"`
 	Cores []CoreConfig `json:"cores"`
 	Name  string       `json:"name"`
+	// AppImageLaunch is "", "direct" or "extract-and-run" (Linux AppImages only)
+	AppImageLaunch string `json:"appImageLaunch,omitempty"`
+	// Bios lists files a standalone emulator needs in BiosDir, which is
+	// relative to the emulator's folder (the one holding its .app on macOS)
+	Bios    []BiosFile `json:"bios,omitempty"`
+	BiosDir string     `json:"biosDir,omitempty"`
 }
 
 type SystemConfig struct {
@@ -225,15 +255,27 @@ type SystemConfig struct {
 	FileExtensions     []string        `json:"fileExtensions"`
 	NeedsExtract       bool            `json:"needsExtract"`
 	SpecialDownload    string          `json:"specialDownload,omitempty"`
+	RetroArchSubsystem string          `json:"retroarchSubsystem,omitempty"`
+	// Mirrors rewrite set URLs to fallback hosts, tried after the game's own mirrors
+	Mirrors []MirrorRule `json:"mirrors,omitempty"`
 }
 
 type SystemsConfig struct {
-	Systems []SystemConfig `json:"systems"`
+	Emulators map[string]EmulatorDef `json:"emulators"`
+	Systems   []SystemConfig         `json:"systems"`
 }
 
 var systems map[string]SystemConfig
+
+// systemsConfigErr is why systems.json couldn't be loaded, if it couldn't
+var systemsConfigErr error
 var systemsList []string
+
+// favorites maps system ID -> game name -> favorite. The game list reads it
+// while drawing, so it's only used through the functions below, under
+// favoritesMu.
 var favorites map[string]map[string]bool
+var favoritesMu sync.RWMutex
 
 var baseDir string
 var romsDir string
@@ -257,11 +299,19 @@ func init() {
 		baseDir = exeDir
 	}
 
-	romsDir = filepath.Join(baseDir, "roms")
 	favoritesPath = filepath.Join(baseDir, "favorites.json")
+	settingsPath = filepath.Join(baseDir, "settings.json")
+	playStatsPath = filepath.Join(baseDir, "playstats.json")
 
-	loadSystemsConfig()
+	if err := loadSystemsConfig(); err != nil {
+		systemsConfigErr = err
+		logError("%v", err)
+	}
 	loadFavorites()
+	loadSettings()
+	resolveDataDirs()
+	loadPlayStats()
+	applySystemVisibility()
 }
 
 func fileExists(path string) bool {
@@ -269,24 +319,55 @@ func fileExists(path string) bool {
 	return err == nil
 }
 
-func loadSystemsConfig() {
-	configPath := filepath.Join(baseDir, "systems.json")
-	data, err := os.ReadFile(configPath)
+// loadSystemsConfig reads systems.json. On error the system list is left
+// empty and the error is returned for main to explain to the user.
+func loadSystemsConfig() error {
+	systems = make(map[string]SystemConfig)
+	allSystemsList = nil
+	systemsList = nil
+	systemsConfigProblems = nil
+
+	data, err := readDataFile("systems.json")
 	if err != nil {
-		panic(fmt.Sprintf("Failed to load systems.json: %v", err))
+		return fmt.Errorf("failed to load systems.json: %w", err)
 	}
 
 	var config SystemsConfig
 	if err := json.Unmarshal(data, &config); err != nil {
-		panic(fmt.Sprintf("Failed to parse systems.json: %v", err))
+		return fmt.Errorf("failed to parse systems.json: %w", err)
 	}
 
-	systems = make(map[string]SystemConfig)
-	systemsList = make([]string, 0, len(config.Systems))
-	for _, sys := range config.Systems {
+	emulatorDefs = config.Emulators
+	emulatorIDs = make(map[string]string)
+
+	allSystemsList = make([]string, 0, len(config.Systems))
+	for i, sys := range config.Systems {
+		warnings, err := normalizeSystem(&sys)
+		if err == nil {
+			if _, dup := systems[sys.ID]; dup {
+				err = fmt.Errorf("duplicate id")
+			}
+		}
+		if err != nil {
+			systemsConfigProblems = append(systemsConfigProblems, fmt.Sprintf("systems.json: entry %d (%s) skipped: %v", i, sys.ID, err))
+			continue
+		}
+		for _, warning := range warnings {
+			systemsConfigProblems = append(systemsConfigProblems, fmt.Sprintf("systems.json: %s: %s", sys.ID, warning))
+		}
 		systems[sys.ID] = sys
-		systemsList = append(systemsList, sys.ID)
+		allSystemsList = append(allSystemsList, sys.ID)
+	}
+	systemsList = allSystemsList
+
+	for _, problem := range systemsConfigProblems {
+		fmt.Println(problem)
+		logWarn("%s", problem)
+	}
+	if len(allSystemsList) == 0 {
+		return errors.New("systems.json doesn't define any usable systems")
 	}
+	return nil
 }
 
 func loadFavorites() {
@@ -299,238 +380,174 @@ func loadFavorites() {
 }
 
 func saveFavorites() {
+	favoritesMu.RLock()
 	data, _ := json.Marshal(favorites)
+	favoritesMu.RUnlock()
 	os.WriteFile(favoritesPath, data, 0644)
 }
 
-// resolvePlatformPath converts Windows paths from systems.json to platform-specific paths
-func resolvePlatformPath(windowsPath string) string {
-	platform := runtime.GOOS
-
-	if platform == "windows" {
-		return windowsPath
+// setFavorite adds a game to its system's favorites, or removes it. It
+// doesn't save them; see saveFavorites.
+func setFavorite(game ROM, on bool) {
+	favoritesMu.Lock()
+	defer favoritesMu.Unlock()
+	if !on {
+		delete(favorites[game.System], game.Name)
+		return
 	}
-
-	// Convert to forward slashes
-	path := filepath.ToSlash(windowsPath)
-
-	if platform == "darwin" {
-		// macOS-specific path resolution
-
-		// Handle RetroArch
-		if strings.Contains(path, "RetroArch/RetroArch-Win64/retroarch.exe") {
-			return strings.Replace(path, "RetroArch/RetroArch-Win64/retroarch.exe", "RetroArch/RetroArch.app/Contents/MacOS/RetroArch", 1)
-		}
-
-		// Handle RetroArch cores
-		// On macOS, cores are stored in ~/Library/Application Support/RetroArch/cores/
-		// Path arrives as .dylib already (converted by GetCorePath())
-		if strings.Contains(path, "cores/") && strings.HasSuffix(path, ".dylib") {
-			coreName := filepath.Base(path)
-			homeDir, _ := os.UserHomeDir()
-			return filepath.Join(homeDir, "Library/Application Support/RetroArch/cores", coreName)
-		}
-
-		// Handle Dolphin
-		if strings.Contains(path, "Dolphin/Dolphin-x64/Dolphin.exe") {
-			return strings.Replace(path, "Dolphin/Dolphin-x64/Dolphin.exe", "Dolphin/Dolphin.app/Contents/MacOS/Dolphin", 1)
-		}
-
-		// Handle PCSX2
-		if strings.Contains(path, "PCSX2/pcsx2-qt.exe") {
-			// Find the actual .app bundle (version may vary)
-			pcsx2Dir := filepath.Join(baseDir, "Emulators", "PCSX2")
-			if entries, err := os.ReadDir(pcsx2Dir); err == nil {
-				for _, entry := range entries {
-					if strings.HasPrefix(entry.Name(), "PCSX2") && strings.HasSuffix(entry.Name(), ".app") {
-						return fmt.Sprintf("Emulators/PCSX2/%s/Contents/MacOS/PCSX2-qt", entry.Name())
-					}
-				}
-			}
-			return strings.Replace(path, "PCSX2/pcsx2-qt.exe", "PCSX2/PCSX2.app/Contents/MacOS/PCSX2-qt", 1)
-		}
-
-		// Handle PPSSPP
-		if strings.Contains(path, "PPSSPP/PPSSPPWindows64.exe") {
-			return strings.Replace(path, "PPSSPP/PPSSPPWindows64.exe", "PPSSPP/PPSSPP.app/Contents/MacOS/PPSSPP", 1)
-		}
-
-		// Handle mGBA
-		if strings.Contains(path, "mGBA/mGBA-0.10.5-win64/mGBA.exe") {
-			return strings.Replace(path, "mGBA/mGBA-0.10.5-win64/mGBA.exe", "mGBA/mGBA.app/Contents/MacOS/mGBA", 1)
-		}
-
-		// Handle melonDS
-		if strings.Contains(path, "melonDS/melonDS.exe") {
-			return strings.Replace(path, "melonDS/melonDS.exe", "melonDS/melonDS.app/Contents/MacOS/melonDS", 1)
-		}
-
-		// Handle Azahar
-		if strings.Contains(path, "Azahar/azahar.exe") {
-			return strings.Replace(path, "Azahar/azahar.exe", "Azahar/azahar.app/Contents/MacOS/azahar", 1)
-		}
+	if favorites[game.System] == nil {
+		favorites[game.System] = make(map[string]bool)
 	}
+	favorites[game.System][game.Name] = true
+}
 
-	if platform == "linux" {
-		// Linux-specific path resolution
-
-		// Handle RetroArch - it's an AppImage on Linux
-		if strings.Contains(path, "RetroArch/RetroArch-Win64/retroarch.exe") {
-			// Find the actual AppImage in the RetroArch directory
-			retroarchDir := filepath.Join(baseDir, "Emulators", "RetroArch", "RetroArch-Linux-x86_64")
-			if entries, err := os.ReadDir(retroarchDir); err == nil {
-				for _, entry := range entries {
-					if strings.HasSuffix(strings.ToLower(entry.Name()), ".appimage") {
-						return fmt.Sprintf("Emulators/RetroArch/RetroArch-Linux-x86_64/%s", entry.Name())
-					}
-				}
-			}
-			return "Emulators/RetroArch/RetroArch-Linux-x86_64/RetroArch-Linux-x86_64.AppImage"
-		}
-
-		// Handle RetroArch cores - .dll -> .so, and update path for Linux
-		if strings.Contains(path, "cores/") && strings.HasSuffix(path, ".dll") {
-			// Change extension and update the RetroArch path
-			path = strings.TrimSuffix(path, ".dll") + ".so"
-			path = strings.Replace(path, "RetroArch-Win64", "RetroArch-Linux-x86_64", 1)
-			return path
-		}
-
-		// Handle PCSX2 - find the AppImage in the PCSX2 folder
-		if strings.Contains(path, "PCSX2/pcsx2-qt.exe") {
-			pcsx2Dir := filepath.Join(baseDir, "Emulators", "PCSX2")
-			if entries, err := os.ReadDir(pcsx2Dir); err == nil {
-				for _, entry := range entries {
-					if strings.HasSuffix(strings.ToLower(entry.Name()), ".appimage") {
-						return fmt.Sprintf("Emulators/PCSX2/%s", entry.Name())
-					}
-				}
-			}
-			return "Emulators/PCSX2/pcsx2.AppImage"
-		}
-
-		// Handle PPSSPP - find the AppImage in the PPSSPP folder
-		if strings.Contains(path, "PPSSPP/PPSSPPWindows64.exe") {
-			ppssppDir := filepath.Join(baseDir, "Emulators", "PPSSPP")
-			if entries, err := os.ReadDir(ppssppDir); err == nil {
-				for _, entry := range entries {
-					if strings.HasSuffix(strings.ToLower(entry.Name()), ".appimage") {
-						return fmt.Sprintf("Emulators/PPSSPP/%s", entry.Name())
-					}
-				}
-			}
-			return "Emulators/PPSSPP/ppsspp.AppImage"
-		}
-
-		// Handle mGBA - find the AppImage in the mGBA folder
-		if strings.Contains(path, "mGBA/mGBA-0.10.5-win64/mGBA.exe") {
-			mgbaDir := filepath.Join(baseDir, "Emulators", "mGBA")
-			if entries, err := os.ReadDir(mgbaDir); err == nil {
-				for _, entry := range entries {
-					if strings.HasSuffix(strings.ToLower(entry.Name()), ".appimage") {
-						return fmt.Sprintf("Emulators/mGBA/%s", entry.Name())
-					}
-				}
-			}
-			return "Emulators/mGBA/mgba.AppImage"
-		}
-
-		// Handle melonDS - find the AppImage in the melonDS folder
-		if strings.Contains(path, "melonDS/melonDS.exe") {
-			melondsDir := filepath.Join(baseDir, "Emulators", "melonDS")
-			if entries, err := os.ReadDir(melondsDir); err == nil {
-				for _, entry := range entries {
-					if strings.HasSuffix(strings.ToLower(entry.Name()), ".appimage") {
-						return fmt.Sprintf("Emulators/melonDS/%s", entry.Name())
-					}
-				}
-			}
-			return "Emulators/melonDS/melonDS.AppImage"
-		}
-
-		// Handle Azahar - find the AppImage in the Azahar folder
-		if strings.Contains(path, "Azahar/azahar.exe") {
-			azaharDir := filepath.Join(baseDir, "Emulators", "Azahar")
-			if entries, err := os.ReadDir(azaharDir); err == nil {
-				for _, entry := range entries {
-					if strings.HasSuffix(strings.ToLower(entry.Name()), ".appimage") {
-						return fmt.Sprintf("Emulators/Azahar/%s", entry.Name())
-					}
-				}
-			}
-			return "Emulators/Azahar/azahar.AppImage"
-		}
-		// Handle Cemu - find the AppImage in the Cemu folder
-		if strings.Contains(path, "Cemu/Cemu.exe") {
-			cemuDir := filepath.Join(baseDir, "Emulators", "Cemu")
-			if entries, err := os.ReadDir(cemuDir); err == nil {
-				for _, entry := range entries {
-					if strings.HasSuffix(strings.ToLower(entry.Name()), ".appimage") {
-						return fmt.Sprintf("Emulators/Cemu/%s", entry.Name())
-					}
-				}
-			}
-			return "Emulators/Cemu/Cemu.AppImage"
-		}
-
-
-		// Handle Dolphin - currently Flatpak, but if installed as AppImage
-		if strings.Contains(path, "Dolphin/Dolphin-x64/Dolphin.exe") {
-			dolphinDir := filepath.Join(baseDir, "Emulators", "Dolphin")
-			// Check for AppImage first
-			if entries, err := os.ReadDir(dolphinDir); err == nil {
-				for _, entry := range entries {
-					if strings.HasSuffix(strings.ToLower(entry.Name()), ".appimage") {
-						return fmt.Sprintf("Emulators/Dolphin/%s", entry.Name())
-					}
-				}
-			}
-			// Dolphin is a Flatpak on Linux - return a special marker that launchGame can handle
-			return "flatpak:org.DolphinEmu.dolphin-emu"
+// favoriteNames returns the names of a system's favorites, in no particular order
+func favoriteNames(sysID string) []string {
+	favoritesMu.RLock()
+	defer favoritesMu.RUnlock()
+	var names []string
+	for name, fav := range favorites[sysID] {
+		if fav {
+			names = append(names, name)
 		}
 	}
-
-	return windowsPath
+	return names
 }
 
-// App holds the application state
+// searchDebounce is how long to wait after the last keystroke before filtering
+const searchDebounce = 150 * time.Millisecond
+
+// App holds the application state. Most of it - the search, filters, sort,
+// restoreGame, the download queue's widgets - is only touched on Fyne's event
+// goroutine, where input handlers and runOnEvents callbacks run. State that
+// Fyne's list callbacks or background goroutines read as well has its own
+// lock, noted by its field.
 type App struct {
 	window          fyne.Window
 	windowFocused   bool
+	focusCheckedAt  time.Time
 	currentSystem   string
+	// gamesMu guards allGames, lowerNames, filteredGames, selectedGameIdx,
+	// revisions and expandedGroups; use the accessors in games.go and
+	// revisions.go
+	gamesMu         sync.RWMutex
 	allGames        []ROM
+	lowerNames      []string // lower-cased allGames names, for search
 	filteredGames   []ROM
 	showFavsOnly    bool
-	romCache        map[string]bool
+	downloadFilter  string // filterAll, filterDownloaded or filterNotDownloaded
+	sortMode        string // one of sortOptions
+	romCache        map[string]bool   // romCacheKey -> on disk
+	romBadges       map[string]string // romCacheKey -> availability badge
+	cacheSystem     string            // system romCache/romBadges describe
+	cacheMu         sync.Mutex        // guards the three above; downloads finish on other goroutines
 	selectedGameIdx int
 	selectedSysIdx  int
 	focusOnGames    bool // true = game list focused, false = system list focused
 	dialogOpen      bool
 	disclaimerShown bool
 	disclaimerAcceptedByController bool
-	gameRunning     bool
+
+	// gameRunning counts emulators started from the launcher that haven't
+	// exited; the controller isn't read while it's non-zero. gameExited is
+	// signalled when the last one exits.
+	gameRunning atomic.Int32
+	gameExited  chan struct{}
+	// updatingEmulators is set while the setup program reinstalls emulators
+	// from the Updates dialog; games aren't launched meanwhile
+	updatingEmulators atomic.Bool
+
+	// favoritesReturnSystem is the system to go back to when Favorites Only
+	// is turned off in All Favorites (see setFavoritesOnly)
+	favoritesReturnSystem string
+
+	// checkedGames are the games ticked for bulk actions (see multiselect.go);
+	// checkedMu guards them, as the game list reads them while drawing
+	checkedGames map[string]ROM
+	checkedMu    sync.Mutex
+
+	// Revision groups of the shown games, with collapseRevisions on, and the
+	// groups that are expanded. Like the game lists they're read by Fyne's
+	// list callbacks, so they're under gamesMu; use the accessors in
+	// revisions.go.
+	revisions      revisionGroups
+	expandedGroups map[string]bool
+
+	// Type-ahead in the game list (see typeahead.go): the letters typed so
+	// far, when the last one was, and whether Shift is held
+	typeAhead   string
+	typeAheadAt time.Time
+	shiftHeld   bool
 
 	// Emulator choice state
 	choosingEmulator    bool
 	emulatorChoices     []string
 	emulatorPaths       []string
 	emulatorArgs        [][]string
+	emulatorMissing     []string // why each choice can't launch, "" if it can
 	selectedEmulatorIdx int
 	pendingGame         ROM
-	
-	// Mouse double-click tracking
-	lastClickTime time.Time
-	lastClickIdx  int
+
+	// The emulator last started, so a game isn't launched twice (see
+	// launchguard.go)
+	lastLaunch launchedEmulator
+
+	// A-Z quick-jump panel (see letterjump.go): shown while choosingLetter,
+	// with the first shown game for each letter
+	choosingLetter  bool
+	letterPanel     *fyne.Container
+	letterButtons   []*widget.Button
+	letterPositions map[string]int
+	letterJumpIdx   int
+
+	// restoringSelection is set while restoreSelection replays the saved
+	// selection, so the intermediate steps aren't remembered
+	restoringSelection bool
+	// restoreGame is the saved game to select once its system has loaded
+	restoreGame string
+
+	// gamesLoad is bumped by every selectSystem; a set still loading for an
+	// earlier one sees it changed and is dropped
+	gamesLoad atomic.Uint64
 
 	// UI elements
 	systemList        *widget.List
 	gameList          *widget.List
 	statusBar         *widget.Label
-	searchEntry       *widget.Entry
+	searchEntry       *SearchEntry
 	searchQuery       string
+	searchTimer       *time.Timer
 	instructions      *widget.Label
 	favsCheck         *widget.Check
+	favsFirstCheck    *widget.Check
+	gridCheck         *widget.Check
+	fullscreenCheck   *widget.Check
+	downloadFilterSel *widget.Select
+	regionFilterBtn   *widget.Button
+	sortSel           *widget.Select
 	launchBtn         *widget.Button
+	retryFailedBtn    *widget.Button
+	debugPanel        fyne.CanvasObject
+	debugLabel        *widget.Label
+
+	// Grid (cover) view; shares selectedGameIdx with the list
+	gridMode      bool
+	gameGrid      *fyne.Container
+	gridScroll    *container.Scroll
+	gridTiles     []*gameTile
+	gridPageStart int
+
+	// Downloads that didn't complete, for "Retry failed"; failedMu guards
+	// the list and its entries
+	failedDownloads []*failedDownload
+	failedMu        sync.Mutex
+
+	// Download queue; survives switching systems
+	queue      []*queueItem
+	queueMu    sync.Mutex
+	queueBox   *fyne.Container
+	queuePanel *fyne.Container
+	queueTotal *widget.Label
 	
 	// Emulator choice UI
 	emulatorList      *widget.List
@@ -542,69 +559,108 @@ type App struct {
 	emulatorPanel     *fyne.Container
 	rightPanel        *fyne.Container  // Container that holds either gamePanel or emulatorPanel
 
+	// Controller poller shutdown: closing controllerStop asks pollController
+	// to close the joystick, and it closes controllerDone when it has
+	controllerStop chan struct{}
+	controllerDone chan struct{}
+
+	// Rumble state: the open controller's index, set by pollController, and
+	// when the last list-end bump was felt
+	controllerIndex atomic.Int32
+	lastEdgeBump    time.Time
+
+	// Library disk usage shown in the bottom bar
+	libraryLabel *widget.Label
+	library      libraryScan
+
+	// Per-system default emulator buttons on the emulator choice panel
+	emulatorDefaultBtn      *widget.Button
+	emulatorGameDefaultBtn  *widget.Button
+	emulatorClearDefaultBtn *widget.Button
+
 	// Disclaimer dialog reference for controller dismissal
 	disclaimerDialog  dialog.Dialog
+
+	// setErrorShown records systems whose game list error was already shown,
+	// so browsing past them doesn't pop the dialog every time
+	setErrorShown map[string]bool
 }
 
-// isSetupComplete checks if emulators have been installed
+// isSetupComplete checks if emulators have been installed: at least one
+// emulator in systems.json must be runnable on this OS. A folder the
+// installer only partly extracted doesn't count, so setup runs again.
 func isSetupComplete() bool {
-	emulatorsDir := filepath.Join(baseDir, "Emulators")
-	
 	// Check if Emulators directory exists
 	info, err := os.Stat(emulatorsDir)
 	if err != nil || !info.IsDir() {
 		return false
 	}
-	
-	// Check if there's at least one subdirectory (an installed emulator)
+
+	for _, def := range emulatorDefs {
+		if p := def.current(); p != nil && p.runnable() {
+			return true
+		}
+	}
+	if len(emulatorDefs) > 0 {
+		return false
+	}
+
+	// A systems.json without an emulators section doesn't say where they
+	// are, so any emulator folder will do
 	entries, err := os.ReadDir(emulatorsDir)
 	if err != nil {
 		return false
 	}
-	
 	for _, entry := range entries {
 		if entry.IsDir() {
-			// Found at least one emulator folder
 			return true
 		}
 	}
-	
 	return false
 }
 
-// runSetupAndExit launches the setup program and exits the launcher
-func runSetupAndExit() {
-	var setupPath string
-	
+// setupPath returns where the setup program for this platform lives
+func setupPath() string {
 	switch runtime.GOOS {
 	case "windows":
-		setupPath = filepath.Join(baseDir, "EmuBuddySetup.exe")
+		return filepath.Join(baseDir, "EmuBuddySetup.exe")
 	case "darwin":
-		setupPath = filepath.Join(baseDir, "EmuBuddySetup-macos")
+		return filepath.Join(baseDir, "EmuBuddySetup-macos")
 	default:
-		setupPath = filepath.Join(baseDir, "EmuBuddySetup-linux")
+		return filepath.Join(baseDir, "EmuBuddySetup-linux")
 	}
-	
-	// Check if setup exists
-	if !fileExists(setupPath) {
-		fmt.Println("Setup program not found:", setupPath)
-		fmt.Println("Please run EmuBuddySetup first to install emulators.")
-		os.Exit(1)
+}
+
+// startSetup starts the setup program without waiting for it
+func startSetup() error {
+	path := setupPath()
+	if !fileExists(path) {
+		return fmt.Errorf("setup program not found: %s", path)
 	}
-	
+
 	// Make executable on Unix
 	if runtime.GOOS != "windows" {
-		os.Chmod(setupPath, 0755)
+		os.Chmod(path, 0755)
 	}
-	
-	fmt.Println("No emulators found. Launching setup...")
-	
-	cmd := exec.Command(setupPath)
+
+	cmd := exec.Command(path)
 	cmd.Dir = baseDir
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-	cmd.Start()
-	
+	return cmd.Start()
+}
+
+// runSetupAndExit launches the setup program and exits the launcher
+func runSetupAndExit() {
+	// Check if setup exists
+	if !fileExists(setupPath()) {
+		fmt.Println("Setup program not found:", setupPath())
+		fmt.Println("Please run EmuBuddySetup first to install emulators.")
+		os.Exit(1)
+	}
+
+	fmt.Println("No working emulators found. Launching setup...")
+	startSetup()
 	os.Exit(0)
 }
 
@@ -616,7 +672,7 @@ func launchROMHeadless(systemID string, romPath string) {
 	config, exists := systems[systemID]
 	if !exists {
 		fmt.Printf("Error: Unknown system '%s'\n", systemID)
-		fmt.Println("Available systems:", systemsList)
+		fmt.Println("Available systems:", allSystemsList)
 		os.Exit(1)
 	}
 
@@ -642,15 +698,16 @@ func launchROMHeadless(systemID string, romPath string) {
 
 	// Create a minimal game ROM struct
 	game := ROM{
-		Name: filepath.Base(romPath),
+		Name:   filepath.Base(romPath),
+		System: systemID,
 	}
 
 	// Handle extraction if needed (for systems like Dolphin that can't read zips)
 	actualRomPath := romPath
-	if config.NeedsExtract && strings.HasSuffix(strings.ToLower(romPath), ".zip") {
-		fmt.Printf("[DEBUG] System requires extraction, extracting ZIP...\n")
+	if config.NeedsExtract && archiveExt(romPath) != "" {
+		fmt.Printf("[DEBUG] System requires extraction, extracting %s...\n", archiveExt(romPath))
 		romDir := filepath.Dir(romPath)
-		extractedPath, err := extractZip(romPath, romDir)
+		extractedPath, err := extractArchive(context.Background(), romPath, romDir, nil)
 		if err != nil {
 			fmt.Printf("Error extracting ROM: %v\n", err)
 			os.Exit(1)
@@ -669,38 +726,41 @@ func launchROMHeadless(systemID string, romPath string) {
 	emuPath := config.Emulator.Path
 	var emuArgs []string
 
-	if len(config.Emulator.Cores) > 0 {
+	if emuPath == "" && config.StandaloneEmulator != nil && config.StandaloneEmulator.Path != "" {
+		// Systems with only a standalone emulator
+		emuPath = config.StandaloneEmulator.Path
+		emuArgs = config.StandaloneEmulator.Args
+		fmt.Printf("[DEBUG] Using standalone emulator with args: %v\n", emuArgs)
+	} else if emuPath == "" {
+		fmt.Printf("Error: No emulator configured for %s\n", config.Name)
+		os.Exit(1)
+	} else if len(config.Emulator.Cores) > 0 {
 		// Use first core - GetCorePath() handles OS-specific paths
 		corePath := config.Emulator.Cores[0].GetCorePath()
-		emuArgs = []string{"-L", corePath}
+		emuArgs = withRetroArchSubsystem(config.Emulator.Cores[0].LaunchArgs(), retroarchSubsystemFor(systemID, game.Name))
 		fmt.Printf("[DEBUG] Using RetroArch core: %s\n", corePath)
 	} else {
 		emuArgs = config.Emulator.Args
 		fmt.Printf("[DEBUG] Using standalone emulator with args: %v\n", emuArgs)
 	}
 
+	if dir, problems := missingBios(config, emuPath, emuArgs); len(problems) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: BIOS files needed in %s:\n  %s\n", dir, strings.Join(problems, "\n  "))
+	}
+	emuArgs = withFullscreen(systemID, emuPath, emuArgs)
+
 	// Launch the game (reuse existing logic)
 	launchGameHeadless(game, actualRomPath, emuPath, emuArgs)
 }
 
 // launchGameHeadless launches a game without GUI
 func launchGameHeadless(game ROM, romPath string, emuPath string, emuArgs []string) {
-	// Resolve platform-specific path
-	emuPath = resolvePlatformPath(emuPath)
-
-	// Handle flatpak on Linux
-	isFlatpak := strings.HasPrefix(emuPath, "flatpak:")
-	var flatpakAppID string
-	if isFlatpak {
-		flatpakAppID = strings.TrimPrefix(emuPath, "flatpak:")
-		emuPath = "flatpak"
-	} else {
-		emuPath = filepath.Join(baseDir, emuPath)
-	}
-	emuDir := filepath.Dir(emuPath)
+	emu := resolveEmulator(emuPath)
+	emuPath, emuDir := emu.Exe, emu.Dir
+	isFlatpak := emu.Style == launchFlatpak
 
 	// On Linux, ensure AppImages are executable
-	if runtime.GOOS == "linux" && strings.HasSuffix(strings.ToLower(emuPath), ".appimage") {
+	if emu.Style == launchAppImage {
 		os.Chmod(emuPath, 0755)
 	}
 
@@ -709,13 +769,16 @@ func launchGameHeadless(game ROM, romPath string, emuPath string, emuArgs []stri
 
 	// For flatpak, add "run" and the app ID first
 	if isFlatpak {
-		args = append(args, "run", flatpakAppID)
+		args = append(args, "run", emu.FlatpakID)
 	}
 
 	for _, arg := range emuArgs {
-		if strings.Contains(arg, "/") || strings.Contains(arg, "\\") {
+		if hasArgPlaceholder(arg) {
+			// Expanded below, once the ROM and core paths are known
+			args = append(args, arg)
+		} else if strings.Contains(arg, "/") || strings.Contains(arg, "\\") {
 			// Resolve platform-specific core paths
-			resolvedArg := resolvePlatformPath(arg)
+			resolvedArg := resolveCorePath(arg)
 			if filepath.IsAbs(resolvedArg) {
 				args = append(args, resolvedArg)
 			} else {
@@ -735,7 +798,11 @@ func launchGameHeadless(game ROM, romPath string, emuPath string, emuArgs []stri
 			args = append(args, arg)
 		}
 	}
-	args = append(args, romPath)
+	args = expandLaunchArgs(args, romPath, filepath.Join(romsDir, systems[game.System].Dir))
+
+	if emu.Style == launchAppImage && useExtractAndRun(emu.AppImageMode) {
+		args = withExtractAndRun(args)
+	}
 
 	fmt.Printf("Command: %s %v\n", emuPath, args)
 
@@ -772,7 +839,7 @@ func launchGameHeadless(game ROM, romPath string, emuPath string, emuArgs []stri
 func main() {
 	// Print banner to confirm this version is running
 	fmt.Println("========================================")
-	fmt.Println("  EmuBuddy Launcher v2.0")
+	fmt.Println("  EmuBuddy Launcher v" + launcherVersion)
 	fmt.Println("  Headless Mode Support")
 	fmt.Println("========================================")
 
@@ -785,6 +852,12 @@ func main() {
 
 	// Check for CLI arguments for headless ROM launch FIRST (before setup check)
 	// This allows testing even if setup isn't complete
+	if systemsConfigErr != nil && len(os.Args) >= 2 && (os.Args[1] == "--launch" || os.Args[1] == "--check-urls" || os.Args[1] == "--download" ||
+		os.Args[1] == "--backup-saves" || os.Args[1] == "--restore-saves") {
+		fmt.Printf("Error: %v\n", systemsConfigErr)
+		os.Exit(1)
+	}
+
 	if len(os.Args) >= 2 && os.Args[1] == "--launch" {
 		fmt.Println("[DEBUG] Headless mode activated")
 		var systemID string
@@ -799,6 +872,43 @@ func main() {
 		return
 	}
 
+	// Download a game without starting the GUI
+	if len(os.Args) >= 2 && os.Args[1] == "--download" {
+		var systemID, query string
+		if len(os.Args) >= 3 {
+			systemID = os.Args[2]
+		}
+		if len(os.Args) >= 4 {
+			query = strings.Join(os.Args[3:], " ")
+		}
+		downloadROMHeadless(systemID, query)
+		return
+	}
+
+	// Back up or restore emulator save data without starting the GUI
+	if len(os.Args) >= 2 && os.Args[1] == "--backup-saves" {
+		backupSavesHeadless()
+		return
+	}
+	if len(os.Args) >= 2 && os.Args[1] == "--restore-saves" {
+		var backup string
+		if len(os.Args) >= 3 {
+			backup = os.Args[2]
+		}
+		restoreSavesHeadless(backup)
+		return
+	}
+
+	// Validate a system's download URLs without starting the GUI
+	if len(os.Args) >= 2 && os.Args[1] == "--check-urls" {
+		var systemID string
+		if len(os.Args) >= 3 {
+			systemID = os.Args[2]
+		}
+		checkSystemURLsHeadless(systemID)
+		return
+	}
+
 	// Check if setup has been run (Emulators folder should have content)
 	if !isSetupComplete() {
 		runSetupAndExit()
@@ -806,7 +916,13 @@ func main() {
 	}
 
 	myApp := app.New()
-	myApp.Settings().SetTheme(theme.DarkTheme())
+	applyTheme(myApp)
+
+	// Without systems.json there's nothing to show; explain and exit
+	if systemsConfigErr != nil {
+		showSystemsConfigError(myApp, systemsConfigErr)
+		return
+	}
 
 	myWindow := myApp.NewWindow("EmuBuddy")
 	myWindow.Resize(fyne.NewSize(1000, 600))
@@ -815,12 +931,29 @@ func main() {
 		window:        myWindow,
 		romCache:      make(map[string]bool),
 		windowFocused: true,
+
+		controllerStop: make(chan struct{}),
+		controllerDone: make(chan struct{}),
+		gameExited:     make(chan struct{}, 1),
 	}
 
+	romDirs.onChange = func(dir string) {
+		runOnEvents(func() { appState.romDirChanged(dir) })
+	}
 	appState.buildUI()
+	if len(dataDirWarnings) > 0 {
+		appState.statusBar.SetText("Warning: " + strings.Join(dataDirWarnings, "; "))
+	}
+	appState.refreshLibraryUsage()
 	appState.showDisclaimer()
+	if settings.CheckForUpdates {
+		go appState.checkForUpdatesAtStartup()
+	}
+	go runUIQueue(myWindow)
 	go appState.pollController()
 	myWindow.ShowAndRun()
+	appState.stopController()
+	saveSettings() // keeps the last selected game
 }
 
 func (a *App) showDisclaimer() {
@@ -869,7 +1002,7 @@ func (a *App) buildUI() {
 			}
 			label := item.(*widget.Label)
 			sysID := systemsList[id]
-			name := systems[sysID].Name
+			name := systemDisplayName(sysID)
 			if !a.focusOnGames && id == a.selectedSysIdx {
 				name = "> " + name
 			}
@@ -882,49 +1015,66 @@ func (a *App) buildUI() {
 		a.focusOnGames = false
 		a.selectSystem(systemsList[id])
 		a.systemList.Refresh()
+		a.rememberSelection()
+		saveSettings()
 	}
 
 	// Game list on right - use TappableListItem for double-click support
 	// Use canvas.Text for game name to prevent MinSize changes on scroll
 	a.gameList = widget.NewList(
-		func() int { return len(a.filteredGames) },
+		func() int { return len(a.shownGames()) },
 		func() fyne.CanvasObject {
 			// Use canvas.Text - it has fixed size and won't cause layout changes
 			nameText := canvas.NewText("Game Name", theme.ForegroundColor())
 			nameText.TextSize = 14
+			badgeText := canvas.NewText("[Region]", theme.ForegroundColor())
+			badgeText.TextSize = 14
 			statusText := canvas.NewText("[Ready]", theme.ForegroundColor())
 			statusText.TextSize = 14
 			sizeText := canvas.NewText("999.9 MiB", theme.ForegroundColor())
 			sizeText.TextSize = 14
-			content := container.NewBorder(nil, nil, nil,
-				container.NewHBox(statusText, sizeText),
+			content := container.NewBorder(nil, nil,
+				newBoxArtImage(),
+				container.NewHBox(badgeText, statusText, sizeText),
 				nameText,
 			)
 			return NewTappableListItem(content)
 		},
 		func(id widget.ListItemID, item fyne.CanvasObject) {
-			if id >= len(a.filteredGames) {
+			games := a.shownGames()
+			if id >= len(games) {
 				return
 			}
-			game := a.filteredGames[id]
+			game := games[id]
 			tappable := item.(*TappableListItem)
 			tappable.SetListInfo(a.gameList, id, func(itemID widget.ListItemID) {
-				a.launchSelected()
+				a.launchOnDoubleTap(itemID)
 			})
+			tappable.onSecondaryTap = func(widget.ListItemID) {
+				a.toggleVariants()
+			}
 			
 			box := tappable.Content.(*fyne.Container)
 			nameText := box.Objects[0].(*canvas.Text)
-			rightBox := box.Objects[1].(*fyne.Container)
-			statusText := rightBox.Objects[0].(*canvas.Text)
-			sizeText := rightBox.Objects[1].(*canvas.Text)
+			boxArt := box.Objects[1].(*canvas.Image)
+			rightBox := box.Objects[2].(*fyne.Container)
+			badgeText := rightBox.Objects[0].(*canvas.Text)
+			statusText := rightBox.Objects[1].(*canvas.Text)
+			sizeText := rightBox.Objects[2].(*canvas.Text)
 
 			// Name with favorite indicator
-			name := strings.TrimSuffix(game.Name, ".zip")
+			name := gameDisplayName(game)
 			name = strings.TrimSuffix(name, ".chd")
-			if a.isFavorite(game.Name) {
+			if a.isFavorite(game) {
 				name = "[FAV] " + name
 			}
-			if a.focusOnGames && id == a.selectedGameIdx {
+			if a.isChecked(game) {
+				name = "✓ " + name
+			}
+			if a.isRevisionVariant(game) {
+				name = "    " + name
+			}
+			if a.focusOnGames && id == a.selectedIndex() {
 				name = "> " + name
 			}
 			// Truncate long names
@@ -932,55 +1082,88 @@ func (a *App) buildUI() {
 				name = name[:47] + "..."
 			}
 			nameText.Text = name
+			nameText.Color = theme.ForegroundColor()
 			nameText.Refresh()
 
 			// Status
-			if a.romCache[game.Name] {
+			if a.isDownloaded(game) {
 				statusText.Text = "[Ready]"
 			} else {
 				statusText.Text = "[DL]"
 			}
+			statusText.Color = theme.ForegroundColor()
 			statusText.Refresh()
 
+			badgeText.Text = strings.TrimSpace(a.revisionLabel(game) + " " + a.romBadgeFor(game))
+			if isVirtualSystem(a.currentSystem) {
+				badgeText.Text = strings.TrimSpace("[" + systems[game.System].Name + "] " + badgeText.Text)
+			}
+			badgeText.Color = theme.ForegroundColor()
+			badgeText.Refresh()
+
 			sizeText.Text = game.Size
+			sizeText.Color = theme.ForegroundColor()
 			sizeText.Refresh()
+
+			a.updateBoxArt(boxArt, game)
 		},
 	)
 
 	a.gameList.OnSelected = func(id widget.ListItemID) {
-		a.selectedGameIdx = id
+		a.setSelectedIndex(id)
 		a.focusOnGames = true
+		a.rememberSelection()
 		a.updateStatus()
 		a.updateLaunchButton()
-		a.gameList.Refresh()
+		a.refreshGameView()
 		a.systemList.Refresh()
 	}
 
 	// Search box
-	a.searchEntry = widget.NewEntry()
+	a.searchEntry = NewSearchEntry(a.leaveSearch)
 	a.searchEntry.SetPlaceHolder("Type to search...")
 	a.searchEntry.OnChanged = func(s string) {
 		a.searchQuery = s
-		a.filterGames()
+		// Debounce - only filter once the user pauses typing. The timer
+		// just posts the filter back here to the event goroutine, where one
+		// that fired before a later keystroke could stop it is dropped.
+		if a.searchTimer != nil {
+			a.searchTimer.Stop()
+		}
+		var timer *time.Timer
+		timer = time.AfterFunc(searchDebounce, func() {
+			runOnEvents(func() {
+				if a.searchTimer == timer {
+					a.searchTimer = nil
+					a.filterGames()
+				}
+			})
+		})
+		a.searchTimer = timer
 	}
 
 	// Status bar
 	a.statusBar = widget.NewLabel("Select a system")
 
 	// Instructions
-	a.instructions = widget.NewLabel("Controller: L-Stick=Sys R-Stick=Games A=Select B=Back X=DL Y=Fav | Keyboard: Arrows/Enter/Esc/D=DL/F=Fav | Mouse: Double-click=Launch")
+	a.instructions = widget.NewLabel("Controller: L-Stick=Sys R-Stick=Games A=Select B=Back X=DL Y=Fav RB=A-Z | Keyboard: Arrows/Enter/Esc/D=DL/F=Fav/Space=Select/Slash=Search | Mouse: Double-click=Launch")
 	a.instructions.TextStyle = fyne.TextStyle{Italic: true}
 
 	// Title
 	title := canvas.NewText("EmuBuddy", theme.ForegroundColor())
 	title.TextSize = 24
 	title.TextStyle = fyne.TextStyle{Bold: true}
+	a.watchTheme(title)
 
 	// System panel with header
 	systemHeader := widget.NewLabel("SYSTEMS")
 	systemHeader.TextStyle = fyne.TextStyle{Bold: true}
+	systemsEditBtn := widget.NewButton("Edit", func() {
+		a.showSystemVisibilityDialog()
+	})
+	systemHeaderRow := container.NewBorder(nil, nil, systemHeader, systemsEditBtn)
 	systemPanel := container.NewBorder(
-		systemHeader, nil, nil, nil,
+		systemHeaderRow, nil, nil, nil,
 		a.systemList,
 	)
 
@@ -1001,31 +1184,98 @@ func (a *App) buildUI() {
 			})
 			
 			label := tappable.Content.(*widget.Label)
-			name := a.emulatorChoices[id]
+			name := emulatorChoiceLabel(a.pendingGame, a.emulatorChoices[id])
 			if id == a.selectedEmulatorIdx {
 				name = "> " + name
 			}
+			// Choices that can't launch are greyed out in italics, with why
+			label.TextStyle = fyne.TextStyle{}
+			if !a.emulatorAvailable(id) {
+				name += " - unavailable: " + a.emulatorMissing[id]
+				label.TextStyle = fyne.TextStyle{Italic: true}
+			}
 			label.SetText(name)
 		},
 	)
 	a.emulatorList.OnSelected = func(id widget.ListItemID) {
 		a.selectedEmulatorIdx = id
 		a.emulatorList.Refresh()
+		if !a.emulatorAvailable(id) && id < len(a.emulatorChoices) {
+			a.statusBar.SetText(fmt.Sprintf("%s can't be launched: %s", a.emulatorChoices[id], a.emulatorMissing[id]))
+		}
 	}
 
 	// Favorites checkbox
 	a.favsCheck = widget.NewCheck("Favorites Only", func(checked bool) {
-		a.showFavsOnly = checked
-		a.filterGames()
+		a.setFavoritesOnly(checked)
 	})
 	
+	// Favorites-first ordering, remembered in settings.json
+	a.favsFirstCheck = widget.NewCheck("Favorites First", func(checked bool) {
+		if settings.FavoritesFirst != checked {
+			settings.FavoritesFirst = checked
+			saveSettings()
+		}
+		a.filterGames()
+	})
+	a.favsFirstCheck.SetChecked(settings.FavoritesFirst)
+
+	// List / grid view toggle, remembered in settings.json
+	a.gridCheck = widget.NewCheck("Grid", func(checked bool) {
+		if settings.GridView != checked {
+			settings.GridView = checked
+			saveSettings()
+		}
+		a.setGridMode(checked)
+	})
+
+	// Fullscreen launches, remembered in settings.json
+	a.fullscreenCheck = widget.NewCheck("Fullscreen", func(checked bool) {
+		if settings.LaunchFullscreen != checked {
+			settings.LaunchFullscreen = checked
+			saveSettings()
+		}
+	})
+	a.fullscreenCheck.SetChecked(settings.LaunchFullscreen)
+
+	// Downloaded / not downloaded filter
+	a.downloadFilterSel = widget.NewSelect(downloadFilterOptions, func(option string) {
+		a.downloadFilter = option
+		a.filterGames()
+	})
+	a.downloadFilterSel.SetSelected(filterAll)
+
+	// Region/language filter, remembered in settings.json
+	a.regionFilterBtn = widget.NewButton(regionFilterLabel(), func() {
+		a.showRegionFilterDialog()
+	})
+
+	// Sort order, remembered in settings.json
+	a.sortSel = widget.NewSelect(sortOptions, func(option string) {
+		a.sortMode = option
+		saved := option
+		if saved == sortDefault {
+			saved = "" // don't write settings.json just for the default
+		}
+		if settings.SortMode != saved {
+			settings.SortMode = saved
+			saveSettings()
+		}
+		a.filterGames()
+	})
+	if isSortOption(settings.SortMode) {
+		a.sortSel.SetSelected(settings.SortMode)
+	} else {
+		a.sortSel.SetSelected(sortDefault)
+	}
+
 	// Launch/Download button - text changes based on game status
 	a.launchBtn = widget.NewButton("Launch", func() {
-		if a.selectedGameIdx < 0 || a.selectedGameIdx >= len(a.filteredGames) {
+		game, ok := a.selectedGame()
+		if !ok {
 			return
 		}
-		game := a.filteredGames[a.selectedGameIdx]
-		if a.romCache[game.Name] {
+		if a.isDownloaded(game) {
 			logDebug("Launch button clicked - launching")
 			a.launchSelected()
 		} else {
@@ -1034,17 +1284,27 @@ func (a *App) buildUI() {
 		}
 	})
 	
+	// Retry button - only shown while there are failed downloads to retry
+	a.retryFailedBtn = widget.NewButton("Retry failed", func() {
+		a.retryFailedDownloads()
+	})
+	a.retryFailedBtn.Hide()
+
 	// Game panel with header, favorites checkbox, launch button, and search
 	gamesLabel := widget.NewLabel("GAMES")
 	gameHeader := container.NewBorder(nil, nil,
-		container.NewHBox(gamesLabel, a.favsCheck, a.launchBtn),
+		container.NewHBox(gamesLabel, a.favsCheck, a.favsFirstCheck, a.downloadFilterSel, a.regionFilterBtn, a.sortSel, a.gridCheck, a.fullscreenCheck, a.launchBtn, a.retryFailedBtn),
 		nil,
 		a.searchEntry,
 	)
 	a.gamePanel = container.NewBorder(
-		gameHeader, nil, nil, nil,
-		a.gameList,
+		gameHeader, container.NewVBox(a.buildQueuePanel(), a.buildDebugPanel()), nil, nil,
+		container.NewMax(a.gameList, a.buildGameGrid()),
 	)
+	a.gridCheck.SetChecked(settings.GridView)
+	if !settings.GridView {
+		a.gridScroll.Hide()
+	}
 
 	// Emulator choice panel
 	emulatorHeader := widget.NewLabel("CHOOSE EMULATOR")
@@ -1059,25 +1319,46 @@ func (a *App) buildUI() {
 		a.cancelEmulatorChoice()
 	})
 	
-	emulatorButtons := container.NewHBox(a.emulatorSelectBtn, a.emulatorCancelBtn)
+	a.emulatorDefaultBtn = widget.NewButton("Set as default", func() {
+		a.setSelectedEmulatorDefault(false)
+	})
+	a.emulatorGameDefaultBtn = widget.NewButton("Set as default for this game", func() {
+		a.setSelectedEmulatorDefault(true)
+	})
+	a.emulatorClearDefaultBtn = widget.NewButton("Clear default", func() {
+		a.clearEmulatorDefault()
+	})
+	
+	emulatorButtons := container.NewHBox(a.emulatorDefaultBtn, a.emulatorGameDefaultBtn, a.emulatorClearDefaultBtn, a.emulatorSelectBtn, a.emulatorCancelBtn)
 	emulatorHeaderRow := container.NewBorder(nil, nil, emulatorHeader, emulatorButtons)
 	
 	a.emulatorPanel = container.NewBorder(
 		emulatorHeaderRow, nil, nil, nil,
 		a.emulatorList,
 	)
+	a.buildLetterPanel()
 
 	// Main layout - use custom FixedWidthLayout that returns constant MinSize
 	a.systemPanel = systemPanel
 	a.rightPanel = container.NewMax(a.gamePanel)
 	a.mainContainer = container.New(NewFixedWidthLayout(200), a.systemPanel, a.rightPanel)
 
-	// Bottom bar
-	bottomBar := container.NewBorder(nil, nil, nil, a.statusBar, a.instructions)
+	// Bottom bar - status on the right, library usage under it
+	a.libraryLabel = widget.NewLabel("Library: scanning...")
+	bottomBar := container.NewBorder(nil, nil, nil, container.NewVBox(a.statusBar, a.libraryLabel), a.instructions)
 
 	// Main layout
+	settingsBtn := widget.NewButtonWithIcon("", theme.SettingsIcon(), func() {
+		a.showSettingsDialog()
+	})
+	savesBtn := widget.NewButtonWithIcon("", theme.DocumentSaveIcon(), func() {
+		a.showSavesDialog()
+	})
+	updatesBtn := widget.NewButtonWithIcon("Updates", theme.DownloadIcon(), func() {
+		a.showUpdatesDialog()
+	})
 	content := container.NewBorder(
-		container.NewPadded(title),
+		container.NewPadded(container.NewBorder(nil, nil, title, container.NewHBox(updatesBtn, savesBtn, settingsBtn))),
 		bottomBar,
 		nil, nil,
 		a.mainContainer,
@@ -1090,7 +1371,29 @@ func (a *App) buildUI() {
 	// Add keyboard shortcuts
 	a.window.Canvas().SetOnTypedKey(func(ke *fyne.KeyEvent) {
 		// Don't handle keys if search box is focused or dialog is open
-		if a.dialogOpen {
+		if a.dialogOpen || a.searchFocused() {
+			return
+		}
+		// The A-Z quick-jump panel takes the arrows, Enter and Escape
+		if a.choosingLetter {
+			switch ke.Name {
+			case fyne.KeyLeft:
+				a.moveLetterJump(-1, 0)
+			case fyne.KeyRight:
+				a.moveLetterJump(1, 0)
+			case fyne.KeyUp:
+				a.moveLetterJump(0, -1)
+			case fyne.KeyDown:
+				a.moveLetterJump(0, 1)
+			case fyne.KeyReturn, fyne.KeyEnter:
+				a.confirmLetterJump()
+			case fyne.KeyEscape, fyne.KeyBackspace:
+				a.closeLetterJump()
+			}
+			return
+		}
+		// Letters typed in the game list jump to games (see typeahead.go)
+		if a.focusOnGames && !a.choosingEmulator && a.typeAheadKey(ke.Name) {
 			return
 		}
 		
@@ -1104,35 +1407,32 @@ func (a *App) buildUI() {
 			} else {
 				// Focus on games
 				a.focusOnGames = true
-				if len(a.filteredGames) > 0 {
-					a.gameList.Select(0)
+				if len(a.shownGames()) > 0 {
+					a.selectGame(0)
 				}
 				a.systemList.Refresh()
-				a.gameList.Refresh()
+				a.refreshGameView()
 			}
 			
 		case fyne.KeyEscape, fyne.KeyBackspace:
 			// Escape/Backspace - Go back
 			if a.choosingEmulator {
 				a.cancelEmulatorChoice()
+			} else if ke.Name == fyne.KeyEscape && a.clearChecked() {
+				// First Escape unticks the games ticked for bulk actions
+				a.updateStatus()
 			} else if a.focusOnGames {
 				a.focusOnGames = false
 				a.systemList.Refresh()
-				a.gameList.Refresh()
+				a.refreshGameView()
 			}
 			
 		case fyne.KeyDown:
 			// Down arrow - Move selection down
 			if a.choosingEmulator {
-				if a.selectedEmulatorIdx < len(a.emulatorChoices)-1 {
-					a.selectedEmulatorIdx++
-					a.emulatorList.Select(a.selectedEmulatorIdx)
-				}
+				a.moveEmulatorSelection(1)
 			} else if a.focusOnGames {
-				if a.selectedGameIdx < len(a.filteredGames)-1 {
-					a.selectedGameIdx++
-					a.gameList.Select(a.selectedGameIdx)
-				}
+				a.moveGameSelection(0, 1)
 			} else {
 				if a.selectedSysIdx < len(systemsList)-1 {
 					a.selectedSysIdx++
@@ -1143,15 +1443,9 @@ func (a *App) buildUI() {
 		case fyne.KeyUp:
 			// Up arrow - Move selection up
 			if a.choosingEmulator {
-				if a.selectedEmulatorIdx > 0 {
-					a.selectedEmulatorIdx--
-					a.emulatorList.Select(a.selectedEmulatorIdx)
-				}
+				a.moveEmulatorSelection(-1)
 			} else if a.focusOnGames {
-				if a.selectedGameIdx > 0 {
-					a.selectedGameIdx--
-					a.gameList.Select(a.selectedGameIdx)
-				}
+				a.moveGameSelection(0, -1)
 			} else {
 				if a.selectedSysIdx > 0 {
 					a.selectedSysIdx--
@@ -1160,35 +1454,78 @@ func (a *App) buildUI() {
 			}
 			
 		case fyne.KeyLeft:
-			// Left arrow - Focus on systems or download
-			if !a.choosingEmulator && a.focusOnGames {
-				a.focusOnGames = false
-				a.systemList.Refresh()
-				a.gameList.Refresh()
+			// Left arrow - Focus on systems (in the grid, move left a column first)
+			if !a.choosingEmulator {
+				a.moveHorizontal(-1)
 			}
 			
 		case fyne.KeyRight:
-			// Right arrow - Focus on games
-			if !a.choosingEmulator && !a.focusOnGames {
-				a.focusOnGames = true
-				if len(a.filteredGames) > 0 && a.selectedGameIdx < 0 {
-					a.selectedGameIdx = 0
-					a.gameList.Select(0)
-				}
-				a.systemList.Refresh()
-				a.gameList.Refresh()
+			// Right arrow - Focus on games (in the grid, then move right a column)
+			if !a.choosingEmulator {
+				a.moveHorizontal(1)
+			}
+			
+		case fyne.KeyE:
+			// E key - Launch with the emulator chooser, ignoring any default
+			if a.focusOnGames && !a.choosingEmulator {
+				a.chooseEmulatorSelected()
+			}
+			
+		case fyne.KeyS:
+			// S key - Make the highlighted emulator the system's default
+			if a.choosingEmulator {
+				a.setSelectedEmulatorDefault(false)
 			}
 			
 		case fyne.KeyD:
-			// D key - Download selected game
+			// D key - Download selected game, or the ticked games
 			if a.focusOnGames && !a.choosingEmulator {
-				a.downloadSelected()
+				if a.checkedCount() > 0 {
+					a.downloadChecked()
+				} else {
+					a.downloadSelected()
+				}
 			}
 			
 		case fyne.KeyF:
-			// F key - Toggle favorite
+			// F key - Toggle favorite, for the ticked games if any are
 			if a.focusOnGames && !a.choosingEmulator {
-				a.toggleSelectedFavorite()
+				if a.checkedCount() > 0 {
+					a.toggleCheckedFavorites()
+				} else {
+					a.toggleSelectedFavorite()
+				}
+			}
+
+		case fyne.KeyG:
+			// G key - Switch between list and grid view, or in the emulator
+			// chooser make the highlighted emulator this game's default
+			if a.choosingEmulator {
+				a.setSelectedEmulatorDefault(true)
+			} else {
+				a.gridCheck.SetChecked(!a.gridMode)
+			}
+
+		case fyne.KeyDelete:
+			// Delete key - Remove the selected (or ticked) games' downloaded files
+			if a.focusOnGames && !a.choosingEmulator {
+				if a.checkedCount() > 0 {
+					a.deleteChecked()
+				} else {
+					a.deleteSelected()
+				}
+			}
+
+		case fyne.KeyV:
+			// V key - Show or hide the other revisions of the selected game
+			if a.focusOnGames && !a.choosingEmulator {
+				a.toggleVariants()
+			}
+
+		case fyne.KeySpace:
+			// Space - Tick the selected game for bulk actions
+			if a.focusOnGames && !a.choosingEmulator {
+				a.toggleCheckedGame()
 			}
 			
 		case fyne.KeyTab:
@@ -1196,592 +1533,766 @@ func (a *App) buildUI() {
 			if !a.choosingEmulator {
 				a.focusOnGames = !a.focusOnGames
 				a.systemList.Refresh()
-				a.gameList.Refresh()
+				a.refreshGameView()
 			}
 			
 		case fyne.KeyPageDown:
-			// Page Down - Jump down 10 items
+			// Page Down - Jump down 10 items (10 rows in the grid)
 			if a.focusOnGames {
-				newIdx := a.selectedGameIdx + 10
-				if newIdx >= len(a.filteredGames) {
-					newIdx = len(a.filteredGames) - 1
-				}
-				if newIdx >= 0 {
-					a.selectedGameIdx = newIdx
-					a.gameList.Select(a.selectedGameIdx)
-				}
+				a.moveGameSelection(0, 10)
 			}
 			
 		case fyne.KeyPageUp:
-			// Page Up - Jump up 10 items
+			// Page Up - Jump up 10 items (10 rows in the grid)
 			if a.focusOnGames {
-				newIdx := a.selectedGameIdx - 10
-				if newIdx < 0 {
-					newIdx = 0
-				}
-				a.selectedGameIdx = newIdx
-				a.gameList.Select(a.selectedGameIdx)
+				a.moveGameSelection(0, -10)
 			}
 			
 		case fyne.KeyHome:
 			// Home - Jump to first item
-			if a.focusOnGames && len(a.filteredGames) > 0 {
-				a.selectedGameIdx = 0
-				a.gameList.Select(0)
+			if a.focusOnGames && len(a.shownGames()) > 0 {
+				a.selectGame(0)
 			}
 			
 		case fyne.KeyEnd:
 			// End - Jump to last item
-			if a.focusOnGames && len(a.filteredGames) > 0 {
-				a.selectedGameIdx = len(a.filteredGames) - 1
-				a.gameList.Select(a.selectedGameIdx)
+			if n := len(a.shownGames()); a.focusOnGames && n > 0 {
+				a.selectGame(n - 1)
 			}
 		}
 	})
 
-	// Select first system
-	if len(systemsList) > 0 {
-		a.systemList.Select(0)
+	// Shift+letter always starts a type-ahead jump, even on a shortcut letter
+	if dc, ok := a.window.Canvas().(desktop.Canvas); ok {
+		dc.SetOnKeyDown(func(ke *fyne.KeyEvent) {
+			if ke.Name == desktop.KeyShiftLeft || ke.Name == desktop.KeyShiftRight {
+				a.shiftHeld = true
+			}
+		})
+		dc.SetOnKeyUp(func(ke *fyne.KeyEvent) {
+			if ke.Name == desktop.KeyShiftLeft || ke.Name == desktop.KeyShiftRight {
+				a.shiftHeld = false
+			}
+		})
 	}
+
+	// Ctrl+A - Tick every game shown for bulk actions
+	a.window.Canvas().AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyA, Modifier: desktop.ControlModifier}, func(fyne.Shortcut) {
+		if a.dialogOpen || a.searchFocused() || a.choosingEmulator {
+			return
+		}
+		a.focusOnGames = true
+		a.checkAllShown()
+		a.systemList.Refresh()
+	})
+
+	// / - Focus the search box. Handled as a rune so it works on any keyboard
+	// layout, and isn't typed into the box it focuses.
+	a.window.Canvas().SetOnTypedRune(func(r rune) {
+		if r == '/' && !a.dialogOpen {
+			a.focusSearch()
+		}
+	})
+
+	// Start where the launcher was closed, or on the first system
+	a.restoreSelection()
 }
 
+// Controller hot-plug: with no controller, indices 0-3 are re-scanned every
+// controllerRescanInterval; this many failed reads in a row (~0.5s) is
+// treated as a disconnect
+const (
+	controllerRescanInterval = 2 * time.Second
+	controllerReadFailures   = 30
+)
+
+// pollController keeps a controller open for the life of the app. It waits
+// for one to be plugged in, and goes back to scanning when it disconnects,
+// until stopController is called.
 func (a *App) pollController() {
-	// Try to find a working joystick
-	var js joystick.Joystick
-	var err error
-	
-	for i := 0; i < 4; i++ {
-		js, err = joystick.Open(i)
+	defer close(a.controllerDone)
+
+	searching := false
+	for {
+		js, id := openController()
+		if js == nil {
+			if !searching {
+				logDebug("No controller found, rescanning every %v", controllerRescanInterval)
+				searching = true
+			}
+			select {
+			case <-a.controllerStop:
+				return
+			case <-time.After(controllerRescanInterval):
+			}
+			continue
+		}
+		searching = false
+
+		logInfo("Controller %d connected: %s", id, js.Name())
+		a.controllerIndex.Store(int32(id))
+		rumbleUnsupported.Store(false)
+		err := a.readController(js)
+		js.Close()
 		if err == nil {
-			break
+			logDebug("Controller %d closed", id)
+			return
 		}
+		logInfo("Controller %d disconnected: %v", id, err)
 	}
-	
-	if err != nil {
-		// No controller found, that's fine
-		return
-	}
-	defer js.Close()
+}
 
-	var lastButtons uint32
-	var lastLeftY, lastRightY int
-	var lastDpadX, lastDpadY int
-	leftRepeatTimer := time.Now()
-	rightRepeatTimer := time.Now()
-	dpadRepeatTimer := time.Now()
-	rightHoldStart := time.Time{}
-	const initialDelay = 300 * time.Millisecond
-	const repeatDelay = 150 * time.Millisecond
-	const fastRepeatDelay = 50 * time.Millisecond
-	const fastScrollThreshold = 500 * time.Millisecond
-	const deadzone = 10000
+// openController returns the first controller that opens, and its index
+func openController() (joystick.Joystick, int) {
+	for i := 0; i < 4; i++ {
+		if js, err := joystick.Open(i); err == nil {
+			return js, i
+		}
+	}
+	return nil, -1
+}
+
+// stopController shuts down pollController and waits briefly for it to
+// close the controller
+func (a *App) stopController() {
+	close(a.controllerStop)
+	select {
+	case <-a.controllerDone:
+	case <-time.After(time.Second):
+		logDebug("Controller poller did not stop in time")
+	}
+}
+
+// padState is what handlePad remembers between controller reads: the last
+// buttons and directions, to see what was just pressed, and when held
+// directions last repeated. It's only used on Fyne's event goroutine.
+type padState struct {
+	lastButtons                   uint32
+	lastLeftY, lastRightY         int
+	lastDpadX, lastDpadY          int
+	leftRepeatTimer               time.Time
+	rightRepeatTimer              time.Time
+	dpadRepeatTimer               time.Time
+	dpadXRepeatTimer              time.Time
+	rightHoldStart, dpadHoldStart time.Time
+}
+
+// padRepeatDelay is how often a held stick or D-pad direction repeats
+const padRepeatDelay = 150 * time.Millisecond
+
+// readController reads an open controller and hands each state to handlePad
+// on Fyne's event goroutine, where keyboard and mouse input are handled too.
+// It returns nil when the app is shutting down, or the read error once the
+// controller is gone.
+func (a *App) readController(js joystick.Joystick) error {
+	ticker := time.NewTicker(16 * time.Millisecond) // ~60fps polling
+	defer ticker.Stop()
+	readFailures := 0
+	resync := false
+	now := time.Now()
+	pad := &padState{leftRepeatTimer: now, rightRepeatTimer: now, dpadRepeatTimer: now, dpadXRepeatTimer: now}
 
-	// Log controller info once
-	logDebug("Controller connected: %d axes, %d buttons", js.AxisCount(), js.ButtonCount())
+	logDebug("Controller has %d axes, %d buttons", js.AxisCount(), js.ButtonCount())
 
 	for {
-		time.Sleep(16 * time.Millisecond) // ~60fps polling
+		select {
+		case <-a.controllerStop:
+			return nil
+		case <-ticker.C:
+		}
 
-		// Skip controller input when a game is running (prevents background navigation)
-		if a.gameRunning {
+		// Don't read the controller while a game is running: the emulator
+		// owns the gamepad, and reading it here would navigate in the background
+		if a.gameRunning.Load() > 0 {
+			logDebug("Controller paused while a game is running")
+			select {
+			case <-a.controllerStop:
+				return nil
+			case <-a.gameExited:
+			}
+			resync = true
 			continue
 		}
 
-		// Window focus check disabled for Steam Deck Game Mode (Gamescope breaks xdotool)
-		// if !isWindowFocused("EmuBuddy") {
-		// 	continue
-		// }
-
-		state, err := js.Read()
-		if err != nil {
+		// The focus check is opt-in: Gamescope (Steam Deck Game Mode) breaks
+		// xdotool, and some Wayland desktops can't report focus at all
+		if controllerNeedsFocus() && !a.hasWindowFocus() {
+			resync = true
 			continue
 		}
 
-		// Debug: Log button presses and axis movements
-		if state.Buttons != lastButtons {
-			logDebug("Buttons RAW: 0x%08X (was 0x%08X)", state.Buttons, lastButtons)
-		}
-
-		// Handle disclaimer dialog with controller buttons (Steam Deck Game Mode fix)
-		if a.disclaimerShown {
-			buttons := state.Buttons
-
-			// Apply platform-specific button remapping
-			if runtime.GOOS == "darwin" {
-				remapped := uint32(0)
-				if buttons&0x0800 != 0 { remapped |= 0x0001 } // A
-				if buttons&0x1000 != 0 { remapped |= 0x0002 } // B
-				if buttons&0x2000 != 0 { remapped |= 0x0004 } // X
-				if buttons&0x4000 != 0 { remapped |= 0x0008 } // Y
-				remapped |= buttons & 0xFFFF00FF
-				buttons = remapped
-			}
-			if runtime.GOOS == "linux" {
-				originalButtons := buttons
-				remapped := uint32(0)
-				if buttons&0x0001 != 0 { remapped |= 0x0001 } // A -> A (bit 0)
-				if buttons&0x0002 != 0 { remapped |= 0x0002 } // B -> B (bit 1)
-				if buttons&0x0008 != 0 { remapped |= 0x0004 } // X -> X (bit 3 -> bit 2)
-				if buttons&0x0010 != 0 { remapped |= 0x0008 } // RB -> Y (bit 4 -> bit 3)
-				if buttons&0x0020 != 0 { remapped |= 0x0020 }
-				if buttons&0x0040 != 0 { remapped |= 0x0040 }
-				if buttons&0x0800 != 0 { remapped |= 0x0080 }
-				remapped |= buttons & 0xFFFFF700
-				buttons = remapped
-				logDebug("Disclaimer button remap: 0x%08X -> 0x%08X", originalButtons, buttons)
-			}
-
-			justPressed := buttons &^ lastButtons
-
-			// A button (bit 0) - Accept disclaimer
-			if justPressed&1 != 0 {
-				logDebug("A button pressed - accepting disclaimer")
-				a.disclaimerAcceptedByController = true
-				a.dialogOpen = false
-				a.disclaimerShown = false
-				if a.disclaimerDialog != nil {
-					a.disclaimerDialog.Hide()
-				}
-			}
-
-			// B button (bit 1) - Exit application
-			if justPressed&2 != 0 {
-				logDebug("B button pressed - exiting application")
-				a.dialogOpen = false
-				a.disclaimerShown = false
-				if a.disclaimerDialog != nil {
-					a.disclaimerDialog.Hide()
-				}
-				a.window.Close()
+		state, err := js.Read()
+		if err != nil {
+			readFailures++
+			if readFailures >= controllerReadFailures {
+				return err
 			}
-
-			lastButtons = state.Buttons
 			continue
 		}
+		readFailures = 0
+		// The driver may keep updating the axes it returned
+		state.AxisData = append([]int(nil), state.AxisData...)
 
-		// Skip if other dialog is open
-		if a.dialogOpen {
-			lastButtons = state.Buttons
+		// After a game, take the current state as the baseline so buttons
+		// still held from quitting the emulator don't act in the launcher
+		if resync {
+			resync = false
+			runOnEvents(func() { pad.lastButtons = state.Buttons })
+			logDebug("Controller resumed")
 			continue
 		}
 
-		buttons := state.Buttons
-
-		// Left stick Y axis (axis 1) - controls system list
-		leftY := 0
-		// Right stick Y axis (axis 3 on most controllers) - controls game list
-		rightY := 0
-		// D-pad on Linux (axes 6 and 7)
-		dpadX := 0
-		dpadY := 0
-
-		if len(state.AxisData) >= 2 {
-			// Y axis handling: Linux joystick API reports positive=down (which is what we want)
-			// macOS reports positive=up, so we need to invert it
-			axisValue := state.AxisData[1]
-			if runtime.GOOS == "darwin" {
-				axisValue = -axisValue
-			}
-			if axisValue > deadzone {
-				leftY = 1
-			} else if axisValue < -deadzone {
-				leftY = -1
-			}
-		}
-
-		// Right stick Y axis - axis index differs by platform
-		var rightAxisIndex int
-		if runtime.GOOS == "linux" {
-			rightAxisIndex = 3 // Linux: axis 3 is right Y (standard mapping)
-		} else {
-			rightAxisIndex = 3 // Windows/macOS: axis 3 is right Y
-		}
+		runOnEvents(func() { a.handlePad(pad, state) })
+	}
+}
 
-		if len(state.AxisData) > rightAxisIndex {
-			axisValue := state.AxisData[rightAxisIndex]
-			// Y axis handling: Linux joystick API reports positive=down (which is what we want)
-			// macOS reports positive=up, so we need to invert it
-			if runtime.GOOS == "darwin" {
-				axisValue = -axisValue
-			}
-			if axisValue > deadzone {
-				rightY = 1
-			} else if axisValue < -deadzone {
-				rightY = -1
-			}
-		}
+// handlePad acts on one controller state read by readController
+func (a *App) handlePad(p *padState, state joystick.State) {
+	// Debug: Log button presses and axis movements
+	if state.Buttons != p.lastButtons {
+		logDebug("Buttons RAW: 0x%08X (was 0x%08X)", state.Buttons, p.lastButtons)
+	}
 
-		// D-pad on Linux - usually axes 6 (X) and 7 (Y)
-		if runtime.GOOS == "linux" && len(state.AxisData) > 7 {
-			// D-pad X axis (left/right)
-			if state.AxisData[6] > deadzone {
-				dpadX = 1 // Right
-			} else if state.AxisData[6] < -deadzone {
-				dpadX = -1 // Left
-			}
-			// D-pad Y axis (up/down)
-			if state.AxisData[7] > deadzone {
-				dpadY = 1 // Down
-			} else if state.AxisData[7] < -deadzone {
-				dpadY = -1 // Up
-			}
-		}
+	// Handle disclaimer dialog with controller buttons (Steam Deck Game Mode fix)
+	if a.disclaimerShown {
+		buttons := state.Buttons
 
-		// Remap buttons for macOS (buttons are at different bit positions)
+		// Apply platform-specific button remapping
 		if runtime.GOOS == "darwin" {
-			// macOS button mapping (observed from Xbox controller):
-			// bit 11 (0x0800) -> A (bit 0)
-			// bit 12 (0x1000) -> B (bit 1)
-			// bit 13 (0x2000) -> X (bit 2)
-			// bit 14 (0x4000) -> Y (bit 3)
 			remapped := uint32(0)
 			if buttons&0x0800 != 0 { remapped |= 0x0001 } // A
 			if buttons&0x1000 != 0 { remapped |= 0x0002 } // B
 			if buttons&0x2000 != 0 { remapped |= 0x0004 } // X
 			if buttons&0x4000 != 0 { remapped |= 0x0008 } // Y
-			// Keep other bits as-is (Start, Select, etc.)
 			remapped |= buttons & 0xFFFF00FF
 			buttons = remapped
 		}
-
-		// Remap buttons for Linux (observed button mapping differs from expected)
-		// User testing shows:
-		// - RB favorites/unfavorites games (should be Y, bit 3)
-		// - Y does nothing
-		// - Start toggles favorites list (working, bit 7)
-		// Looking at logs: bit 4 (0x10) is being pressed when RB is pressed
-		// Expected: bit 0=A, bit 1=B, bit 2=X, bit 3=Y, bit 7=Start
-		// Actual: bit 0=A, bit 1=B, bit 3=X, bit 4=RB(should be Y), bit 11=Start
 		if runtime.GOOS == "linux" {
 			originalButtons := buttons
 			remapped := uint32(0)
-			// Remap buttons correctly
 			if buttons&0x0001 != 0 { remapped |= 0x0001 } // A -> A (bit 0)
 			if buttons&0x0002 != 0 { remapped |= 0x0002 } // B -> B (bit 1)
 			if buttons&0x0008 != 0 { remapped |= 0x0004 } // X -> X (bit 3 -> bit 2)
 			if buttons&0x0010 != 0 { remapped |= 0x0008 } // RB -> Y (bit 4 -> bit 3)
-			if buttons&0x0020 != 0 { remapped |= 0x0020 } // Keep bit 5 as-is
-			if buttons&0x0040 != 0 { remapped |= 0x0040 } // Back -> Back (bit 6)
-			// bit 7 is NOT used, don't map it
-			if buttons&0x0800 != 0 { remapped |= 0x0080 } // Start -> Start (bit 11 -> bit 7)
-			// Copy any other bits we haven't explicitly mapped
+			if buttons&0x0020 != 0 { remapped |= 0x0020 }
+			if buttons&0x0040 != 0 { remapped |= 0x0040 }
+			if buttons&0x0800 != 0 { remapped |= 0x0080 }
 			remapped |= buttons & 0xFFFFF700
 			buttons = remapped
-			if originalButtons != remapped {
-				logDebug("Linux button remap: 0x%08X -> 0x%08X", originalButtons, remapped)
-			}
+			logDebug("Disclaimer button remap: 0x%08X -> 0x%08X", originalButtons, buttons)
 		}
 
-		// Check for new button presses
-		justPressed := buttons &^ lastButtons
+		justPressed := buttons &^ p.lastButtons
 
-		// Handle emulator choice mode
-		if a.choosingEmulator {
-			// A button - confirm choice
-			if justPressed&1 != 0 {
-				a.confirmEmulatorChoice()
-			}
-			// B button - cancel
-			if justPressed&2 != 0 {
-				a.cancelEmulatorChoice()
+		// A button (bit 0) - Accept disclaimer
+		if justPressed&1 != 0 {
+			logDebug("A button pressed - accepting disclaimer")
+			a.disclaimerAcceptedByController = true
+			a.dialogOpen = false
+			a.disclaimerShown = false
+			if a.disclaimerDialog != nil {
+				a.disclaimerDialog.Hide()
 			}
-			// Right stick or D-pad to navigate emulator list
-			if rightY != 0 && (rightY != lastRightY || time.Since(rightRepeatTimer) > repeatDelay) {
-				newIdx := a.selectedEmulatorIdx + rightY
-				if newIdx >= 0 && newIdx < len(a.emulatorChoices) {
-					a.selectedEmulatorIdx = newIdx
-					a.emulatorList.Select(newIdx)
-					a.emulatorList.Refresh()
-				}
-				rightRepeatTimer = time.Now()
-			}
-			// D-pad navigation - axes on Linux, buttons on other platforms
-			if runtime.GOOS == "linux" {
-				if dpadY != 0 && (dpadY != lastDpadY || time.Since(dpadRepeatTimer) > repeatDelay) {
-					newIdx := a.selectedEmulatorIdx + dpadY
-					if newIdx >= 0 && newIdx < len(a.emulatorChoices) {
-						a.selectedEmulatorIdx = newIdx
-						a.emulatorList.Select(newIdx)
-						a.emulatorList.Refresh()
-					}
-					dpadRepeatTimer = time.Now()
-				}
-			} else {
-				// Button-based D-pad for other platforms
-				if justPressed&4096 != 0 && a.selectedEmulatorIdx > 0 {
-					a.selectedEmulatorIdx--
-					a.emulatorList.Select(a.selectedEmulatorIdx)
-					a.emulatorList.Refresh()
-				}
-				if justPressed&8192 != 0 && a.selectedEmulatorIdx < len(a.emulatorChoices)-1 {
-					a.selectedEmulatorIdx++
-					a.emulatorList.Select(a.selectedEmulatorIdx)
-					a.emulatorList.Refresh()
-				}
+		}
+
+		// B button (bit 1) - Exit application
+		if justPressed&2 != 0 {
+			logDebug("B button pressed - exiting application")
+			a.dialogOpen = false
+			a.disclaimerShown = false
+			if a.disclaimerDialog != nil {
+				a.disclaimerDialog.Hide()
 			}
+			a.window.Close()
+		}
 
-			lastButtons = buttons
-			lastLeftY = leftY
-			lastRightY = rightY
-			lastDpadX = dpadX
-			lastDpadY = dpadY
-			continue
+		p.lastButtons = state.Buttons
+		return
+	}
+
+	// Skip if other dialog is open
+	if a.dialogOpen {
+		p.lastButtons = state.Buttons
+		return
+	}
+
+	buttons := state.Buttons
+	deadzone := stickDeadzone()
+
+	// Left stick Y axis (axis 1) - controls system list
+	leftY := 0
+	// Right stick Y axis (axis 3 on most controllers) - controls game list,
+	// scrolling faster the further it's pushed
+	rightY := 0
+	rightDeflection := 0.0
+	// D-pad on Linux (axes 6 and 7)
+	dpadX := 0
+	dpadY := 0
+
+	if len(state.AxisData) >= 2 {
+		// Y axis handling: Linux joystick API reports positive=down (which is what we want)
+		// macOS reports positive=up, so we need to invert it
+		axisValue := state.AxisData[1]
+		if runtime.GOOS == "darwin" {
+			axisValue = -axisValue
+		}
+		if axisValue > deadzone {
+			leftY = 1
+		} else if axisValue < -deadzone {
+			leftY = -1
 		}
+	}
+
+	// Right stick Y axis - axis index differs by platform
+	var rightAxisIndex int
+	if runtime.GOOS == "linux" {
+		rightAxisIndex = 3 // Linux: axis 3 is right Y (standard mapping)
+	} else {
+		rightAxisIndex = 3 // Windows/macOS: axis 3 is right Y
+	}
 
-		// A button (bit 0) - Select/Launch
+	if len(state.AxisData) > rightAxisIndex {
+		axisValue := state.AxisData[rightAxisIndex]
+		// Y axis handling: Linux joystick API reports positive=down (which is what we want)
+		// macOS reports positive=up, so we need to invert it
+		if runtime.GOOS == "darwin" {
+			axisValue = -axisValue
+		}
+		if axisValue > deadzone {
+			rightY = 1
+		} else if axisValue < -deadzone {
+			rightY = -1
+		}
+		rightDeflection = stickDeflection(axisValue, deadzone)
+	}
+
+	// D-pad on Linux - usually axes 6 (X) and 7 (Y)
+	if runtime.GOOS == "linux" && len(state.AxisData) > 7 {
+		// D-pad X axis (left/right)
+		if state.AxisData[6] > deadzone {
+			dpadX = 1 // Right
+		} else if state.AxisData[6] < -deadzone {
+			dpadX = -1 // Left
+		}
+		// D-pad Y axis (up/down)
+		if state.AxisData[7] > deadzone {
+			dpadY = 1 // Down
+		} else if state.AxisData[7] < -deadzone {
+			dpadY = -1 // Up
+		}
+	}
+
+	// Remap buttons for macOS (buttons are at different bit positions)
+	if runtime.GOOS == "darwin" {
+		// macOS button mapping (observed from Xbox controller):
+		// bit 11 (0x0800) -> A (bit 0)
+		// bit 12 (0x1000) -> B (bit 1)
+		// bit 13 (0x2000) -> X (bit 2)
+		// bit 14 (0x4000) -> Y (bit 3)
+		remapped := uint32(0)
+		if buttons&0x0800 != 0 { remapped |= 0x0001 } // A
+		if buttons&0x1000 != 0 { remapped |= 0x0002 } // B
+		if buttons&0x2000 != 0 { remapped |= 0x0004 } // X
+		if buttons&0x4000 != 0 { remapped |= 0x0008 } // Y
+		// Keep other bits as-is (Start, Select, etc.)
+		remapped |= buttons & 0xFFFF00FF
+		buttons = remapped
+	}
+
+	// Remap buttons for Linux (observed button mapping differs from expected)
+	// User testing shows:
+	// - RB favorites/unfavorites games (should be Y, bit 3)
+	// - Y does nothing
+	// - Start toggles favorites list (working, bit 7)
+	// Looking at logs: bit 4 (0x10) is being pressed when RB is pressed
+	// Expected: bit 0=A, bit 1=B, bit 2=X, bit 3=Y, bit 7=Start
+	// Actual: bit 0=A, bit 1=B, bit 3=X, bit 4=RB(should be Y), bit 11=Start
+	if runtime.GOOS == "linux" {
+		originalButtons := buttons
+		remapped := uint32(0)
+		// Remap buttons correctly
+		if buttons&0x0001 != 0 { remapped |= 0x0001 } // A -> A (bit 0)
+		if buttons&0x0002 != 0 { remapped |= 0x0002 } // B -> B (bit 1)
+		if buttons&0x0008 != 0 { remapped |= 0x0004 } // X -> X (bit 3 -> bit 2)
+		if buttons&0x0010 != 0 { remapped |= 0x0008 } // RB -> Y (bit 4 -> bit 3)
+		if buttons&0x0020 != 0 { remapped |= 0x0020 } // Keep bit 5 as-is
+		if buttons&0x0040 != 0 { remapped |= 0x0040 } // Back -> Back (bit 6)
+		// bit 7 is NOT used, don't map it
+		if buttons&0x0800 != 0 { remapped |= 0x0080 } // Start -> Start (bit 11 -> bit 7)
+		// Copy any other bits we haven't explicitly mapped
+		remapped |= buttons & 0xFFFFF700
+		buttons = remapped
+		if originalButtons != remapped {
+			logDebug("Linux button remap: 0x%08X -> 0x%08X", originalButtons, remapped)
+		}
+	}
+
+	// Nintendo layout: the right face button confirms, the bottom one goes back
+	if settings.SwapABButtons {
+		buttons = buttons&^0x3 | (buttons&0x1)<<1 | (buttons&0x2)>>1
+	}
+
+	// Other platforms report the D-pad as buttons (bits 12-15). Read them
+	// like the Linux D-pad axes, so holding a direction repeats there too.
+	if runtime.GOOS != "linux" {
+		if buttons&4096 != 0 {
+			dpadY = -1 // Up
+		} else if buttons&8192 != 0 {
+			dpadY = 1 // Down
+		}
+		if buttons&16384 != 0 {
+			dpadX = -1 // Left
+		} else if buttons&32768 != 0 {
+			dpadX = 1 // Right
+		}
+	}
+
+	// Check for new button presses
+	justPressed := buttons &^ p.lastButtons
+
+	// Handle emulator choice mode
+	if a.choosingEmulator {
+		// A button - confirm choice
 		if justPressed&1 != 0 {
-			if a.focusOnGames {
-				a.launchSelected()
-			} else {
-				a.focusOnGames = true
-				if len(a.filteredGames) > 0 {
-					a.gameList.Select(0)
-				}
-				a.systemList.Refresh()
-				a.gameList.Refresh()
-			}
+			a.confirmEmulatorChoice()
 		}
-
-		// B button (bit 1) - Back
+		// B button - cancel
 		if justPressed&2 != 0 {
-			if a.focusOnGames {
-				a.focusOnGames = false
-				a.systemList.Refresh()
-				a.gameList.Refresh()
-			}
+			a.cancelEmulatorChoice()
+		}
+		// X button - set highlighted emulator as this game's default
+		if justPressed&4 != 0 {
+			a.setSelectedEmulatorDefault(true)
+		}
+		// Y button - set highlighted emulator as the system's default
+		if justPressed&8 != 0 {
+			a.setSelectedEmulatorDefault(false)
+		}
+		// Right stick or D-pad to navigate emulator list
+		if rightY != 0 && (rightY != p.lastRightY || time.Since(p.rightRepeatTimer) > padRepeatDelay) {
+			a.moveEmulatorSelection(rightY)
+			p.rightRepeatTimer = time.Now()
+		}
+		// D-pad navigation
+		if dpadY != 0 && (dpadY != p.lastDpadY || time.Since(p.dpadRepeatTimer) > padRepeatDelay) {
+			a.moveEmulatorSelection(dpadY)
+			p.dpadRepeatTimer = time.Now()
 		}
 
-		// X button (bit 2) - Download
-		if justPressed&4 != 0 && a.focusOnGames {
-			a.downloadSelected()
+		p.lastButtons = buttons
+		p.lastLeftY = leftY
+		p.lastRightY = rightY
+		p.lastDpadX = dpadX
+		p.lastDpadY = dpadY
+		return
+	}
+
+	// A-Z quick-jump panel: A jumps, B or RB goes back, the D-pad and
+	// sticks move between letters
+	if a.choosingLetter {
+		if justPressed&1 != 0 {
+			a.confirmLetterJump()
+		} else if justPressed&(2|32) != 0 {
+			a.closeLetterJump()
+		}
+		moveY := dpadY
+		if moveY == 0 {
+			moveY = rightY
+		}
+		if moveY == 0 {
+			moveY = leftY
+		}
+		if dpadX != 0 && (dpadX != p.lastDpadX || time.Since(p.dpadXRepeatTimer) > padRepeatDelay) {
+			a.moveLetterJump(dpadX, 0)
+			p.dpadXRepeatTimer = time.Now()
+		}
+		if moveY != 0 && (moveY != p.lastDpadY || time.Since(p.dpadRepeatTimer) > padRepeatDelay) {
+			a.moveLetterJump(0, moveY)
+			p.dpadRepeatTimer = time.Now()
 		}
 
-		// Y button (bit 3) - Favorite
-		if justPressed&8 != 0 && a.focusOnGames {
-			a.toggleSelectedFavorite()
+		p.lastButtons = buttons
+		p.lastLeftY = leftY
+		p.lastRightY = rightY
+		p.lastDpadX = dpadX
+		p.lastDpadY = moveY
+		return
+	}
+
+	// RB (bit 5) - A-Z quick-jump through the game list
+	if justPressed&32 != 0 {
+		a.showLetterJump()
+	}
+
+	// A button (bit 0) - Select/Launch
+	if justPressed&1 != 0 {
+		if a.focusOnGames {
+			if _, ok := a.selectedGame(); ok {
+				a.rumble(pulseLaunch)
+			}
+			a.launchSelected()
+		} else {
+			a.focusOnGames = true
+			if len(a.shownGames()) > 0 {
+				a.selectGame(0)
+			}
+			a.systemList.Refresh()
+			a.refreshGameView()
 		}
+	}
 
-		// Start button (bit 7) - Toggle favorites view
-		if justPressed&128 != 0 {
-			a.showFavsOnly = !a.showFavsOnly
-			a.favsCheck.SetChecked(a.showFavsOnly) // Sync checkbox
-			a.filterGames()
+	// B button (bit 1) - Back
+	if justPressed&2 != 0 {
+		if a.focusOnGames {
+			a.focusOnGames = false
+			a.systemList.Refresh()
+			a.refreshGameView()
 		}
+	}
 
-		// Left stick - navigate systems
-		if leftY != 0 {
-			// Just started moving or repeat timer elapsed
-			if leftY != lastLeftY || time.Since(leftRepeatTimer) > repeatDelay {
-				newIdx := a.selectedSysIdx + leftY
-				if newIdx >= 0 && newIdx < len(systemsList) {
-					a.selectedSysIdx = newIdx
-					a.systemList.Select(newIdx)
-				}
-				leftRepeatTimer = time.Now()
-			}
+	// X button (bit 2) - Download
+	if justPressed&4 != 0 && a.focusOnGames {
+		a.downloadSelected()
+	}
+
+	// Y button (bit 3) - Favorite
+	if justPressed&8 != 0 && a.focusOnGames {
+		if _, ok := a.selectedGame(); ok {
+			a.rumble(pulseFavorite)
 		}
+		a.toggleSelectedFavorite()
+	}
 
-		// Right stick - navigate games with fast scroll
-		if rightY != 0 {
-			// Track how long stick has been held
-			if rightY != lastRightY {
-				rightHoldStart = time.Now()
-			}
-			
-			holdDuration := time.Since(rightHoldStart)
-			currentRepeatDelay := repeatDelay
-			scrollAmount := 1
-			
-			// Fast scroll after holding for a bit
-			if holdDuration > fastScrollThreshold {
-				currentRepeatDelay = fastRepeatDelay
-				scrollAmount = 5 // Jump 5 items at a time
-			}
-			
-			// Just started moving or repeat timer elapsed
-			if rightY != lastRightY || time.Since(rightRepeatTimer) > currentRepeatDelay {
-				a.focusOnGames = true
-				newIdx := a.selectedGameIdx + (rightY * scrollAmount)
-				if newIdx < 0 {
-					newIdx = 0
-				}
-				if newIdx >= len(a.filteredGames) {
-					newIdx = len(a.filteredGames) - 1
-				}
-				if newIdx >= 0 && newIdx < len(a.filteredGames) {
-					a.selectedGameIdx = newIdx
-					a.gameList.Select(newIdx)
-					a.updateStatus()
-				}
-				rightRepeatTimer = time.Now()
-				a.systemList.Refresh()
-				a.gameList.Refresh()
+	// Back button (bit 6) - Launch with the emulator chooser, ignoring any default
+	if justPressed&64 != 0 && a.focusOnGames {
+		a.chooseEmulatorSelected()
+	}
+
+	// Start button (bit 7) - Toggle favorites view
+	if justPressed&128 != 0 {
+		a.setFavoritesOnly(!a.showFavsOnly)
+		a.favsCheck.SetChecked(a.showFavsOnly) // Sync checkbox
+	}
+
+	// Left stick - navigate systems
+	if leftY != 0 {
+		// Just started moving or repeat timer elapsed
+		if leftY != p.lastLeftY || time.Since(p.leftRepeatTimer) > padRepeatDelay {
+			newIdx := a.selectedSysIdx + leftY
+			if newIdx >= 0 && newIdx < len(systemsList) {
+				a.selectedSysIdx = newIdx
+				a.systemList.Select(newIdx)
+			} else {
+				a.bumpListEnd()
 			}
-		} else {
-			rightHoldStart = time.Time{}
+			p.leftRepeatTimer = time.Now()
 		}
+	}
 
-		// D-pad navigation - use axes on Linux, buttons on other platforms
-		if runtime.GOOS == "linux" {
-			// On Linux, use D-pad axes for navigation
-			if dpadY != 0 && (dpadY != lastDpadY || time.Since(dpadRepeatTimer) > repeatDelay) {
-				a.navigate(dpadY)
-				dpadRepeatTimer = time.Now()
-			}
-			if dpadX != 0 && (dpadX != lastDpadX || time.Since(dpadRepeatTimer) > repeatDelay) {
-				if dpadX < 0 && a.selectedSysIdx > 0 {
-					a.systemList.Select(a.selectedSysIdx - 1)
-				} else if dpadX > 0 && a.selectedSysIdx < len(systemsList)-1 {
-					a.systemList.Select(a.selectedSysIdx + 1)
-				}
-				dpadRepeatTimer = time.Now()
-			}
-		} else {
-			// On other platforms, use button-based D-pad
-			// D-pad Up (bit 12)
-			if justPressed&4096 != 0 {
-				a.navigate(-1)
-			}
-			// D-pad Down (bit 13)
-			if justPressed&8192 != 0 {
-				a.navigate(1)
-			}
-			// D-pad Left (bit 14)
-			if justPressed&16384 != 0 {
-				if a.selectedSysIdx > 0 {
-					a.systemList.Select(a.selectedSysIdx - 1)
-				}
+	// Right stick - navigate games, speeding up with deflection and hold time
+	if rightY != 0 {
+		// Track how long stick has been held
+		if rightY != p.lastRightY {
+			p.rightHoldStart = time.Now()
+		}
+		scrollAmount, currentRepeatDelay := stickScroll(rightDeflection, time.Since(p.rightHoldStart), len(a.shownGames()))
+
+		// Just started moving or repeat timer elapsed
+		if rightY != p.lastRightY || time.Since(p.rightRepeatTimer) > currentRepeatDelay {
+			a.focusOnGames = true
+			if !a.moveGameSelection(0, rightY*scrollAmount) {
+				a.bumpListEnd()
 			}
-			// D-pad Right (bit 15)
-			if justPressed&32768 != 0 {
-				if a.selectedSysIdx < len(systemsList)-1 {
-					a.systemList.Select(a.selectedSysIdx + 1)
-				}
+			p.rightRepeatTimer = time.Now()
+			a.systemList.Refresh()
+			a.refreshGameView()
+		}
+	} else {
+		p.rightHoldStart = time.Time{}
+	}
+
+	// D-pad up/down moves through the focused list. On the games it speeds
+	// up when held, like the right stick pushed all the way.
+	if dpadY != 0 {
+		if dpadY != p.lastDpadY {
+			p.dpadHoldStart = time.Now()
+		}
+		moved := true
+		if a.focusOnGames {
+			step, delay := stickScroll(1, time.Since(p.dpadHoldStart), len(a.shownGames()))
+			if dpadY != p.lastDpadY || time.Since(p.dpadRepeatTimer) > delay {
+				moved = a.moveGameSelection(0, dpadY*step)
+				p.dpadRepeatTimer = time.Now()
 			}
+		} else if dpadY != p.lastDpadY || time.Since(p.dpadRepeatTimer) > padRepeatDelay {
+			moved = a.navigate(dpadY)
+			p.dpadRepeatTimer = time.Now()
 		}
+		if !moved {
+			a.bumpListEnd()
+		}
+	}
 
-		lastButtons = buttons
-		lastLeftY = leftY
-		lastRightY = rightY
-		lastDpadX = dpadX
-		lastDpadY = dpadY
+	// D-pad left/right switches between the systems and games, as the
+	// arrow keys do (in the grid, moving between columns first)
+	if dpadX != 0 && (dpadX != p.lastDpadX || time.Since(p.dpadXRepeatTimer) > padRepeatDelay) {
+		a.moveHorizontal(dpadX)
+		p.dpadXRepeatTimer = time.Now()
 	}
+
+	p.lastButtons = buttons
+	p.lastLeftY = leftY
+	p.lastRightY = rightY
+	p.lastDpadX = dpadX
+	p.lastDpadY = dpadY
 }
 
-func (a *App) navigate(delta int) {
+// navigate moves up or down the focused list, reporting whether the
+// selection moved (false at the top or bottom)
+func (a *App) navigate(delta int) bool {
 	if a.focusOnGames {
-		newIdx := a.selectedGameIdx + delta
-		if newIdx >= 0 && newIdx < len(a.filteredGames) {
-			a.selectedGameIdx = newIdx
-			a.gameList.Select(newIdx)
-			a.updateStatus()
-		}
-	} else {
-		newIdx := a.selectedSysIdx + delta
-		if newIdx >= 0 && newIdx < len(systemsList) {
-			a.systemList.Select(newIdx)
-		}
+		return a.moveGameSelection(0, delta)
+	}
+	newIdx := a.selectedSysIdx + delta
+	if newIdx < 0 || newIdx >= len(systemsList) {
+		return false
 	}
+	a.systemList.Select(newIdx)
+	return true
 }
 
 func (a *App) selectSystem(sysID string) {
 	a.currentSystem = sysID
-	config := systems[sysID]
-
-	// Clear existing games before loading new ones
-	a.allGames = nil
-	
-	// Load ROM JSON
-	jsonFile := filepath.Join(baseDir, "1g1rsets", config.RomJsonFile)
-	
-	// Debug logging
-	logFile, _ := os.OpenFile(filepath.Join(baseDir, "launcher_debug.log"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if logFile != nil {
-		logFile.WriteString(fmt.Sprintf("[%s] Selecting system: %s, JSON: %s\n", time.Now().Format("15:04:05"), sysID, jsonFile))
-		defer logFile.Close()
-	}
-	
-	data, err := os.ReadFile(jsonFile)
-	if err != nil {
-		a.statusBar.SetText(fmt.Sprintf("Error: %v", err))
-		if logFile != nil {
-			logFile.WriteString(fmt.Sprintf("[%s] ERROR reading file: %v\n", time.Now().Format("15:04:05"), err))
-		}
+	load := a.gamesLoad.Add(1)
+	a.restoreGame = ""
+	a.checkedMu.Lock()
+	a.checkedGames = nil
+	a.checkedMu.Unlock()
+	a.closeLetterJump()
+	if sysID != favoritesSystemID {
+		a.favoritesReturnSystem = ""
+	}
+	switch sysID {
+	case recentSystemID:
+		a.selectRecentGames()
+		return
+	case favoritesSystemID:
+		a.selectFavoriteGames()
 		return
-	}
-	
-	if logFile != nil {
-		logFile.WriteString(fmt.Sprintf("[%s] Read %d bytes from JSON\n", time.Now().Format("15:04:05"), len(data)))
 	}
 
-	if err := json.Unmarshal(data, &a.allGames); err != nil {
-		a.statusBar.SetText(fmt.Sprintf("Error: %v", err))
-		if logFile != nil {
-			logFile.WriteString(fmt.Sprintf("[%s] ERROR parsing JSON: %v\n", time.Now().Format("15:04:05"), err))
+	// Clear existing games; the set loads in the background and fills the
+	// list in as it's read
+	a.setLoadedGames(nil, nil)
+	a.filterGames()
+	logInfo("Selecting system: %s, JSON: %s", sysID, filepath.Join(baseDir, "1g1rsets", systems[sysID].RomJsonFile))
+	a.statusBar.SetText(fmt.Sprintf("Loading %s...", systems[sysID].Name))
+	go a.loadSystemGames(load, sysID)
+}
+
+func (a *App) buildROMCache() {
+	sysID := a.currentSystem
+	romCache := make(map[string]bool)
+	romBadges := make(map[string]string)
+	defer a.setROMCache(sysID, romCache, romBadges)
+
+	// Recently Played and All Favorites mix systems, so each one's folder is
+	// scanned for its games
+	games, _ := a.loadedGames()
+	if isVirtualSystem(sysID) {
+		bySystem := make(map[string][]ROM)
+		for _, game := range games {
+			bySystem[game.System] = append(bySystem[game.System], game)
+		}
+		for id, games := range bySystem {
+			scanROMs(id, games, romCache, romBadges)
 		}
 		return
 	}
-	
-	if logFile != nil {
-		logFile.WriteString(fmt.Sprintf("[%s] Loaded %d games\n", time.Now().Format("15:04:05"), len(a.allGames)))
-		// Debug: log first few games with TitleID for Wii U
-		for i, game := range a.allGames {
-			if i < 5 {
-				logFile.WriteString(fmt.Sprintf("[%s] Game[%d]: Name=%s, TitleID=%s, URL=%s\n", 
-					time.Now().Format("15:04:05"), i, game.Name, game.TitleID, game.URL))
-			}
-		}
+	scanROMs(sysID, games, romCache, romBadges)
+}
+
+// setROMCache swaps in a system's new maps at once so a finishing download
+// can't write into a cache that's being rebuilt for a different system
+func (a *App) setROMCache(sysID string, romCache map[string]bool, romBadges map[string]string) {
+	a.cacheMu.Lock()
+	a.romCache = romCache
+	a.romBadges = romBadges
+	a.cacheSystem = sysID
+	a.cacheMu.Unlock()
+}
+
+// publishROMCache is setROMCache for the loader started for load: it leaves
+// the cache be, and reports false, if another system has been selected since
+func (a *App) publishROMCache(load uint64, sysID string, romCache map[string]bool, romBadges map[string]string) bool {
+	a.cacheMu.Lock()
+	defer a.cacheMu.Unlock()
+	if a.gamesLoad.Load() != load {
+		return false
 	}
+	a.romCache = romCache
+	a.romBadges = romBadges
+	a.cacheSystem = sysID
+	return true
+}
 
-	// Build ROM cache
-	a.buildROMCache()
-	a.filterGames()
+// romCacheKey identifies a game in romCache/romBadges
+func romCacheKey(game ROM) string {
+	return game.System + "/" + game.Name
 }
 
-func (a *App) buildROMCache() {
-	a.romCache = make(map[string]bool)
-	config := systems[a.currentSystem]
+// scanROMs records which of a system's games are on disk, and their badges
+func scanROMs(sysID string, games []ROM, romCache map[string]bool, romBadges map[string]string) {
+	config := systems[sysID]
 	romDir := filepath.Join(romsDir, config.Dir)
 
-	entries, err := os.ReadDir(romDir)
-	if err != nil {
+	files := walkROMDir(romDir, config.isWiiU())
+	if len(files) == 0 {
 		return
 	}
 
+	// Files are matched by name wherever they are; folders only count for
+	// Wii U titles, which sit directly in the rom folder
 	existingFiles := make(map[string]bool)
 	existingDirs := make(map[string]bool)
-	for _, entry := range entries {
-		if entry.IsDir() {
-			existingDirs[strings.ToLower(entry.Name())] = true
-		} else {
-			existingFiles[strings.ToLower(entry.Name())] = true
+	localTitles := make(map[string]int) // lower-case title -> highest revision on disk
+	for _, file := range files {
+		if !file.IsDir {
+			existingFiles[strings.ToLower(file.Name)] = true
+		} else if file.Depth == 0 {
+			existingDirs[strings.ToLower(file.Name)] = true
+		}
+		tags := parseROMTags(file.Name)
+		key := strings.ToLower(tags.Title)
+		if rev, ok := localTitles[key]; !ok || tags.Revision > rev {
+			localTitles[key] = tags.Revision
 		}
 	}
 
-	for _, game := range a.allGames {
+	// onDisk reports whether a single game file (or disc) is downloaded
+	onDisk := func(game ROM) bool {
 		exists := false
 		
 		// For Wii U games, check for directory with sanitized name
-		if config.SpecialDownload == "wiiu" {
-			sanitizedName := strings.Map(func(r rune) rune {
-				if r == '/' || r == '\\' || r == ':' || r == '*' || r == '?' || r == '"' || r == '<' || r == '>' || r == '|' {
-					return '_'
-				}
-				return r
-			}, game.Name)
+		if config.isWiiU() {
+			sanitizedName := sanitizeFileName(game.Name)
 			if existingDirs[strings.ToLower(sanitizedName)] {
 				// Check if the directory has content (code or meta folder)
 				gamePath := filepath.Join(romDir, sanitizedName)
 				codePath := filepath.Join(gamePath, "code")
 				metaPath := filepath.Join(gamePath, "meta")
-				if _, err := os.Stat(codePath); err == nil {
+				// title.tmd is removed after decrypting, so a title still
+				// holding it is a download that stopped part way
+				if fileExists(filepath.Join(gamePath, "title.tmd")) {
+					exists = false
+				} else if _, err := os.Stat(codePath); err == nil {
 					exists = true
 				} else if _, err := os.Stat(metaPath); err == nil {
 					exists = true
 				}
 			}
 		} else {
-			baseName := strings.TrimSuffix(game.Name, ".zip")
+			baseName := trimArchiveExt(game.Name)
 
 			for _, ext := range config.FileExtensions {
 				if existingFiles[strings.ToLower(baseName+ext)] {
@@ -1796,87 +2307,192 @@ func (a *App) buildROMCache() {
 				}
 			}
 		}
+		return exists
+	}
+
+	// A multi-disc set's discs are recorded one by one, as they're
+	// downloaded one by one; the set is downloaded once they all are
+	for _, game := range games {
+		exists := true
+		for _, disc := range gameDiscs(game) {
+			discExists := onDisk(disc)
+			romCache[romCacheKey(disc)] = discExists
+			exists = exists && discExists
+		}
+		romBadges[romCacheKey(game)] = romBadge(game, exists, localTitles)
+	}
+}
+
+// isDownloaded reports whether a game from the shown system is on disk - for
+// a multi-disc set, every disc
+func (a *App) isDownloaded(game ROM) bool {
+	a.cacheMu.Lock()
+	defer a.cacheMu.Unlock()
+	if !a.cacheCovers(game) {
+		return false
+	}
+	for _, disc := range gameDiscs(game) {
+		if !a.romCache[romCacheKey(disc)] {
+			return false
+		}
+	}
+	return true
+}
 
-		a.romCache[game.Name] = exists
+// cacheCovers reports whether romCache describes a game's system; must be
+// called with cacheMu held
+func (a *App) cacheCovers(game ROM) bool {
+	return game.System == a.cacheSystem || isVirtualSystem(a.cacheSystem)
+}
+
+// romBadgeFor returns the availability badge for a game from the shown system
+func (a *App) romBadgeFor(game ROM) string {
+	a.cacheMu.Lock()
+	defer a.cacheMu.Unlock()
+	if !a.cacheCovers(game) {
+		return ""
 	}
+	return a.romBadges[romCacheKey(game)]
+}
+
+// setDownloaded records that a game was downloaded or deleted. It returns
+// false, changing nothing, if the game's system is no longer shown - that
+// system's cache is rebuilt from disk when it's selected again.
+func (a *App) setDownloaded(game ROM, downloaded bool) bool {
+	a.cacheMu.Lock()
+	defer a.cacheMu.Unlock()
+	if !a.cacheCovers(game) {
+		return false
+	}
+	for _, disc := range gameDiscs(game) {
+		a.romCache[romCacheKey(disc)] = downloaded
+	}
+	if downloaded {
+		a.romBadges[romCacheKey(game)] = romBadge(game, true, nil)
+	} else {
+		a.romBadges[romCacheKey(game)] = ""
+	}
+	return true
 }
 
 func (a *App) filterGames() {
-	a.filteredGames = []ROM{}
-	query := strings.ToLower(a.searchQuery)
+	allGames, lowerNames := a.loadedGames()
+	filtered := []ROM{}
+	search := newSearchQuery(a.searchQuery)
+	ranks := make(map[string]int)
+
+	// The second pass only runs when nothing matched, and allows fuzzy matches
+	for pass := 0; pass < 2; pass++ {
+		fuzzy := pass == 1
+		for i, game := range allGames {
+			// Search filter
+			if !search.empty() {
+				lower := lowerName(allGames, lowerNames, i)
+				rank := search.match(lower)
+				if fuzzy && rank < 0 && search.fuzzyMatch(lower) {
+					rank = rankFuzzy
+				}
+				if rank < 0 {
+					continue
+				}
+				ranks[game.Name] = rank
+			}
 
-	for _, game := range a.allGames {
-		// Search filter
-		if query != "" && !strings.Contains(strings.ToLower(game.Name), query) {
-			continue
-		}
+			// Favorites filter
+			if a.showFavsOnly && !a.isFavorite(game) {
+				continue
+			}
 
-		// Favorites filter
-		if a.showFavsOnly && !a.isFavorite(game.Name) {
-			continue
-		}
+			// Downloaded / not downloaded filter
+			if !a.matchesDownloadFilter(game) {
+				continue
+			}
 
-		a.filteredGames = append(a.filteredGames, game)
+			// Region / language filter
+			if !matchesRegionFilter(game) {
+				continue
+			}
+
+			filtered = append(filtered, game)
+		}
+		if len(filtered) > 0 || search.empty() {
+			break
+		}
+	}
+	sortGames(filtered, a.sortMode)
+	a.orderFavoritesFirst(filtered)
+	if !search.empty() {
+		rankResults(filtered, ranks)
 	}
+	filtered, revisions := collapseRevisions(filtered, a.expandedRevisionGroups())
 
-	a.gameList.Refresh()
-	a.statusBar.SetText(fmt.Sprintf("%d games", len(a.filteredGames)))
+	a.setShownGames(filtered, revisions)
+	a.refreshGameView()
+	a.statusBar.SetText(fmt.Sprintf("%d games", len(filtered)))
 
-	if len(a.filteredGames) > 0 {
-		a.gameList.Select(0)
+	if len(filtered) > 0 {
+		a.selectGame(0)
 	}
+	a.rememberSelection()
 }
 
-func (a *App) isFavorite(gameName string) bool {
-	if favorites[a.currentSystem] == nil {
-		return false
+// lowerName returns the lower-cased name of games[i]
+func lowerName(games []ROM, lowerNames []string, i int) string {
+	if i < len(lowerNames) {
+		return lowerNames[i]
 	}
-	return favorites[a.currentSystem][gameName]
+	return strings.ToLower(games[i].Name)
+}
+
+func (a *App) isFavorite(game ROM) bool {
+	favoritesMu.RLock()
+	defer favoritesMu.RUnlock()
+	return favorites[game.System][game.Name]
 }
 
 func (a *App) toggleSelectedFavorite() {
-	if a.selectedGameIdx < 0 || a.selectedGameIdx >= len(a.filteredGames) {
+	game, ok := a.selectedGame()
+	if !ok {
 		return
 	}
 
-	game := a.filteredGames[a.selectedGameIdx]
-	if favorites[a.currentSystem] == nil {
-		favorites[a.currentSystem] = make(map[string]bool)
-	}
-
-	if favorites[a.currentSystem][game.Name] {
-		delete(favorites[a.currentSystem], game.Name)
+	if a.isFavorite(game) {
+		setFavorite(game, false)
 		a.statusBar.SetText("Removed from favorites")
 	} else {
-		favorites[a.currentSystem][game.Name] = true
+		setFavorite(game, true)
 		a.statusBar.SetText("Added to favorites")
 	}
 	saveFavorites()
-	a.gameList.Refresh()
+	a.refreshGameView()
 }
 
 func (a *App) updateStatus() {
-	if a.selectedGameIdx < 0 || a.selectedGameIdx >= len(a.filteredGames) {
+	game, ok := a.selectedGame()
+	if !ok {
 		return
 	}
-	game := a.filteredGames[a.selectedGameIdx]
-	name := strings.TrimSuffix(game.Name, ".zip")
+	name := gameDisplayName(game)
 	name = strings.TrimSuffix(name, ".chd")
 
-	if a.romCache[game.Name] {
-		a.statusBar.SetText(fmt.Sprintf("Ready: %s", name))
-	} else {
-		a.statusBar.SetText(fmt.Sprintf("Not downloaded: %s (%s)", name, game.Size))
+	status := fmt.Sprintf("Not downloaded: %s (%s)", name, game.Size)
+	if a.isDownloaded(game) {
+		status = fmt.Sprintf("Ready: %s", name)
+	}
+	if stat, ok := playStatFor(game.System, game.Name); ok {
+		status += fmt.Sprintf(" | Last played: %s | Play time: %s",
+			stat.LastPlayed.Format("2006-01-02 15:04"), formatPlayTime(stat.TotalSeconds))
 	}
+	a.statusBar.SetText(status)
 }
 
 func (a *App) updateLaunchButton() {
-	if a.selectedGameIdx < 0 || a.selectedGameIdx >= len(a.filteredGames) {
+	game, ok := a.selectedGame()
+	if !ok {
 		a.launchBtn.SetText("Launch")
 		return
 	}
-	game := a.filteredGames[a.selectedGameIdx]
-	if a.romCache[game.Name] {
+	if a.isDownloaded(game) {
 		a.launchBtn.SetText("Launch")
 	} else {
 		a.launchBtn.SetText("Download")
@@ -1884,73 +2500,103 @@ func (a *App) updateLaunchButton() {
 }
 
 func (a *App) launchSelected() {
-	if a.selectedGameIdx < 0 || a.selectedGameIdx >= len(a.filteredGames) {
+	game, ok := a.selectedGame()
+	if !ok {
 		a.statusBar.SetText("No game selected")
 		return
 	}
 
-	game := a.filteredGames[a.selectedGameIdx]
-	if !a.romCache[game.Name] {
+	if !a.isDownloaded(game) {
 		a.statusBar.SetText("Game not downloaded yet")
 		return
 	}
 
-	a.launchGame(game)
+	a.launchGame(game, false)
 }
 
-func (a *App) downloadSelected() {
-	if a.selectedGameIdx < 0 || a.selectedGameIdx >= len(a.filteredGames) {
+// chooseEmulatorSelected launches the selected game through the emulator
+// chooser, even if its system has a default emulator
+func (a *App) chooseEmulatorSelected() {
+	game, ok := a.selectedGame()
+	if !ok {
 		a.statusBar.SetText("No game selected")
 		return
 	}
 
-	game := a.filteredGames[a.selectedGameIdx]
-	if a.romCache[game.Name] {
-		a.statusBar.SetText("Already downloaded")
+	if !a.isDownloaded(game) {
+		a.statusBar.SetText("Game not downloaded yet")
 		return
 	}
 
-	a.downloadGame(game)
+	a.launchGame(game, true)
 }
 
-func (a *App) launchGame(game ROM) {
-	config := systems[a.currentSystem]
+func (a *App) downloadSelected() {
+	game, ok := a.selectedGame()
+	if !ok {
+		a.statusBar.SetText("No game selected")
+		return
+	}
 
-	// Count total options
-	totalOptions := 0
-	
-	// Main emulator: either has cores or is standalone
-	if len(config.Emulator.Cores) > 0 {
-		totalOptions += len(config.Emulator.Cores)
-	} else if config.Emulator.Path != "" {
-		totalOptions++
+	if a.isDownloaded(game) {
+		a.statusBar.SetText("Already downloaded")
+		return
 	}
-	
-	// Standalone emulator: either has cores or is standalone
-	if config.StandaloneEmulator != nil {
-		if len(config.StandaloneEmulator.Cores) > 0 {
-			totalOptions += len(config.StandaloneEmulator.Cores)
-		} else if config.StandaloneEmulator.Path != "" {
-			totalOptions++
+
+	// A multi-disc set queues each disc that isn't downloaded yet
+	a.confirmDiskSpace(game, func() {
+		for _, disc := range gameDiscs(game) {
+			if len(game.Discs) > 0 && a.isDownloaded(disc) {
+				continue
+			}
+			a.enqueueDownload(game.System, disc, nil)
 		}
+	})
+}
+
+// launchGame launches a game with the one emulator that can, its default, or
+// the one chosen. Choices whose emulator or core isn't installed are never
+// launched; the chooser shows them greyed out.
+func (a *App) launchGame(game ROM, forceChooser bool) {
+	if a.launchBlocked() {
+		return
+	}
+	config := systems[game.System]
+	a.collectEmulatorChoices(config)
+	if len(a.emulatorChoices) == 0 {
+		a.statusBar.SetText(fmt.Sprintf("No emulator configured for %s", config.Name))
+		return
 	}
 
-	if totalOptions > 1 {
-		a.showEmulatorChoice(game, config)
-	} else {
-		// Single option - launch directly
-		args := config.Emulator.Args
-		if len(config.Emulator.Cores) == 1 {
-			args = []string{"-L", config.Emulator.Cores[0].GetCorePath()}
+	available := a.availableEmulators()
+	switch {
+	case len(available) == 0:
+		a.statusBar.SetText(fmt.Sprintf("No emulator for %s is installed (%s: %s); run EmuBuddySetup",
+			config.Name, a.emulatorChoices[0], a.emulatorMissing[0]))
+	case forceChooser && len(a.emulatorChoices) > 1:
+		a.showEmulatorChoice(game)
+	case len(available) == 1:
+		// Single working option - launch directly
+		idx := available[0]
+		a.launchWithEmulator(game, a.emulatorPaths[idx], a.emulatorArgs[idx])
+	default:
+		// A saved default skips the chooser unless the user asked for it
+		if idx := a.defaultEmulatorIdx(game); a.emulatorAvailable(idx) && !settings.AlwaysAskEmulator {
+			logDebug("Launching with default emulator for %s: %s", game.System, a.emulatorChoices[idx])
+			a.launchWithEmulator(game, a.emulatorPaths[idx], a.emulatorArgs[idx])
+			return
 		}
-		a.launchWithEmulator(game, config.Emulator.Path, args)
+		a.showEmulatorChoice(game)
 	}
 }
 
-func (a *App) showEmulatorChoice(game ROM, config SystemConfig) {
+// collectEmulatorChoices fills emulatorChoices/Paths/Args with every way the
+// system can be launched: each core of the main emulator, then the standalone
+func (a *App) collectEmulatorChoices(config SystemConfig) {
 	a.emulatorChoices = []string{}
 	a.emulatorPaths = []string{}
 	a.emulatorArgs = [][]string{}
+	a.emulatorMissing = []string{}
 
 	// Add main emulator options
 	if len(config.Emulator.Cores) > 0 {
@@ -1958,7 +2604,8 @@ func (a *App) showEmulatorChoice(game ROM, config SystemConfig) {
 		for _, core := range config.Emulator.Cores {
 			a.emulatorChoices = append(a.emulatorChoices, fmt.Sprintf("RetroArch (%s)", core.Name))
 			a.emulatorPaths = append(a.emulatorPaths, config.Emulator.Path)
-			a.emulatorArgs = append(a.emulatorArgs, []string{"-L", core.GetCorePath()})
+			a.emulatorArgs = append(a.emulatorArgs, core.LaunchArgs())
+			a.emulatorMissing = append(a.emulatorMissing, emulatorUnavailable(config.Emulator.Path, &core))
 		}
 	} else if config.Emulator.Path != "" {
 		// Standalone emulator (no cores)
@@ -1969,6 +2616,7 @@ func (a *App) showEmulatorChoice(game ROM, config SystemConfig) {
 		a.emulatorChoices = append(a.emulatorChoices, name)
 		a.emulatorPaths = append(a.emulatorPaths, config.Emulator.Path)
 		a.emulatorArgs = append(a.emulatorArgs, config.Emulator.Args)
+		a.emulatorMissing = append(a.emulatorMissing, emulatorUnavailable(config.Emulator.Path, nil))
 	}
 
 	// Add standalone emulator options
@@ -1978,7 +2626,8 @@ func (a *App) showEmulatorChoice(game ROM, config SystemConfig) {
 			for _, core := range config.StandaloneEmulator.Cores {
 				a.emulatorChoices = append(a.emulatorChoices, fmt.Sprintf("RetroArch (%s)", core.Name))
 				a.emulatorPaths = append(a.emulatorPaths, config.StandaloneEmulator.Path)
-				a.emulatorArgs = append(a.emulatorArgs, []string{"-L", core.GetCorePath()})
+				a.emulatorArgs = append(a.emulatorArgs, core.LaunchArgs())
+				a.emulatorMissing = append(a.emulatorMissing, emulatorUnavailable(config.StandaloneEmulator.Path, &core))
 			}
 		} else if config.StandaloneEmulator.Path != "" {
 			// Standalone (no cores)
@@ -1989,9 +2638,44 @@ func (a *App) showEmulatorChoice(game ROM, config SystemConfig) {
 			a.emulatorChoices = append(a.emulatorChoices, name)
 			a.emulatorPaths = append(a.emulatorPaths, config.StandaloneEmulator.Path)
 			a.emulatorArgs = append(a.emulatorArgs, config.StandaloneEmulator.Args)
+			a.emulatorMissing = append(a.emulatorMissing, emulatorUnavailable(config.StandaloneEmulator.Path, nil))
+		}
+	}
+}
+
+// emulatorAvailable reports whether emulator choice idx can be launched
+func (a *App) emulatorAvailable(idx int) bool {
+	return idx >= 0 && idx < len(a.emulatorMissing) && a.emulatorMissing[idx] == ""
+}
+
+// availableEmulators returns the indexes of the choices that can be launched
+func (a *App) availableEmulators() []int {
+	var available []int
+	for i := range a.emulatorChoices {
+		if a.emulatorAvailable(i) {
+			available = append(available, i)
 		}
 	}
+	return available
+}
 
+// moveEmulatorSelection moves the highlighted emulator choice by step,
+// skipping ones that can't be launched. It stays put at the ends.
+func (a *App) moveEmulatorSelection(step int) {
+	for idx := a.selectedEmulatorIdx + step; idx >= 0 && idx < len(a.emulatorChoices); idx += step {
+		if a.emulatorAvailable(idx) {
+			a.selectedEmulatorIdx = idx
+			a.emulatorList.Select(idx)
+			a.emulatorList.Refresh()
+			return
+		}
+	}
+}
+
+// showEmulatorChoice swaps in the emulator panel for the choices gathered by
+// collectEmulatorChoices, starting on the game's default if it can launch,
+// otherwise the first choice that can
+func (a *App) showEmulatorChoice(game ROM) {
 	if len(a.emulatorChoices) == 0 {
 		return
 	}
@@ -1999,15 +2683,30 @@ func (a *App) showEmulatorChoice(game ROM, config SystemConfig) {
 	// Store pending game and switch to emulator choice mode
 	a.pendingGame = game
 	a.selectedEmulatorIdx = 0
+	if available := a.availableEmulators(); len(available) > 0 {
+		a.selectedEmulatorIdx = available[0]
+	}
+	if idx := a.defaultEmulatorIdx(game); a.emulatorAvailable(idx) {
+		a.selectedEmulatorIdx = idx
+	}
 	a.choosingEmulator = true
+	a.updateDefaultEmulatorButtons()
 	
 	// Swap game panel for emulator panel
 	a.rightPanel.Objects = []fyne.CanvasObject{a.emulatorPanel}
 	a.rightPanel.Refresh()
-	a.emulatorList.Select(0)
+	a.emulatorList.Select(a.selectedEmulatorIdx)
 	a.emulatorList.Refresh()
 	
-	a.statusBar.SetText(fmt.Sprintf("Choose emulator for: %s", game.Name))
+	status := fmt.Sprintf("Choose emulator for: %s", game.Name)
+	if label, forGame := defaultEmulatorLabel(game); label != "" {
+		if forGame {
+			status += fmt.Sprintf(" (default for this game: %s)", label)
+		} else {
+			status += fmt.Sprintf(" (default: %s)", label)
+		}
+	}
+	a.statusBar.SetText(status)
 }
 
 func (a *App) cancelEmulatorChoice() {
@@ -2019,52 +2718,25 @@ func (a *App) cancelEmulatorChoice() {
 
 func (a *App) confirmEmulatorChoice() {
 	if a.selectedEmulatorIdx >= 0 && a.selectedEmulatorIdx < len(a.emulatorPaths) {
+		if !a.emulatorAvailable(a.selectedEmulatorIdx) {
+			a.statusBar.SetText(fmt.Sprintf("%s can't be launched: %s", a.emulatorChoices[a.selectedEmulatorIdx], a.emulatorMissing[a.selectedEmulatorIdx]))
+			return
+		}
 		a.choosingEmulator = false
 		a.rightPanel.Objects = []fyne.CanvasObject{a.gamePanel}
 		a.rightPanel.Refresh()
 		a.launchWithEmulator(a.pendingGame, a.emulatorPaths[a.selectedEmulatorIdx], a.emulatorArgs[a.selectedEmulatorIdx])
 	}
-}
-
-func (a *App) launchWithEmulator(game ROM, emuPath string, emuArgs []string) {
-	config := systems[a.currentSystem]
-	romDir := filepath.Join(romsDir, config.Dir)
-
-	// Resolve platform-specific path
-	emuPath = resolvePlatformPath(emuPath)
-	
-	// Handle flatpak on Linux
-	isFlatpak := strings.HasPrefix(emuPath, "flatpak:")
-	var flatpakAppID string
-	if isFlatpak {
-		flatpakAppID = strings.TrimPrefix(emuPath, "flatpak:")
-		emuPath = "flatpak"
-	} else {
-		emuPath = filepath.Join(baseDir, emuPath)
-	}
-	emuDir := filepath.Dir(emuPath)
-
-	// On Linux, ensure AppImages are executable
-	if runtime.GOOS == "linux" && strings.HasSuffix(strings.ToLower(emuPath), ".appimage") {
-		os.Chmod(emuPath, 0755)
-	}
-
-	// Log the resolved path for debugging
-	logDebug("Launching with emulator: %s", emuPath)
-	logDebug("Emulator dir: %s", emuDir)
+}
 
-	// Find ROM file
+// findROMFile returns the file (or for Wii U, the .rpx or title folder) a
+// downloaded game launches from, or "" if it isn't on disk
+func findROMFile(config SystemConfig, romDir string, game ROM) string {
 	var romPath string
-	
+
 	// For Wii U games, the ROM is a directory
-	if config.SpecialDownload == "wiiu" {
-		sanitizedName := strings.Map(func(r rune) rune {
-			if r == '/' || r == '\\' || r == ':' || r == '*' || r == '?' || r == '"' || r == '<' || r == '>' || r == '|' {
-				return '_'
-			}
-			return r
-		}, game.Name)
-		romPath = filepath.Join(romDir, sanitizedName)
+	if config.isWiiU() {
+		romPath = filepath.Join(romDir, sanitizeFileName(game.Name))
 		
 		// For Cemu, we need to point to the rpx file in the code folder
 		rpxPath := filepath.Join(romPath, "code")
@@ -2077,7 +2749,7 @@ func (a *App) launchWithEmulator(game ROM, emuPath string, emuArgs []string) {
 			}
 		}
 	} else if config.NeedsExtract {
-		baseName := strings.TrimSuffix(game.Name, ".zip")
+		baseName := trimArchiveExt(game.Name)
 		for _, ext := range config.FileExtensions {
 			testPath := filepath.Join(romDir, baseName+ext)
 			if fileExists(testPath) {
@@ -2110,22 +2782,98 @@ func (a *App) launchWithEmulator(game ROM, emuPath string, emuArgs []string) {
 	}
 
 	if !fileExists(romPath) {
-		a.statusBar.SetText("ROM not found: " + game.Name)
+		if !config.isWiiU() {
+			return findNestedROM(config, romDir, game)
+		}
+		return ""
+	}
+	return romPath
+}
+
+// launchWithEmulator launches a game once its BIOS files are checked. A
+// multi-disc set is launched from its .m3u playlist on RetroArch; other
+// emulators are given the disc the user picks.
+func (a *App) launchWithEmulator(game ROM, emuPath string, emuArgs []string) {
+	a.checkBios(game.System, emuPath, emuArgs, func() {
+		if len(game.Discs) > 1 && !usesDiscPlaylist(emuArgs) {
+			a.chooseDisc(game, func(disc int) {
+				a.launchFile(game, game.Discs[disc], emuPath, emuArgs)
+			})
+			return
+		}
+		a.launchFile(game, game, emuPath, emuArgs)
+	})
+}
+
+// launchFile launches file, which is game itself or one of its discs. Play
+// time is recorded against game.
+func (a *App) launchFile(game, file ROM, emuPath string, emuArgs []string) {
+	if a.updatingEmulators.Load() {
+		a.statusBar.SetText("Emulators are being updated; launch the game when that's done")
+		return
+	}
+	// Checked again, as the chooser and the BIOS and disc dialogs wait for the user
+	if a.launchBlocked() {
+		return
+	}
+	sysID := game.System
+	config := systems[sysID]
+	romDir := filepath.Join(romsDir, config.Dir)
+	// Matched against the path as written in systems.json, before resolving
+	emuArgs = withFullscreen(sysID, emuPath, emuArgs)
+	// As configured, for the BIOS check if the launch fails
+	configuredPath, configuredArgs := emuPath, emuArgs
+
+	emu := resolveEmulator(emuPath)
+	emuPath, emuDir := emu.Exe, emu.Dir
+	isFlatpak := emu.Style == launchFlatpak
+	appImageMode := emu.AppImageMode
+
+	// On Linux, ensure AppImages are executable
+	if emu.Style == launchAppImage {
+		os.Chmod(emuPath, 0755)
+	}
+
+	// Log the resolved path for debugging
+	logDebug("Launching with emulator: %s", emuPath)
+	logDebug("Emulator dir: %s", emuDir)
+
+	// Find ROM file
+	var romPath string
+	if len(game.Discs) > 0 && usesDiscPlaylist(emuArgs) {
+		playlist, err := writeDiscPlaylist(sysID, game.Discs)
+		if err != nil {
+			logDebug("Disc playlist for %s: %v, launching the first disc", game.Name, err)
+		} else {
+			romPath = playlist
+		}
+	}
+	if romPath == "" {
+		romPath = findROMFile(config, romDir, file)
+	}
+	if romPath == "" {
+		a.statusBar.SetText("ROM not found: " + file.Name)
 		return
 	}
 
+	// Force RetroArch's content type for ambiguous extensions (e.g. .bin)
+	emuArgs = withRetroArchSubsystem(emuArgs, retroarchSubsystemFor(sysID, game.Name))
+
 	// Build args
 	args := []string{}
 
 	// For flatpak, add "run" and the app ID first
 	if isFlatpak {
-		args = append(args, "run", flatpakAppID)
+		args = append(args, "run", emu.FlatpakID)
 	}
 
 	for _, arg := range emuArgs {
-		if strings.Contains(arg, "/") || strings.Contains(arg, "\\") {
+		if hasArgPlaceholder(arg) {
+			// Expanded below, once the ROM and core paths are known
+			args = append(args, arg)
+		} else if strings.Contains(arg, "/") || strings.Contains(arg, "\\") {
 			// Resolve platform-specific core paths
-			resolvedArg := resolvePlatformPath(arg)
+			resolvedArg := resolveCorePath(arg)
 			logDebug("Path resolution: '%s' -> '%s' (IsAbs=%v, platform=%s)", arg, resolvedArg, filepath.IsAbs(resolvedArg), runtime.GOOS)
 
 			// If resolved path is absolute, use it directly; otherwise join with emuDir
@@ -2139,7 +2887,7 @@ func (a *App) launchWithEmulator(game ROM, emuPath string, emuArgs []string) {
 				// On Linux, verify core file exists
 				if runtime.GOOS == "linux" && strings.HasSuffix(strings.ToLower(resolvedPath), ".so") {
 					if !fileExists(resolvedPath) {
-						logDebug("ERROR: Core file not found: %s", resolvedPath)
+						logError("Core file not found: %s", resolvedPath)
 						a.statusBar.SetText(fmt.Sprintf("Core not found: %s", filepath.Base(resolvedPath)))
 						return
 					}
@@ -2152,139 +2900,157 @@ func (a *App) launchWithEmulator(game ROM, emuPath string, emuArgs []string) {
 			args = append(args, arg)
 		}
 	}
-	args = append(args, romPath)
+	args = expandLaunchArgs(args, romPath, romDir)
 
 	// Log launch command for debugging
-	logDebug("Launch command: %s %v", emuPath, args)
 	logDebug("Emulator directory: %s", emuDir)
 	logDebug("ROM path: %s", romPath)
 
-	cmd := exec.Command(emuPath, args...)
-	if !isFlatpak {
-		// On Linux, for AppImages (standalone emulators), use base directory as working dir
-		// This fixes issues with Cemu and other AppImages that need to run from the project root
-		if runtime.GOOS == "linux" && strings.HasSuffix(strings.ToLower(emuPath), ".appimage") {
-			cmd.Dir = baseDir
-			logDebug("Using base directory as working dir for AppImage: %s", baseDir)
-		} else {
-			cmd.Dir = emuDir
-		}
-	}
-	logDebug("Working directory: %s", cmd.Dir)
+	appImage := emu.Style == launchAppImage
+	extractAndRun := appImage && useExtractAndRun(appImageMode)
 
-	// On Linux, set environment variables to fix AppImage compatibility
-	if runtime.GOOS == "linux" {
-		// Force SDL to use X11 instead of Wayland (fixes EGL symbol errors)
-		cmd.Env = append(os.Environ(),
-			"SDL_VIDEODRIVER=x11",
-			"QT_QPA_PLATFORM=xcb",
-		)
+	// The emulator's output goes to this launch's own log on every platform
+	command := commandLine(emuPath, args)
+	output := newLaunchLog(sysID, file, command)
 
-		// Capture stderr to debug log for troubleshooting
-		if debugLog != nil {
-			cmd.Stderr = debugLog
-			cmd.Stdout = debugLog
+	start := func(extractAndRun bool) (*exec.Cmd, *fuseErrorWatcher, error) {
+		cmdArgs := args
+		if extractAndRun {
+			cmdArgs = withExtractAndRun(args)
 		}
-	}
+		logDebug("Launch command: %s %v", emuPath, cmdArgs)
 
-	if err := cmd.Start(); err != nil {
-		logDebug("Failed to start: %v", err)
-		a.statusBar.SetText(fmt.Sprintf("Launch failed: %v", err))
-		return
-	}
+		cmd := exec.Command(emuPath, cmdArgs...)
+		if !isFlatpak {
+			// On Linux, for AppImages (standalone emulators), use base directory as working dir
+			// This fixes issues with Cemu and other AppImages that need to run from the project root
+			if appImage {
+				cmd.Dir = baseDir
+				logDebug("Using base directory as working dir for AppImage: %s", baseDir)
+			} else {
+				cmd.Dir = emuDir
+			}
+		}
+		logDebug("Working directory: %s", cmd.Dir)
 
-	// Disable controller input while game is running (prevents background navigation)
-	a.gameRunning = true
-	logDebug("Game launched - controller input disabled in launcher")
+		// Capture output for the launch log, and the debug log when debugging
+		var out io.Writer = output
+		if debugOut := emulatorOutput(); debugOut != nil {
+			out = io.MultiWriter(output, debugOut)
+		}
+		cmd.Stdout = out
+		cmd.Stderr = out
 
-	// On Linux, check if process exits immediately (indicates error)
-	if runtime.GOOS == "linux" {
-		go func() {
-			err := cmd.Wait()
-			if err != nil {
-				logDebug("Process exited with error: %v", err)
-			}
-			// Re-enable controller input when game exits
-			a.gameRunning = false
-			logDebug("Game exited - controller input re-enabled in launcher")
-		}()
-	}
+		var watcher *fuseErrorWatcher
+		// On Linux, set environment variables to fix AppImage compatibility
+		if runtime.GOOS == "linux" {
+			// Force SDL to use X11 instead of Wayland (fixes EGL symbol errors)
+			cmd.Env = append(os.Environ(),
+				"SDL_VIDEODRIVER=x11",
+				"QT_QPA_PLATFORM=xcb",
+			)
 
-	a.statusBar.SetText("Launched: " + game.Name)
-}
+			// Watch for the AppImage runtime failing to mount without FUSE
+			if appImage && !extractAndRun {
+				watcher = &fuseErrorWatcher{}
+				cmd.Stderr = io.MultiWriter(out, watcher)
+			}
+		}
 
-func (a *App) downloadGame(game ROM) {
-	config := systems[a.currentSystem]
-	romDir := filepath.Join(romsDir, config.Dir)
-	os.MkdirAll(romDir, 0755)
+		return cmd, watcher, cmd.Start()
+	}
 
-	// Debug logging
-	debugLog, _ := os.OpenFile(filepath.Join(baseDir, "launcher_debug.log"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if debugLog != nil {
-		debugLog.WriteString(fmt.Sprintf("[%s] downloadGame: Name=%s, TitleID=%s, SpecialDownload=%s\n", 
-			time.Now().Format("15:04:05"), game.Name, game.TitleID, config.SpecialDownload))
-		debugLog.Close()
+	hooks := launchHooks(sysID)
+	if err := runLaunchHook("Pre-launch", hooks.PreLaunch, sysID, game, romPath, emuPath); err != nil {
+		if settings.RequireHookSuccess {
+			a.statusBar.SetText(fmt.Sprintf("Launch cancelled: %v", err))
+			return
+		}
+		a.statusBar.SetText(fmt.Sprintf("Warning: %v", err))
 	}
 
-	// Handle Wii U special download
-	if config.SpecialDownload == "wiiu" && game.TitleID != "" {
-		a.downloadWiiUGame(game)
+	cmd, watcher, err := start(extractAndRun)
+	if err != nil {
+		logDebug("Failed to start: %v", err)
+		logError("launch failed: system=%s game=%q err=%v", sysID, game.Name, err)
+		fmt.Fprintf(output, "Failed to start: %v\n", err)
+		output.Close()
+		a.statusBar.SetText(fmt.Sprintf("Launch failed: %v", err))
+		a.showLaunchFailure(file, fmt.Sprintf("%s couldn't be started:\n%v", filepath.Base(emuPath), err),
+			command, nil, output.path, launchHints(sysID, configuredPath, configuredArgs, args, err, nil))
 		return
 	}
+	launchedAt := time.Now()
+	pid := cmd.Process.Pid
+	a.trackLaunch(file, pid)
+	logInfo("launch start: system=%s game=%q pid=%d extractAndRun=%v", sysID, game.Name, pid, extractAndRun)
+	recordLaunch(sysID, game.Name, launchedAt)
 
-	outputPath := filepath.Join(romDir, game.Name)
-
-	progressBar := widget.NewProgressBar()
-	progressLabel := widget.NewLabel("Starting download...")
-
-	progressContent := container.NewVBox(
-		widget.NewLabel(game.Name),
-		progressBar,
-		progressLabel,
-	)
-
-	progressDialog := dialog.NewCustom("Downloading", "Cancel", progressContent, a.window)
-	cancelled := false
-	progressDialog.SetOnClosed(func() {
-		cancelled = true
-	})
-	progressDialog.Show()
+	// Disable controller input while game is running (prevents background navigation)
+	a.gameRunning.Add(1)
+	logDebug("Game launched - controller input disabled in launcher")
 
+	// Wait for the emulator to exit to track play time. On Linux this also
+	// catches processes that exit immediately (indicates error).
 	go func() {
-		err := downloadWithProgress(game.URL, outputPath, func(downloaded, total int64) {
-			if cancelled {
-				return
-			}
-			if total > 0 {
-				pct := float64(downloaded) / float64(total)
-				progressBar.SetValue(pct)
-				progressLabel.SetText(fmt.Sprintf("%.1f MB / %.1f MB", float64(downloaded)/1024/1024, float64(total)/1024/1024))
-			}
-		})
-
-		if cancelled {
-			os.Remove(outputPath)
-			return
-		}
-
+		err := cmd.Wait()
 		if err != nil {
-			progressDialog.Hide()
-			dialog.ShowError(err, a.window)
-			return
+			logWarn("Process exited with error: %v", err)
+
+			// A direct AppImage launch that died for lack of FUSE is retried
+			// with extract-and-run, unless the emulator is pinned to direct
+			if watcher != nil && watcher.failed() && appImageMode != appImageLaunchDirect {
+				logWarn("AppImage could not mount (FUSE unavailable), retrying with %s", appImageExtractAndRunFlag)
+				retry, _, retryErr := start(true)
+				if retryErr == nil {
+					err = retry.Wait()
+					if err != nil {
+						logWarn("Process exited with error: %v", err)
+					}
+				} else {
+					logError("Failed to start: %v", retryErr)
+					runOnEvents(func() { a.statusBar.SetText(fmt.Sprintf("Launch failed: %v", retryErr)) })
+				}
+			}
 		}
+		played := time.Since(launchedAt)
+		logInfo("launch exit: game=%q status=%s runtime=%s", game.Name, exitStatus(err), played.Round(time.Second))
+		output.Close()
+		// An emulator that gives up straight away explains itself
+		if err != nil && played < quickExitTime {
+			lines := output.lastLines(launchLogTailLines)
+			hints := launchHints(sysID, configuredPath, configuredArgs, args, err, lines)
+			summary := fmt.Sprintf("%s exited straight away (exit status %s).", filepath.Base(emuPath), exitStatus(err))
+			runOnEvents(func() { a.showLaunchFailure(file, summary, command, lines, output.path, hints) })
+		}
+		recordPlaySession(sysID, game.Name, played)
+		// Restore whatever the pre-launch hook changed before the launcher
+		// takes focus back
+		if hookErr := runLaunchHook("Post-launch", hooks.PostLaunch, sysID, game, romPath, emuPath); hookErr != nil {
+			runOnEvents(func() { a.statusBar.SetText(fmt.Sprintf("Warning: %v", hookErr)) })
+		}
+		// Re-enable controller input when game exits
+		if a.gameRunning.Add(-1) == 0 {
+			select {
+			case a.gameExited <- struct{}{}:
+			default:
+			}
+		}
+		logDebug("Game exited - controller input re-enabled in launcher")
+		runOnEvents(func() { a.launchExited(pid) })
+		runOnEvents(a.returnFocus)
+	}()
 
-		// Extract if needed
-		if config.NeedsExtract && strings.HasSuffix(game.Name, ".zip") {
-			progressLabel.SetText("Extracting...")
-			extractZip(outputPath, romDir)
-			os.Remove(outputPath)
-		}
+	a.statusBar.SetText("Launched: " + game.Name)
+}
 
-		progressDialog.Hide()
-		a.romCache[game.Name] = true
-		a.gameList.Refresh()
-		a.statusBar.SetText("Downloaded: " + game.Name)
-	}()
+// returnFocus brings the launcher back to the front after an emulator exits,
+// so a controller can carry on without reaching for the mouse
+func (a *App) returnFocus() {
+	focusOwnWindow("EmuBuddy")
+	a.window.RequestFocus()
+	a.refreshGameView()
+	a.updateStatus()
 }
 
 // WiiUProgressReporter implements the wiiu.ProgressReporter interface
@@ -2293,12 +3059,13 @@ type WiiUProgressReporter struct {
 	progressLabel  *widget.Label
 	downloadLabel  *widget.Label
 	gameTitle      string
-	cancelled      bool
 	downloadSize   int64
 	mu             sync.Mutex
 	fileProgress   map[string]int64
+	doneFiles      map[string]bool // files complete, whose progress is final
 	totalDownloaded int64
 	startTime      time.Time
+	throttle       uiThrottle
 }
 
 func NewWiiUProgressReporter(progressBar *widget.ProgressBar, progressLabel, downloadLabel *widget.Label) *WiiUProgressReporter {
@@ -2307,6 +3074,7 @@ func NewWiiUProgressReporter(progressBar *widget.ProgressBar, progressLabel, dow
 		progressLabel: progressLabel,
 		downloadLabel: downloadLabel,
 		fileProgress:  make(map[string]int64),
+		doneFiles:     make(map[string]bool),
 	}
 }
 
@@ -2318,36 +3086,50 @@ func (r *WiiUProgressReporter) SetGameTitle(title string) {
 
 func (r *WiiUProgressReporter) UpdateDownloadProgress(downloaded int64, filename string) {
 	r.mu.Lock()
+	if r.doneFiles[filename] {
+		r.mu.Unlock()
+		return
+	}
 	r.fileProgress[filename] = downloaded
+	r.mu.Unlock()
+	r.showDownloadProgress(false)
+}
+
+// showDownloadProgress shows the bytes downloaded across the whole title,
+// counting files kept from an earlier attempt, and how many files are done
+func (r *WiiUProgressReporter) showDownloadProgress(force bool) {
+	r.mu.Lock()
 	var total int64
 	for _, v := range r.fileProgress {
 		total += v
 	}
 	r.totalDownloaded = total
+	size := r.downloadSize
+	done := len(r.doneFiles)
 	r.mu.Unlock()
 
-	if r.downloadSize > 0 {
-		pct := float64(total) / float64(r.downloadSize)
-		r.progressBar.SetValue(pct)
-		r.progressLabel.SetText(fmt.Sprintf("%.1f MB / %.1f MB", float64(total)/1024/1024, float64(r.downloadSize)/1024/1024))
+	if size <= 0 || !r.throttle.ready(force || total >= size) {
+		return
+	}
+	text := fmt.Sprintf("%.1f MB / %.1f MB (%d files done)", float64(total)/1024/1024, float64(size)/1024/1024, done)
+	value := float64(total) / float64(size)
+	if value > 1 {
+		value = 1 // .h3 hash files aren't part of the title's size
 	}
+	runOnEvents(func() {
+		r.progressBar.SetValue(value)
+		r.progressLabel.SetText(text)
+	})
 }
 
 func (r *WiiUProgressReporter) UpdateDecryptionProgress(progress float64) {
-	r.progressBar.SetValue(progress)
-	r.progressLabel.SetText(fmt.Sprintf("Decrypting... %.0f%%", progress*100))
-}
-
-func (r *WiiUProgressReporter) Cancelled() bool {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	return r.cancelled
-}
-
-func (r *WiiUProgressReporter) SetCancelled() {
-	r.mu.Lock()
-	r.cancelled = true
-	r.mu.Unlock()
+	if !r.throttle.ready(progress >= 1) {
+		return
+	}
+	runOnEvents(func() {
+		r.progressBar.SetValue(progress)
+		r.progressLabel.SetText(fmt.Sprintf("Decrypting... %.0f%%", progress*100))
+	})
 }
 
 func (r *WiiUProgressReporter) SetDownloadSize(size int64) {
@@ -2359,17 +3141,26 @@ func (r *WiiUProgressReporter) SetDownloadSize(size int64) {
 func (r *WiiUProgressReporter) ResetTotals() {
 	r.mu.Lock()
 	r.fileProgress = make(map[string]int64)
+	r.doneFiles = make(map[string]bool)
 	r.totalDownloaded = 0
 	r.mu.Unlock()
 }
 
+// MarkFileAsDone records that a file is complete, either just downloaded or
+// kept from an earlier attempt, so its bytes stay counted in the title's total
 func (r *WiiUProgressReporter) MarkFileAsDone(filename string) {
-	// No-op for now
+	r.mu.Lock()
+	r.doneFiles[filename] = true
+	r.mu.Unlock()
+	r.showDownloadProgress(true)
 }
 
+// SetTotalDownloadedForFile sets a file's byte count. A file being
+// downloaded again starts from 0 and is no longer done.
 func (r *WiiUProgressReporter) SetTotalDownloadedForFile(filename string, downloaded int64) {
 	r.mu.Lock()
 	r.fileProgress[filename] = downloaded
+	delete(r.doneFiles, filename)
 	r.mu.Unlock()
 }
 
@@ -2379,334 +3170,59 @@ func (r *WiiUProgressReporter) SetStartTime(startTime time.Time) {
 	r.mu.Unlock()
 }
 
-func (a *App) downloadWiiUGame(game ROM) {
-	config := systems[a.currentSystem]
-	
-	// Use a sanitized directory name based on the game name
-	sanitizedName := strings.Map(func(r rune) rune {
-		if r == '/' || r == '\\' || r == ':' || r == '*' || r == '?' || r == '"' || r == '<' || r == '>' || r == '|' {
-			return '_'
-		}
-		return r
-	}, game.Name)
-	
-	romDir := filepath.Join(romsDir, config.Dir, sanitizedName)
-	os.MkdirAll(romDir, 0755)
-
-	progressBar := widget.NewProgressBar()
-	progressLabel := widget.NewLabel("Starting download...")
-	downloadLabel := widget.NewLabel(game.Name)
-
-	progressContent := container.NewVBox(
-		downloadLabel,
-		progressBar,
-		progressLabel,
-	)
-
-	progressDialog := dialog.NewCustom("Downloading Wii U Title", "Cancel", progressContent, a.window)
-	reporter := NewWiiUProgressReporter(progressBar, progressLabel, downloadLabel)
-
-	progressDialog.SetOnClosed(func() {
-		reporter.SetCancelled()
-	})
-	progressDialog.Show()
-
-	go func() {
-		client := &http.Client{Timeout: 0} // No timeout for large downloads
+// fetchWiiUGame downloads and decrypts a Wii U title from Nintendo's CDN
+func (a *App) fetchWiiUGame(ctx context.Context, item *queueItem) error {
+	game := item.Game
+	config := systems[item.System]
 
-		// Download and decrypt
-		progressLabel.SetText("Downloading from Nintendo CDN...")
-		err := wiiu.DownloadTitle(game.TitleID, romDir, true, reporter, true, client)
+	romDir := filepath.Join(romsDir, config.Dir, sanitizeFileName(game.Name))
+	os.MkdirAll(romDir, 0755)
 
-		if reporter.Cancelled() {
-			os.RemoveAll(romDir)
-			return
-		}
+	reporter := NewWiiUProgressReporter(item.bar, item.label, item.title)
+	client := newHTTPClient(0) // No timeout for large downloads
 
-		if err != nil {
-			progressDialog.Hide()
-			dialog.ShowError(err, a.window)
-			os.RemoveAll(romDir)
-			return
-		}
+	// Download and decrypt. Content files already in romDir from a cancelled
+	// or failed attempt are kept and skipped, so they're left there on failure.
+	runOnEvents(func() { item.label.SetText("Downloading from Nintendo CDN...") })
+	err := wiiu.DownloadTitle(ctx, game.TitleID, romDir, true, reporter, true, client)
 
-		progressDialog.Hide()
-		a.romCache[game.Name] = true
-		a.gameList.Refresh()
-		a.statusBar.SetText("Downloaded: " + game.Name)
-	}()
+	if ctx.Err() != nil {
+		return errDownloadCancelled
+	}
+	return err
 }
 
-// Parallel download configuration
+// Parallel download configuration (workers and chunk size can be changed in settings.json)
 const (
-	numDownloadWorkers = 4               // Number of parallel connections (reduced to avoid rate limiting)
-	minChunkSize       = 4 * 1024 * 1024 // 4MB minimum chunk size
-	maxChunkRetries    = 3               // Retries per chunk on failure
+	defaultDownloadWorkers = 4                            // Number of parallel connections (reduced to avoid rate limiting)
+	defaultMinChunkSize    = download.DefaultMinChunkSize // 4MB minimum chunk size
+	downloadRetries        = 2                            // Retries per chunk (or stream) on failure
 )
 
-func downloadWithProgress(url, outputPath string, progress func(downloaded, total int64)) error {
-	// First, get file size and check for Range support
-	transport := &http.Transport{
-		MaxIdleConns:        100,
-		MaxIdleConnsPerHost: 100,
-		IdleConnTimeout:     90 * time.Second,
-		WriteBufferSize:     1024 * 1024,
-		ReadBufferSize:      1024 * 1024,
-		DisableCompression:  true,
-		ForceAttemptHTTP2:   true,
-	}
-	client := &http.Client{Transport: transport}
-
-	// HEAD request to get file info
-	headReq, err := http.NewRequest("HEAD", url, nil)
-	if err != nil {
-		return err
-	}
-	headReq.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
-	
-	headResp, err := client.Do(headReq)
-	if err != nil {
-		return err
-	}
-	headResp.Body.Close()
-
-	totalSize := headResp.ContentLength
-	supportsRange := headResp.Header.Get("Accept-Ranges") == "bytes"
-
-	// Use parallel download for large files that support Range requests
-	if supportsRange && totalSize > minChunkSize*2 {
-		return downloadParallel(client, url, outputPath, totalSize, progress)
-	}
-
-	// Fall back to single-threaded download
-	return downloadSingle(client, url, outputPath, progress)
-}
-
-func downloadParallel(client *http.Client, url, outputPath string, totalSize int64, progress func(downloaded, total int64)) error {
-	// Calculate chunk size
-	chunkSize := totalSize / int64(numDownloadWorkers)
-	if chunkSize < minChunkSize {
-		chunkSize = minChunkSize
-	}
-
-	// Create output file
-	out, err := os.Create(outputPath)
-	if err != nil {
-		return err
-	}
-	defer out.Close()
-
-	// Pre-allocate file
-	if err := out.Truncate(totalSize); err != nil {
-		return err
-	}
-
-	// Progress tracking - track per-chunk progress to handle retries correctly
-	chunkProgress := make(map[int64]int64) // start position -> bytes downloaded
-	var progressMu sync.Mutex
-	
-	updateProgress := func(chunkStart int64, totalForChunk int64) {
-		progressMu.Lock()
-		chunkProgress[chunkStart] = totalForChunk
-		var total int64
-		for _, v := range chunkProgress {
-			total += v
-		}
-		progressMu.Unlock()
-		progress(total, totalSize)
-	}
-
-	// Create worker pool
-	type chunk struct {
-		start, end int64
-	}
-	chunks := make(chan chunk, numDownloadWorkers*2)
-	errChan := make(chan error, numDownloadWorkers)
-	var wg sync.WaitGroup
-
-	// Start workers
-	for i := 0; i < numDownloadWorkers; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for c := range chunks {
-				if err := downloadChunk(client, url, out, c.start, c.end, updateProgress); err != nil {
-					errChan <- err
-					return
-				}
-			}
-		}()
-	}
-
-	// Queue chunks
-	for start := int64(0); start < totalSize; start += chunkSize {
-		end := start + chunkSize - 1
-		if end >= totalSize {
-			end = totalSize - 1
-		}
-		chunks <- chunk{start, end}
-	}
-	close(chunks)
-
-	// Wait for completion
-	wg.Wait()
-	close(errChan)
-
-	// Check for errors
-	for err := range errChan {
-		if err != nil {
-			os.Remove(outputPath)
-			return err
-		}
-	}
-
-	return nil
-}
-
-func downloadChunk(client *http.Client, url string, out *os.File, start, end int64, updateProgress func(chunkStart int64, totalForChunk int64)) error {
-	var lastErr error
-	
-	for attempt := 0; attempt < maxChunkRetries; attempt++ {
-		if attempt > 0 {
-			time.Sleep(time.Duration(attempt) * time.Second) // Backoff: 1s, 2s
-			// Reset progress for this chunk on retry
-			updateProgress(start, 0)
-		}
-		
-		err := downloadChunkAttempt(client, url, out, start, end, updateProgress)
-		if err == nil {
-			return nil
-		}
-		lastErr = err
-	}
-	return fmt.Errorf("chunk %d-%d failed after %d retries: %w", start, end, maxChunkRetries, lastErr)
-}
-
-func downloadChunkAttempt(client *http.Client, url string, out *os.File, start, end int64, updateProgress func(chunkStart int64, totalForChunk int64)) error {
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return err
-	}
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
-	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusPartialContent && resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("HTTP %d for range %d-%d", resp.StatusCode, start, end)
-	}
-
-	buf := make([]byte, 256*1024) // 256KB read buffer
-	pos := start
-	var chunkDownloaded int64
-	for {
-		n, err := resp.Body.Read(buf)
-		if n > 0 {
-			_, writeErr := out.WriteAt(buf[:n], pos)
-			if writeErr != nil {
-				return writeErr
-			}
-			pos += int64(n)
-			chunkDownloaded += int64(n)
-			updateProgress(start, chunkDownloaded)
-		}
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
-func downloadSingle(client *http.Client, url, outputPath string, progress func(downloaded, total int64)) error {
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return err
-	}
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
-	req.Header.Set("Accept", "*/*")
-	req.Header.Set("Connection", "keep-alive")
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("HTTP %d", resp.StatusCode)
-	}
-
-	out, err := os.Create(outputPath)
-	if err != nil {
-		return err
-	}
-	defer out.Close()
-
-	bufferedOut := bufio.NewWriterSize(out, 1024*1024)
-	defer bufferedOut.Flush()
-
-	total := resp.ContentLength
-	var downloaded int64
-
-	buf := make([]byte, 1024*1024)
-	for {
-		n, err := resp.Body.Read(buf)
-		if n > 0 {
-			bufferedOut.Write(buf[:n])
-			downloaded += int64(n)
-			progress(downloaded, total)
-		}
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
-func extractZip(zipPath, destDir string) (string, error) {
-	r, err := zip.OpenReader(zipPath)
-	if err != nil {
-		return "", err
-	}
-	defer r.Close()
-
-	var extractedFile string
-	for _, f := range r.File {
-		if f.FileInfo().IsDir() {
-			continue
-		}
-
-		destPath := filepath.Join(destDir, f.Name)
-		os.MkdirAll(filepath.Dir(destPath), 0755)
-
-		outFile, err := os.Create(destPath)
-		if err != nil {
-			continue
-		}
-
-		rc, err := f.Open()
-		if err != nil {
-			outFile.Close()
-			continue
-		}
-
-		io.Copy(outFile, rc)
-		outFile.Close()
-		rc.Close()
-
-		extractedFile = destPath
-	}
-	return extractedFile, nil
+// downloadLimiter enforces settings.MaxDownloadBytesPerSec across all downloads
+var downloadLimiter = &download.Limiter{BytesPerSec: func() int64 { return settings.MaxDownloadBytesPerSec }}
+
+// downloadWithProgress downloads url to outputPath. Data is written to
+// outputPath+".part" and only renamed into place once complete, so a
+// cancelled or interrupted download can be resumed by calling this again.
+func downloadWithProgress(ctx context.Context, url, outputPath string, progress func(downloaded, total int64)) error {
+	telemetry := newDownloadTelemetry(url)
+	workers := downloadWorkersFor(url)
+	logInfo("download start: host=%s workers=%d", urlHost(url), workers)
+	err := download.Download(ctx, url, outputPath, download.Options{
+		UserAgent:    userAgentFor(url),
+		Retries:      downloadRetries,
+		Workers:      workers,
+		MinChunkSize: minChunkSize(),
+		Limiter:      downloadLimiter,
+		Resume:       true,
+		Progress:     telemetry.wrap(progress),
+		Logf:         logInfo,
+		Header:       hostHeadersFor(url),
+		Proxy:        settings.Proxy,
+	})
+	telemetry.finish(err)
+	return err
 }
 
 // Ensure Windows doesn't need console
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/emubuddy/download"
+)
+
+// MirrorRule rewrites a download URL for a mirror: a URL starting with From
+// is also tried with that prefix replaced by To, e.g.
+// {"from": "https://myrient.erista.me/files/", "to": "https://mirror.example/files/"}
+type MirrorRule struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// preferredMirrors remembers, per host of a set's primary URL, the host that
+// last completed a download, so later downloads this session try it first
+var preferredMirrors = struct {
+	sync.Mutex
+	hosts map[string]string
+}{hosts: make(map[string]string)}
+
+// downloadURLs returns every URL a game can be fetched from: its own URL, the
+// set entry's mirrors, then the system's rewrite rules applied to its URL
+func downloadURLs(game ROM, config SystemConfig) []string {
+	var urls []string
+	seen := make(map[string]bool)
+	add := func(u string) {
+		if u != "" && !seen[u] {
+			seen[u] = true
+			urls = append(urls, u)
+		}
+	}
+
+	add(game.URL)
+	for _, mirror := range game.Mirrors {
+		add(mirror)
+	}
+	for _, rule := range config.Mirrors {
+		if rule.From != "" && strings.HasPrefix(game.URL, rule.From) {
+			add(rule.To + strings.TrimPrefix(game.URL, rule.From))
+		}
+	}
+	return urls
+}
+
+// preferMirror moves the first URL on the host that last worked for primaryHost
+// to the front
+func preferMirror(urls []string, primaryHost string) []string {
+	preferredMirrors.Lock()
+	preferred := preferredMirrors.hosts[primaryHost]
+	preferredMirrors.Unlock()
+	if preferred == "" {
+		return urls
+	}
+	for i, u := range urls {
+		if urlHost(u) == preferred {
+			ordered := append([]string{u}, urls[:i]...)
+			return append(ordered, urls[i+1:]...)
+		}
+	}
+	return urls
+}
+
+// shouldTryMirror reports whether a download error means the server is down
+// or refusing us (connection failure, 429 or 5xx), so another mirror may work
+func shouldTryMirror(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var limited *download.RateLimitedError
+	if errors.As(err, &limited) {
+		return true
+	}
+	var status *download.StatusError
+	if errors.As(err, &status) {
+		return status.Status == http.StatusTooManyRequests || status.Status >= 500
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// downloadFromMirrors downloads from the first of urls (the set's URL, then
+// mirrors) that works, moving on when shouldTryMirror says the current server
+// is failing. onMirror is called whenever a URL other than the set's is used.
+func downloadFromMirrors(ctx context.Context, urls []string, outputPath string, progress func(downloaded, total int64), onMirror func(url string)) error {
+	if len(urls) == 0 {
+		return errors.New("no download URL")
+	}
+	primaryHost := urlHost(urls[0])
+
+	var err error
+	for i, u := range preferMirror(urls, primaryHost) {
+		if i > 0 {
+			logWarn("Trying %s after: %v", urlHost(u), err)
+		}
+		if u != urls[0] && onMirror != nil {
+			onMirror(u)
+		}
+		err = downloadWithProgress(ctx, u, outputPath, progress)
+		if err == nil {
+			preferredMirrors.Lock()
+			preferredMirrors.hosts[primaryHost] = urlHost(u)
+			preferredMirrors.Unlock()
+			return nil
+		}
+		if ctx.Err() != nil || !shouldTryMirror(err) {
+			return err
+		}
+	}
+	return err
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Multi-disc games are separate files in the sets ("Game (USA) (Disc 1).zip",
+// "Game (USA) (Disc 2).zip"), or entries sharing a "discSet" name. The game
+// list shows each set as one entry - its first disc, with the others in Discs.
+// Downloading it queues every disc; launching it on RetroArch uses an .m3u
+// playlist of the discs so the core can swap them, and other emulators are
+// given the disc picked in a disc picker.
+
+// discTag matches the "(Disc N)" tag and any tags after it, which can differ
+// between discs: "Armored Core - Nexus (USA) (Disc 2) (Revolution)"
+var discTag = regexp.MustCompile(`\s*\(Disc (\d+)\).*$`)
+
+// discSetName returns the name of the multi-disc set a game belongs to, or ""
+// if it's a single disc
+func discSetName(game ROM) string {
+	if game.DiscSet != "" {
+		return game.DiscSet
+	}
+	name := trimArchiveExt(game.Name)
+	if loc := discTag.FindStringIndex(name); loc != nil {
+		return name[:loc[0]]
+	}
+	return ""
+}
+
+// discNumber returns the disc number from a game's "(Disc N)" tag, or 0
+func discNumber(game ROM) int {
+	m := discTag.FindStringSubmatch(trimArchiveExt(game.Name))
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(m[1])
+	return n
+}
+
+// gameDiscs returns the files a game is made of: its discs for a multi-disc
+// set, otherwise just the game
+func gameDiscs(game ROM) []ROM {
+	if len(game.Discs) > 0 {
+		return game.Discs
+	}
+	return []ROM{game}
+}
+
+// groupDiscs folds each multi-disc set into one entry, listed where its
+// first disc was. The entry is the lowest-numbered disc, with every disc in
+// Discs and the size of them all. A set with only one disc in the JSON is
+// left alone.
+func groupDiscs(games []ROM) []ROM {
+	members := make(map[string][]int)
+	for i, game := range games {
+		if name := discSetName(game); name != "" {
+			members[name] = append(members[name], i)
+		}
+	}
+
+	grouped := make([]ROM, 0, len(games))
+	for i, game := range games {
+		indices := members[discSetName(game)]
+		if len(indices) < 2 {
+			grouped = append(grouped, game)
+			continue
+		}
+		if indices[0] != i {
+			continue
+		}
+		discs := make([]ROM, len(indices))
+		for j, k := range indices {
+			discs[j] = games[k]
+		}
+		sort.SliceStable(discs, func(a, b int) bool { return discNumber(discs[a]) < discNumber(discs[b]) })
+
+		entry := discs[0]
+		entry.Discs = discs
+		var total int64
+		for _, disc := range discs {
+			size := parseROMSize(disc.Size)
+			if size < 0 {
+				total = -1
+				break
+			}
+			total += size
+		}
+		if total >= 0 {
+			entry.Size = formatBytes(total)
+		}
+		grouped = append(grouped, entry)
+	}
+	return grouped
+}
+
+// gameDisplayName is how a game is named in the list: without its archive
+// extension, and a multi-disc set by its set name and disc count
+func gameDisplayName(game ROM) string {
+	if len(game.Discs) > 0 {
+		return fmt.Sprintf("%s (%d Discs)", discSetName(game), len(game.Discs))
+	}
+	return trimArchiveExt(game.Name)
+}
+
+// usesDiscPlaylist reports whether a launch loads a RetroArch core, which
+// can swap discs from an .m3u playlist
+func usesDiscPlaylist(emuArgs []string) bool {
+	for _, arg := range emuArgs {
+		if arg == "-L" {
+			return true
+		}
+	}
+	return false
+}
+
+// discPlaylistPath is where a set's .m3u playlist is written
+func discPlaylistPath(sysID string, game ROM) string {
+	return filepath.Join(romsDir, systems[sysID].Dir, sanitizeFileName(discSetName(game))+".m3u")
+}
+
+// writeDiscPlaylist writes the .m3u playlist for a downloaded multi-disc set,
+// listing each disc's ROM file in order, and returns its path. Every disc
+// must be on disk.
+func writeDiscPlaylist(sysID string, discs []ROM) (string, error) {
+	config := systems[sysID]
+	romDir := filepath.Join(romsDir, config.Dir)
+
+	var lines []string
+	for _, disc := range discs {
+		path := findROMFile(config, romDir, disc)
+		if path == "" {
+			return "", fmt.Errorf("disc not downloaded: %s", disc.Name)
+		}
+		// Relative to the playlist, which sits in the rom folder, so discs
+		// sorted into a subfolder are found too
+		rel, err := filepath.Rel(romDir, path)
+		if err != nil {
+			rel = filepath.Base(path)
+		}
+		lines = append(lines, rel)
+	}
+
+	playlist := discPlaylistPath(sysID, discs[0])
+	if err := os.WriteFile(playlist, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		return "", err
+	}
+	return playlist, nil
+}
+
+// updateDiscPlaylist writes the playlist of the set a downloaded disc is
+// part of, once all of its discs are on disk, for systems launched with
+// RetroArch cores. It reads the system's set, so it's run off the UI goroutine.
+func updateDiscPlaylist(sysID string, disc ROM) {
+	name := discSetName(disc)
+	if name == "" || len(systems[sysID].Emulator.Cores) == 0 {
+		return
+	}
+	set, err := loadSet(sysID)
+	if err != nil {
+		return
+	}
+	for _, game := range groupDiscs(set) {
+		if len(game.Discs) == 0 || discSetName(game) != name {
+			continue
+		}
+		if playlist, err := writeDiscPlaylist(sysID, game.Discs); err == nil {
+			logInfo("Wrote disc playlist %s", playlist)
+		}
+		return
+	}
+}
+
+// chooseDisc asks which disc of a multi-disc set to launch, for emulators
+// that can't read a playlist, and calls launch with its index in Discs
+func (a *App) chooseDisc(game ROM, launch func(disc int)) {
+	options := make([]string, len(game.Discs))
+	for i, disc := range game.Discs {
+		options[i] = trimArchiveExt(disc.Name)
+	}
+	picker := widget.NewSelect(options, nil)
+	picker.SetSelected(options[0])
+
+	a.dialogOpen = true
+	d := dialog.NewCustomConfirm("Choose Disc", "Launch", "Cancel", picker, func(ok bool) {
+		a.dialogOpen = false
+		if !ok {
+			return
+		}
+		for i, option := range options {
+			if option == picker.Selected {
+				launch(i)
+				return
+			}
+		}
+	}, a.window)
+	d.Show()
+}
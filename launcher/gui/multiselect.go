@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"fyne.io/fyne/v2/dialog"
+)
+
+// Multi-select: Space ticks games in the browser and Ctrl+A ticks every game
+// shown. While any are ticked, D, F and Delete act on all of them instead of
+// the selected game. Ticks are kept in a.checkedGames, keyed by romCacheKey,
+// and cleared by Escape or by switching system. The game list reads them
+// while drawing, so they're only touched under checkedMu.
+
+// isChecked reports whether a game is ticked for a bulk action
+func (a *App) isChecked(game ROM) bool {
+	a.checkedMu.Lock()
+	defer a.checkedMu.Unlock()
+	_, ok := a.checkedGames[romCacheKey(game)]
+	return ok
+}
+
+// checkedCount returns how many games are ticked
+func (a *App) checkedCount() int {
+	a.checkedMu.Lock()
+	defer a.checkedMu.Unlock()
+	return len(a.checkedGames)
+}
+
+// checkedList returns the ticked games, sorted by system and name so bulk
+// actions run in a predictable order
+func (a *App) checkedList() []ROM {
+	a.checkedMu.Lock()
+	games := make([]ROM, 0, len(a.checkedGames))
+	for _, game := range a.checkedGames {
+		games = append(games, game)
+	}
+	a.checkedMu.Unlock()
+	sort.Slice(games, func(i, j int) bool {
+		if games[i].System != games[j].System {
+			return games[i].System < games[j].System
+		}
+		return games[i].Name < games[j].Name
+	})
+	return games
+}
+
+// toggleCheckedGame ticks or unticks the selected game
+func (a *App) toggleCheckedGame() {
+	game, ok := a.selectedGame()
+	if !ok {
+		return
+	}
+	a.setChecked(game, !a.isChecked(game))
+	a.showCheckedCount()
+	a.refreshGameView()
+}
+
+// setChecked ticks or unticks a game
+func (a *App) setChecked(game ROM, on bool) {
+	a.checkedMu.Lock()
+	defer a.checkedMu.Unlock()
+	if !on {
+		delete(a.checkedGames, romCacheKey(game))
+		return
+	}
+	if a.checkedGames == nil {
+		a.checkedGames = make(map[string]ROM)
+	}
+	a.checkedGames[romCacheKey(game)] = game
+}
+
+// checkAllShown ticks every game the search and filters leave in the browser
+func (a *App) checkAllShown() {
+	games := a.shownGames()
+	if len(games) == 0 {
+		return
+	}
+	a.checkedMu.Lock()
+	if a.checkedGames == nil {
+		a.checkedGames = make(map[string]ROM)
+	}
+	for _, game := range games {
+		a.checkedGames[romCacheKey(game)] = game
+	}
+	a.checkedMu.Unlock()
+	a.showCheckedCount()
+	a.refreshGameView()
+}
+
+// clearChecked unticks every game. It reports whether any were ticked.
+func (a *App) clearChecked() bool {
+	a.checkedMu.Lock()
+	ticked := len(a.checkedGames)
+	a.checkedGames = nil
+	a.checkedMu.Unlock()
+	if ticked == 0 {
+		return false
+	}
+	a.refreshGameView()
+	return true
+}
+
+// showCheckedCount shows in the status bar how many games are ticked and the
+// total size of those still to download
+func (a *App) showCheckedCount() {
+	checked := a.checkedList()
+	if len(checked) == 0 {
+		a.updateStatus()
+		return
+	}
+	var toDownload []ROM
+	for _, game := range checked {
+		if !a.isDownloaded(game) {
+			toDownload = append(toDownload, game)
+		}
+	}
+	total, unknown := sizeTotal(toDownload)
+	a.statusBar.SetText(fmt.Sprintf("Selected: %d games, %s to download%s - D=Download F=Favorite Del=Delete Esc=Clear",
+		len(checked), formatSizeTotal(total, unknown), freeSpaceNote(total)))
+}
+
+// toggleCheckedFavorites adds the ticked games to favorites, or removes them
+// if they all already are
+func (a *App) toggleCheckedFavorites() {
+	games := a.checkedList()
+	allFavorites := true
+	for _, game := range games {
+		if !a.isFavorite(game) {
+			allFavorites = false
+			break
+		}
+	}
+
+	for _, game := range games {
+		setFavorite(game, !allFavorites)
+	}
+	saveFavorites()
+	if allFavorites {
+		a.statusBar.SetText(fmt.Sprintf("Removed %d game(s) from favorites", len(games)))
+	} else {
+		a.statusBar.SetText(fmt.Sprintf("Added %d game(s) to favorites", len(games)))
+	}
+	a.refreshGameView()
+}
+
+// downloadChecked queues every ticked game that isn't downloaded yet, after a
+// single disk space check for all of them
+func (a *App) downloadChecked() {
+	var games []ROM
+	for _, game := range a.checkedList() {
+		if !a.isDownloaded(game) {
+			games = append(games, game)
+		}
+	}
+	if len(games) == 0 {
+		a.statusBar.SetText("Selected games are already downloaded")
+		return
+	}
+
+	a.confirmDiskSpaceAll(games, func() {
+		for _, game := range games {
+			for _, disc := range gameDiscs(game) {
+				if len(game.Discs) > 0 && a.isDownloaded(disc) {
+					continue
+				}
+				a.enqueueDownload(game.System, disc, nil)
+			}
+		}
+		a.statusBar.SetText(fmt.Sprintf("Queued %d game(s)", len(games)))
+	})
+}
+
+// deleteChecked removes the ticked games' downloaded files after one
+// confirmation listing them all
+func (a *App) deleteChecked() {
+	var games []ROM
+	var gamePaths [][]string
+	var paths []string
+	for _, game := range a.checkedList() {
+		local := localGamePaths(game.System, game)
+		if len(local) == 0 {
+			continue
+		}
+		games = append(games, game)
+		gamePaths = append(gamePaths, local)
+		paths = append(paths, local...)
+	}
+	if len(paths) == 0 {
+		a.statusBar.SetText("None of the selected games are downloaded")
+		return
+	}
+
+	a.confirmDestructive(fmt.Sprintf("Delete %d Games", len(games)), "Delete", paths, func() {
+		// Games deleted before a failure are still marked as not downloaded
+		deleted := 0
+		var deleteErr error
+	remove:
+		for i, game := range games {
+			for _, p := range gamePaths[i] {
+				if err := os.RemoveAll(p); err != nil {
+					logError("Delete failed for %s: %v", p, err)
+					deleteErr = err
+					break remove
+				}
+				logInfo("Deleted %s", p)
+			}
+			if a.setDownloaded(game, false) {
+				deleted++
+			}
+		}
+		if deleted > 0 {
+			a.refilterAfterDownloadChange()
+			a.updateLaunchButton()
+		}
+		a.refreshLibraryUsage()
+		if deleteErr != nil {
+			dialog.ShowError(deleteErr, a.window)
+			return
+		}
+		a.statusBar.SetText(fmt.Sprintf("Deleted %d game(s)", len(games)))
+	})
+}
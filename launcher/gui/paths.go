@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// emulatorsDir is where emulators are installed: baseDir/Emulators unless
+// settings.json overrides it
+var emulatorsDir string
+
+// dataDirWarnings explains any romsDir/emulatorsDir override that couldn't
+// be used; they're shown in the status bar at startup
+var dataDirWarnings []string
+
+var windowsEnvVar = regexp.MustCompile(`%([A-Za-z0-9_]+)%`)
+
+// expandPath expands ~, $VAR/${VAR} and %VAR% in a path from settings.json.
+// Relative paths are taken from baseDir.
+func expandPath(path string) string {
+	path = windowsEnvVar.ReplaceAllStringFunc(path, func(m string) string {
+		if value, ok := os.LookupEnv(strings.Trim(m, "%")); ok {
+			return value
+		}
+		return m
+	})
+	path = os.ExpandEnv(path)
+	if path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, `~\`) {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[1:])
+		}
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(baseDir, path)
+	}
+	return filepath.Clean(path)
+}
+
+// resolveDataDirs applies the romsDir and emulatorsDir overrides from settings.
+// A missing ROM folder is created; if that fails, or the emulators folder
+// doesn't exist, the default is kept and a warning recorded.
+func resolveDataDirs() {
+	romsDir = filepath.Join(baseDir, "roms")
+	emulatorsDir = filepath.Join(baseDir, "Emulators")
+	dataDirWarnings = nil
+
+	if settings.RomsDir != "" {
+		dir := expandPath(settings.RomsDir)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			dataDirWarnings = append(dataDirWarnings, fmt.Sprintf("romsDir %s can't be used (%v), using %s", dir, err, romsDir))
+		} else {
+			romsDir = dir
+		}
+	}
+
+	if settings.EmulatorsDir != "" {
+		dir := expandPath(settings.EmulatorsDir)
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			dataDirWarnings = append(dataDirWarnings, fmt.Sprintf("emulatorsDir %s doesn't exist, using %s", dir, emulatorsDir))
+		} else {
+			emulatorsDir = dir
+		}
+	}
+
+	for _, warning := range dataDirWarnings {
+		fmt.Println("Warning: " + warning)
+		logWarn("%s", warning)
+	}
+}
+
+// emulatorPath turns an emulator path from systems.json, relative to baseDir
+// (e.g. "Emulators/PCSX2/pcsx2-qt.exe"), into a full path. Paths under
+// Emulators/ follow the emulatorsDir setting.
+func emulatorPath(rel string) string {
+	if filepath.IsAbs(rel) {
+		return rel
+	}
+	slash := filepath.ToSlash(rel)
+	if strings.HasPrefix(slash, "Emulators/") {
+		return filepath.Join(emulatorsDir, filepath.FromSlash(strings.TrimPrefix(slash, "Emulators/")))
+	}
+	return filepath.Join(baseDir, rel)
+}
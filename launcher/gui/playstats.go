@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// PlayStat is the play history of one game, persisted to playstats.json
+type PlayStat struct {
+	TotalSeconds int64     `json:"totalSeconds"`
+	LastPlayed   time.Time `json:"lastPlayed"`
+	Launches     int       `json:"launches"`
+}
+
+var (
+	// playStats maps system ID -> game name -> stats, like favorites
+	playStats     map[string]map[string]*PlayStat
+	playStatsPath string
+	playStatsMu   sync.Mutex // sessions end on the process-wait goroutine
+)
+
+func loadPlayStats() {
+	playStats = make(map[string]map[string]*PlayStat)
+	data, err := os.ReadFile(playStatsPath)
+	if err != nil {
+		return
+	}
+	json.Unmarshal(data, &playStats)
+}
+
+// savePlayStats must be called with playStatsMu held
+func savePlayStats() {
+	data, _ := json.MarshalIndent(playStats, "", "  ")
+	os.WriteFile(playStatsPath, data, 0644)
+}
+
+func playStatFor(sysID, gameName string) (PlayStat, bool) {
+	playStatsMu.Lock()
+	defer playStatsMu.Unlock()
+	stat, ok := playStats[sysID][gameName]
+	if !ok {
+		return PlayStat{}, false
+	}
+	return *stat, true
+}
+
+// recordLaunch notes that a game was started
+func recordLaunch(sysID, gameName string, at time.Time) {
+	playStatsMu.Lock()
+	defer playStatsMu.Unlock()
+	if playStats[sysID] == nil {
+		playStats[sysID] = make(map[string]*PlayStat)
+	}
+	stat := playStats[sysID][gameName]
+	if stat == nil {
+		stat = &PlayStat{}
+		playStats[sysID][gameName] = stat
+	}
+	stat.LastPlayed = at
+	stat.Launches++
+	savePlayStats()
+}
+
+// recordPlaySession adds the length of a finished session to a game's total
+func recordPlaySession(sysID, gameName string, played time.Duration) {
+	playStatsMu.Lock()
+	defer playStatsMu.Unlock()
+	stat := playStats[sysID][gameName]
+	if stat == nil {
+		return
+	}
+	stat.TotalSeconds += int64(played.Seconds())
+	savePlayStats()
+}
+
+// formatPlayTime renders a total like "3h 05m" or "12m"
+func formatPlayTime(seconds int64) string {
+	if seconds < 60 {
+		return "<1m"
+	}
+	hours := seconds / 3600
+	minutes := (seconds % 3600) / 60
+	if hours == 0 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh %02dm", hours, minutes)
+}
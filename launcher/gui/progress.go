@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// speedTimeConstant is how quickly the shown speed follows changes: each
+	// sample's weight halves roughly every 2 seconds
+	speedTimeConstant = 3 * time.Second
+	// speedSampleInterval is the shortest gap between speed samples
+	speedSampleInterval = 500 * time.Millisecond
+	// stallTimeout is how long a download may receive nothing before it's
+	// restarted (resuming from its .part file), at most maxStallRetries times
+	stallTimeout    = 30 * time.Second
+	maxStallRetries = 3
+)
+
+// transferMeter turns a download's running byte count into a smoothed speed
+// (an exponential moving average) and notices when no data arrives
+type transferMeter struct {
+	mu          sync.Mutex
+	started     bool
+	sampleBytes int64
+	sampleTime  time.Time
+	rate        float64   // bytes/sec
+	lastData    time.Time // when the count last went up
+}
+
+func newTransferMeter() *transferMeter {
+	return &transferMeter{lastData: time.Now()}
+}
+
+// update records the total bytes downloaded so far. The first call only sets
+// the baseline, so bytes resumed from a .part file don't count as speed.
+func (m *transferMeter) update(downloaded int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	if !m.started {
+		m.started = true
+		m.sampleBytes, m.sampleTime, m.lastData = downloaded, now, now
+		return
+	}
+	if downloaded > m.sampleBytes {
+		m.lastData = now
+	}
+	elapsed := now.Sub(m.sampleTime)
+	if elapsed < speedSampleInterval {
+		return
+	}
+	current := float64(downloaded-m.sampleBytes) / elapsed.Seconds()
+	weight := 1 - math.Exp(-elapsed.Seconds()/speedTimeConstant.Seconds())
+	if m.rate == 0 {
+		weight = 1
+	}
+	m.rate += weight * (current - m.rate)
+	m.sampleBytes, m.sampleTime = downloaded, now
+}
+
+// speed returns the smoothed download speed in bytes/sec
+func (m *transferMeter) speed() float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rate
+}
+
+// idleFor returns how long it's been since any data arrived
+func (m *transferMeter) idleFor() time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return time.Since(m.lastData)
+}
+
+// formatETA renders a time remaining like "4m12s", "1h05m" or "38s"
+func formatETA(d time.Duration) string {
+	d = d.Round(time.Second)
+	switch {
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	default:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+}
+
+// progressText describes a download's progress, with speed and time remaining
+// once the meter has a speed
+func progressText(downloaded, total int64, bytesPerSec float64) string {
+	text := fmt.Sprintf("%.1f MB / %.1f MB", float64(downloaded)/1024/1024, float64(total)/1024/1024)
+	if bytesPerSec <= 0 {
+		return text
+	}
+	text += fmt.Sprintf(" - %.1f MB/s", bytesPerSec/1024/1024)
+	if remaining := total - downloaded; remaining > 0 {
+		eta := time.Duration(float64(remaining) / bytesPerSec * float64(time.Second))
+		text += " - ETA " + formatETA(eta)
+	}
+	return text
+}
+
+// downloadWatched downloads a queued game from urls, showing progress, speed
+// and ETA on its row. A download that gets no data for stallTimeout is
+// stopped and resumed, up to maxStallRetries times.
+func (a *App) downloadWatched(ctx context.Context, item *queueItem, urls []string, outputPath string) error {
+	game := item.Game
+	throttle := &uiThrottle{}
+	for retry := 0; ; retry++ {
+		attemptCtx, cancelAttempt := context.WithCancel(ctx)
+		meter := newTransferMeter()
+		var stalled atomic.Bool
+		watchDone := make(chan struct{})
+		go func() {
+			ticker := time.NewTicker(time.Second)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-watchDone:
+					return
+				case <-ticker.C:
+					if meter.idleFor() >= stallTimeout {
+						stalled.Store(true)
+						cancelAttempt()
+						return
+					}
+				}
+			}
+		}()
+
+		err := downloadFromMirrors(attemptCtx, urls, outputPath, func(downloaded, total int64) {
+			meter.update(downloaded)
+			if attemptCtx.Err() != nil || total <= 0 || !throttle.ready(downloaded >= total) {
+				return
+			}
+			text := progressText(downloaded, total, meter.speed())
+			runOnEvents(func() {
+				item.bar.SetValue(float64(downloaded) / float64(total))
+				item.label.SetText(text)
+			})
+		}, func(url string) {
+			runOnEvents(func() {
+				a.statusBar.SetText(fmt.Sprintf("Downloading %s from mirror %s", trimArchiveExt(game.Name), urlHost(url)))
+			})
+		})
+		close(watchDone)
+		cancelAttempt()
+
+		if ctx.Err() != nil || !stalled.Load() {
+			return err
+		}
+		if retry >= maxStallRetries {
+			return fmt.Errorf("download stalled: no data for %s, %d times", stallTimeout, retry+1)
+		}
+		logWarn("Download stalled, no data for %s: %s (retry %d/%d)", stallTimeout, game.Name, retry+1, maxStallRetries)
+		label := fmt.Sprintf("Stalled - retrying (%d/%d)", retry+1, maxStallRetries)
+		runOnEvents(func() { item.label.SetText(label) })
+	}
+}
@@ -0,0 +1,432 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Download queue concurrency (settings.json downloadConcurrency)
+const (
+	defaultDownloadConcurrency = 1
+	maxDownloadConcurrency     = 3
+)
+
+// errVerifyFailed wraps hash verification failures, which are reported in the
+// status bar rather than in an error dialog
+var errVerifyFailed = errors.New("verification failed")
+
+type queueState int
+
+const (
+	queuePending queueState = iota
+	queueActive
+	queueDone
+	queueFailed
+)
+
+// queueItem is one game in the download queue. Each item carries the system it
+// was queued from, so it keeps downloading to the right place while the user
+// browses other systems.
+type queueItem struct {
+	System string
+	Game   ROM
+	State  queueState
+	Err    error
+
+	cancel    context.CancelFunc
+	cancelled bool
+	done      func(err error)
+
+	// Row widgets, kept so progress can be updated in place
+	bar       *widget.ProgressBar
+	label     *widget.Label
+	title     *widget.Label
+	cancelBtn *widget.Button
+}
+
+func downloadConcurrency() int {
+	n := settings.DownloadConcurrency
+	if n < 1 {
+		return defaultDownloadConcurrency
+	}
+	if n > maxDownloadConcurrency {
+		return maxDownloadConcurrency
+	}
+	return n
+}
+
+// buildQueuePanel creates the download queue panel shown under the game list.
+// It is hidden while the queue is empty.
+func (a *App) buildQueuePanel() fyne.CanvasObject {
+	a.queueBox = container.NewVBox()
+	header := widget.NewLabel("DOWNLOADS")
+	header.TextStyle = fyne.TextStyle{Bold: true}
+	clearBtn := widget.NewButton("Clear finished", func() {
+		a.clearFinishedDownloads()
+	})
+	a.queueTotal = widget.NewLabel("")
+	scroll := container.NewVScroll(a.queueBox)
+	scroll.SetMinSize(fyne.NewSize(0, 140))
+	a.queuePanel = container.NewBorder(container.NewHBox(header, clearBtn, a.queueTotal), nil, nil, nil, scroll)
+	a.queuePanel.Hide()
+	return a.queuePanel
+}
+
+// enqueueDownload adds a game to the download queue. done, if non-nil, is
+// called with the result once the item finishes, fails or is cancelled. It
+// reports false, without calling done, if the game is already queued.
+func (a *App) enqueueDownload(sysID string, game ROM, done func(err error)) bool {
+	a.queueMu.Lock()
+	for _, item := range a.queue {
+		if item.System == sysID && item.Game.Name == game.Name && (item.State == queuePending || item.State == queueActive) {
+			a.queueMu.Unlock()
+			a.statusBar.SetText("Already queued: " + game.Name)
+			return false
+		}
+	}
+
+	game.System = sysID
+	item := &queueItem{
+		System: sysID,
+		Game:   game,
+		State:  queuePending,
+		done:   done,
+		bar:    widget.NewProgressBar(),
+		label:  widget.NewLabel("Queued"),
+		title:  widget.NewLabel(game.Name),
+	}
+	if sysID != a.currentSystem {
+		item.title.SetText(fmt.Sprintf("%s (%s)", game.Name, systems[sysID].Name))
+	}
+	item.cancelBtn = widget.NewButton("Cancel", func() {
+		a.cancelQueueItem(item)
+	})
+	a.queue = append(a.queue, item)
+	a.queueMu.Unlock()
+
+	logInfo("Queued download: %s (%s)", game.Name, sysID)
+	a.statusBar.SetText("Queued: " + game.Name)
+	a.refreshQueuePanel()
+	a.pumpQueue()
+	return true
+}
+
+// pumpQueue starts pending items until the concurrency limit is reached
+func (a *App) pumpQueue() {
+	a.queueMu.Lock()
+	var start []*queueItem
+	active := 0
+	for _, item := range a.queue {
+		if item.State == queueActive {
+			active++
+		}
+	}
+	for _, item := range a.queue {
+		if active >= downloadConcurrency() {
+			break
+		}
+		if item.State == queuePending {
+			ctx, cancel := context.WithCancel(context.Background())
+			item.State = queueActive
+			item.cancel = cancel
+			active++
+			start = append(start, item)
+			go a.runQueueItem(ctx, item)
+		}
+	}
+	a.queueMu.Unlock()
+
+	for _, item := range start {
+		item.label.SetText("Starting download...")
+	}
+}
+
+// runQueueItem downloads one item and then lets the next one start
+func (a *App) runQueueItem(ctx context.Context, item *queueItem) {
+	err := a.fetchGame(ctx, item)
+	item.cancel()
+
+	a.queueMu.Lock()
+	// A game that's on disk despite the cancel (it finished first, or only
+	// its CHD compression was stopped) still counts as downloaded
+	if item.cancelled && err != nil {
+		err = errDownloadCancelled
+	}
+	item.Err = err
+	if err == nil {
+		item.State = queueDone
+	} else {
+		item.State = queueFailed
+	}
+	a.queueMu.Unlock()
+
+	runOnEvents(func() { a.finishQueueItem(item, err) })
+}
+
+// finishQueueItem shows a finished item's result and lets the next one start
+func (a *App) finishQueueItem(item *queueItem, err error) {
+	game := item.Game
+	switch {
+	case err == nil:
+		item.bar.SetValue(1)
+		item.label.SetText("Done")
+		if a.setDownloaded(game, true) {
+			a.refilterAfterDownloadChange()
+			a.updateLaunchButton()
+		}
+		a.statusBar.SetText("Downloaded: " + game.Name)
+		a.refreshLibraryUsage()
+		go updateDiscPlaylist(item.System, game)
+	case errors.Is(err, errDownloadCancelled):
+		// Cancelled items leave the queue straight away
+		a.removeQueueItem(item)
+	case errors.Is(err, errVerifyFailed):
+		item.label.SetText("Failed: " + err.Error())
+		if a.setDownloaded(game, false) {
+			a.refilterAfterDownloadChange()
+		}
+		a.statusBar.SetText(fmt.Sprintf("Verification failed: %s (%v)", game.Name, errors.Unwrap(err)))
+		a.refreshLibraryUsage()
+	default:
+		item.label.SetText("Failed: " + err.Error())
+		a.showDownloadError(item, err)
+	}
+
+	a.recordDownloadResult(item.System, game, err)
+	if item.done != nil {
+		item.done(err)
+	}
+	a.refreshQueuePanel()
+	a.pumpQueue()
+}
+
+// showDownloadError reports a failed download and offers to retry it. What
+// was downloaded is kept as a .part file, so a retry picks up where it stopped.
+func (a *App) showDownloadError(item *queueItem, err error) {
+	message := widget.NewLabel(fmt.Sprintf("Downloading %s failed:\n%v\n\nRetry resumes from where it stopped.", item.Game.Name, err))
+	message.Wrapping = fyne.TextWrapWord
+
+	a.dialogOpen = true
+	d := dialog.NewCustomConfirm("Download Failed", "Retry", "Cancel", message, func(retry bool) {
+		a.dialogOpen = false
+		if !retry {
+			return
+		}
+		logInfo("Retrying download from error dialog: %s", item.Game.Name)
+		// done has already seen this failure, so the retry doesn't report to it
+		a.removeQueueItem(item)
+		a.enqueueDownload(item.System, item.Game, nil)
+	}, a.window)
+	d.Resize(fyne.NewSize(450, 200))
+	d.Show()
+}
+
+// cancelQueueItem cancels a single item. A pending item is simply dropped; an
+// active one is stopped and its .part file kept so it can resume later.
+func (a *App) cancelQueueItem(item *queueItem) {
+	a.queueMu.Lock()
+	state := item.State
+	if state == queueActive {
+		item.cancelled = true
+		item.cancel()
+	}
+	a.queueMu.Unlock()
+
+	switch state {
+	case queuePending:
+		logInfo("Cancelled queued download: %s", item.Game.Name)
+		a.removeQueueItem(item)
+		a.recordDownloadResult(item.System, item.Game, errDownloadCancelled)
+		if item.done != nil {
+			item.done(errDownloadCancelled)
+		}
+	case queueActive:
+		logInfo("Cancelling active download: %s", item.Game.Name)
+		item.label.SetText("Cancelling...")
+	default:
+		a.removeQueueItem(item)
+	}
+}
+
+func (a *App) removeQueueItem(item *queueItem) {
+	a.queueMu.Lock()
+	for i, it := range a.queue {
+		if it == item {
+			a.queue = append(a.queue[:i], a.queue[i+1:]...)
+			break
+		}
+	}
+	a.queueMu.Unlock()
+	a.refreshQueuePanel()
+}
+
+// clearFinishedDownloads removes done and failed items from the panel
+func (a *App) clearFinishedDownloads() {
+	a.queueMu.Lock()
+	remaining := a.queue[:0]
+	for _, item := range a.queue {
+		if item.State == queuePending || item.State == queueActive {
+			remaining = append(remaining, item)
+		}
+	}
+	a.queue = remaining
+	a.queueMu.Unlock()
+	a.refreshQueuePanel()
+}
+
+// refreshQueuePanel rebuilds the queue rows and hides the panel when empty
+func (a *App) refreshQueuePanel() {
+	if a.queueBox == nil {
+		return
+	}
+	a.queueMu.Lock()
+	rows := make([]fyne.CanvasObject, 0, len(a.queue))
+	var waiting []ROM
+	for _, item := range a.queue {
+		if item.State == queueDone || item.State == queueFailed {
+			item.cancelBtn.SetText("Remove")
+		} else {
+			waiting = append(waiting, item.Game)
+		}
+		row := container.NewBorder(nil, nil, nil,
+			container.NewHBox(item.label, item.cancelBtn),
+			container.NewVBox(item.title, item.bar),
+		)
+		rows = append(rows, row)
+	}
+	empty := len(a.queue) == 0
+	a.queueMu.Unlock()
+
+	a.queueBox.Objects = rows
+	a.queueBox.Refresh()
+	a.queueTotal.SetText(queueTotalText(waiting))
+	if empty {
+		a.queuePanel.Hide()
+	} else {
+		a.queuePanel.Show()
+	}
+}
+
+// queueTotalText sums the sizes of the queued and downloading games for the
+// queue panel's header, warning when they won't fit on the ROMs drive
+func queueTotalText(games []ROM) string {
+	if len(games) == 0 {
+		return ""
+	}
+	total, unknown := sizeTotal(games)
+	return fmt.Sprintf("%d to go, %s%s", len(games), formatSizeTotal(total, unknown), freeSpaceNote(total))
+}
+
+// fetchGame downloads (and extracts/verifies) a queued game, reporting
+// progress on the item's row
+func (a *App) fetchGame(ctx context.Context, item *queueItem) error {
+	game := item.Game
+	config := systems[item.System]
+	romDir := filepath.Join(romsDir, config.Dir)
+	os.MkdirAll(romDir, 0755)
+
+	logDebug("downloadGame: Name=%s, TitleID=%s, SpecialDownload=%s", game.Name, game.TitleID, config.SpecialDownload)
+
+	// Handle Wii U special download
+	if config.isWiiU() && game.TitleID != "" {
+		return a.fetchWiiUGame(ctx, item)
+	}
+
+	if game.URL == "" {
+		return fmt.Errorf("%s has no download URL in %s", game.Name, config.RomJsonFile)
+	}
+
+	outputPath := filepath.Join(romDir, game.Name)
+	err := a.downloadWatched(ctx, item, downloadURLs(game, config), outputPath)
+	if ctx.Err() != nil {
+		// The .part file is kept so downloading again resumes
+		return errDownloadCancelled
+	}
+	if err != nil {
+		return err
+	}
+
+	// Extract if needed
+	verifyPath := outputPath
+	if config.NeedsExtract && archiveExt(game.Name) != "" {
+		runOnEvents(func() {
+			item.label.SetText("Extracting...")
+			item.bar.SetValue(0)
+		})
+		throttle := &uiThrottle{}
+		extractedPath, err := extractArchive(ctx, outputPath, romDir, func(done, total int64) {
+			if total <= 0 || !throttle.ready(done >= total) {
+				return
+			}
+			runOnEvents(func() {
+				item.bar.SetValue(float64(done) / float64(total))
+				item.label.SetText(fmt.Sprintf("Extracting %s / %s", formatBytes(done), formatBytes(total)))
+			})
+		})
+		// The archive goes either way: after a failed extraction it would
+		// otherwise look like a downloaded game the emulator can't open
+		os.Remove(outputPath)
+		if ctx.Err() != nil {
+			return errDownloadCancelled
+		}
+		if err != nil {
+			logError("Extraction failed for %s: %v", game.Name, err)
+			return err
+		}
+		verifyPath = extractedPath
+	}
+
+	// Check the result against the set's hashes, if it has any
+	if verifyPath != "" && game.hasHashes() && !settings.SkipHashVerification {
+		runOnEvents(func() { item.label.SetText("Verifying...") })
+		if err := verifyROMFile(verifyPath, game); err != nil {
+			logError("Verification failed for %s: %v", game.Name, err)
+			os.Remove(verifyPath)
+			return fmt.Errorf("%w: %v", errVerifyFailed, err)
+		}
+	}
+
+	// Compress disc images to CHD once they've been checked
+	if wantsCHD(config) {
+		if src := chdSource(romDir, game, verifyPath); src != "" {
+			if findChdman() == "" {
+				logWarn("chdman not found, keeping %s uncompressed", filepath.Base(src))
+				return nil
+			}
+			runOnEvents(func() {
+				item.label.SetText("Compressing to CHD...")
+				item.bar.SetValue(0)
+			})
+			throttle := &uiThrottle{}
+			chdPath, err := compressToCHD(ctx, item.System, src, func(done float64) {
+				if !throttle.ready(done >= 1) {
+					return
+				}
+				runOnEvents(func() {
+					item.bar.SetValue(done)
+					item.label.SetText(fmt.Sprintf("Compressing to CHD... %.0f%%", done*100))
+				})
+			})
+			if ctx.Err() != nil {
+				// The uncompressed image is kept and still launches, so the
+				// game is downloaded
+				logInfo("CHD compression of %s cancelled, keeping it uncompressed", filepath.Base(src))
+				return nil
+			}
+			if err != nil {
+				logWarn("CHD compression failed for %s, keeping the original: %v", game.Name, err)
+			} else {
+				logInfo("Compressed %s to %s", filepath.Base(src), filepath.Base(chdPath))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// recentSystemID is the sidebar entry listing the last games played on any
+// system. It isn't in systems.json; each of its games keeps its real System,
+// so launching, downloading and favorites go through that system's config.
+const (
+	recentSystemID   = "_recent"
+	recentSystemName = "Recently Played"
+	maxRecentGames   = 30
+)
+
+// systemDisplayName returns the sidebar name of a system ID
+func systemDisplayName(sysID string) string {
+	switch sysID {
+	case recentSystemID:
+		return recentSystemName
+	case favoritesSystemID:
+		return favoritesSystemName
+	}
+	return systems[sysID].Name
+}
+
+// recentGames returns the most recently launched games across all systems,
+// newest first. Games are looked up in their system's set so they keep their
+// URL and size; one that's no longer in the set is listed by name only.
+func recentGames() []ROM {
+	type played struct {
+		system string
+		name   string
+		at     time.Time
+	}
+
+	var entries []played
+	playStatsMu.Lock()
+	for sysID, games := range playStats {
+		if _, ok := systems[sysID]; !ok {
+			continue
+		}
+		for name, stat := range games {
+			entries = append(entries, played{sysID, name, stat.LastPlayed})
+		}
+	}
+	playStatsMu.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].at.After(entries[j].at)
+	})
+	if len(entries) > maxRecentGames {
+		entries = entries[:maxRecentGames]
+	}
+
+	sets := make(map[string]map[string]ROM)
+	games := make([]ROM, 0, len(entries))
+	for _, entry := range entries {
+		set, ok := sets[entry.system]
+		if !ok {
+			set = loadSetByName(entry.system)
+			sets[entry.system] = set
+		}
+		game, ok := set[entry.name]
+		if !ok {
+			game = ROM{Name: entry.name}
+		}
+		game.System = entry.system
+		games = append(games, game)
+	}
+	return games
+}
+
+// loadSet reads a system's game set, with each game's System set
+func loadSet(sysID string) ([]ROM, error) {
+	var games []ROM
+	data, err := readDataFile(filepath.Join("1g1rsets", systems[sysID].RomJsonFile))
+	if err == nil {
+		err = json.Unmarshal(data, &games)
+	}
+	if err != nil {
+		logWarn("Can't read set for %s: %v", sysID, err)
+		return nil, err
+	}
+	for i := range games {
+		games[i].System = sysID
+	}
+	return games, nil
+}
+
+// loadSetByName reads a system's game set, with multi-disc sets grouped as
+// in the game list, keyed by game name. Errors give an empty set.
+func loadSetByName(sysID string) map[string]ROM {
+	games, _ := loadSet(sysID)
+	games = groupDiscs(games)
+	set := make(map[string]ROM, len(games))
+	for _, game := range games {
+		set[game.Name] = game
+	}
+	return set
+}
+
+// selectRecentGames shows the Recently Played list in the game browser
+func (a *App) selectRecentGames() {
+	games := recentGames()
+	logDebug("Recently Played: %d games", len(games))
+	a.showMixedGames(games)
+}
+
+// showMixedGames fills the game browser with games from several systems,
+// for Recently Played and All Favorites
+func (a *App) showMixedGames(games []ROM) {
+	lowerNames := make([]string, len(games))
+	for i, game := range games {
+		lowerNames[i] = strings.ToLower(game.Name)
+	}
+	a.setLoadedGames(games, lowerNames)
+
+	a.buildROMCache()
+	a.filterGames()
+	a.updateRetryButton()
+	a.updateDebugPanel()
+	a.updateLibraryLabel()
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Region/language filter choices in the Regions dialog
+const (
+	tagFilterAny  = "Any"
+	tagFilterOnly = "Only"
+	tagFilterHide = "Hide"
+)
+
+// regionLanguages is the language of a single-region release without a
+// language tag: No-Intro only tags languages a region doesn't imply
+var regionLanguages = map[string]string{
+	"World": "En", "USA": "En", "Europe": "En", "UK": "En", "Australia": "En",
+	"Canada": "En", "Japan": "Ja", "France": "Fr", "Germany": "De", "Spain": "Es",
+	"Italy": "It", "Netherlands": "Nl", "Sweden": "Sv", "Brazil": "Pt",
+	"Portugal": "Pt", "Korea": "Ko", "China": "Zh", "Taiwan": "Zh",
+	"Hong Kong": "Zh", "Russia": "Ru", "Poland": "Pl",
+}
+
+// tagLanguages returns a game's languages from its name tags, or the ones
+// its regions imply when it has none
+func tagLanguages(tags romTags, regions []string) []string {
+	if len(tags.Languages) > 0 {
+		return tags.Languages
+	}
+	var languages []string
+	for _, region := range regions {
+		if lang, ok := regionLanguages[region]; ok && !containsFold(languages, lang) {
+			languages = append(languages, lang)
+		}
+	}
+	return languages
+}
+
+// regionFilterActive reports whether any region or language rule is set
+func regionFilterActive() bool {
+	return len(settings.OnlyRegions) > 0 || len(settings.HideRegions) > 0 ||
+		len(settings.OnlyLanguages) > 0 || len(settings.HideLanguages) > 0
+}
+
+// matchesRegionFilter reports whether a game passes the region and language
+// rules in settings. Games without region (or language) information always
+// pass that half of the filter.
+func matchesRegionFilter(game ROM) bool {
+	if !regionFilterActive() {
+		return true
+	}
+	tags := parseROMTags(game.Name)
+	regions := tagRegions(game, tags)
+	return matchesTagRules(regions, settings.OnlyRegions, settings.HideRegions, "World") &&
+		matchesTagRules(tagLanguages(tags, regions), settings.OnlyLanguages, settings.HideLanguages, "")
+}
+
+// matchesTagRules applies one kind of rule to a game's tags: with only set,
+// one of them must be listed (wildcard, e.g. "World", matches any); a game
+// is hidden when every one of its tags is in hide, so "(USA, Japan)" stays
+// when Japan is hidden.
+func matchesTagRules(tags, only, hide []string, wildcard string) bool {
+	if len(tags) == 0 {
+		return true
+	}
+	if len(only) > 0 {
+		found := false
+		for _, tag := range tags {
+			if (wildcard != "" && tag == wildcard) || containsFold(only, tag) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(hide) > 0 {
+		for _, tag := range tags {
+			if !containsFold(hide, tag) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// regionFilterLabel is the header button's text, with the number of rules set
+func regionFilterLabel() string {
+	n := len(settings.OnlyRegions) + len(settings.HideRegions) +
+		len(settings.OnlyLanguages) + len(settings.HideLanguages)
+	if n == 0 {
+		return "Regions"
+	}
+	return fmt.Sprintf("Regions (%d)", n)
+}
+
+// showRegionFilterDialog lets the user pick, for each region and language in
+// the current list (and any already in settings), whether to show only those
+// games, hide them, or not filter on it. The choice is saved and applies to
+// every system.
+func (a *App) showRegionFilterDialog() {
+	regions := append([]string{}, settings.OnlyRegions...)
+	regions = append(regions, settings.HideRegions...)
+	languages := append([]string{}, settings.OnlyLanguages...)
+	languages = append(languages, settings.HideLanguages...)
+	games, _ := a.loadedGames()
+	for _, game := range games {
+		tags := parseROMTags(game.Name)
+		gameRegions := tagRegions(game, tags)
+		regions = append(regions, gameRegions...)
+		languages = append(languages, tagLanguages(tags, gameRegions)...)
+	}
+	regions = uniqueSorted(regions)
+	languages = uniqueSorted(languages)
+	if len(regions) == 0 && len(languages) == 0 {
+		a.statusBar.SetText("No region or language tags in this list")
+		return
+	}
+
+	regionChoices := tagFilterForm(regions, settings.OnlyRegions, settings.HideRegions)
+	languageChoices := tagFilterForm(languages, settings.OnlyLanguages, settings.HideLanguages)
+
+	regionsHeader := widget.NewLabel("Regions")
+	regionsHeader.TextStyle = fyne.TextStyle{Bold: true}
+	languagesHeader := widget.NewLabel("Languages")
+	languagesHeader.TextStyle = fyne.TextStyle{Bold: true}
+	help := widget.NewLabel("Only: list just games with one of these.\nHide: leave out games tagged only with these.\nGames without tags are always listed.")
+	rows := container.NewVBox(help, regionsHeader, regionChoices.form, languagesHeader, languageChoices.form)
+	scroll := container.NewVScroll(rows)
+	scroll.SetMinSize(fyne.NewSize(420, 400))
+
+	a.dialogOpen = true
+	d := dialog.NewCustomConfirm("Region & Language Filter", "Save", "Cancel", scroll, func(ok bool) {
+		a.dialogOpen = false
+		if !ok {
+			return
+		}
+		settings.OnlyRegions, settings.HideRegions = regionChoices.rules()
+		settings.OnlyLanguages, settings.HideLanguages = languageChoices.rules()
+		saveSettings()
+		a.regionFilterBtn.SetText(regionFilterLabel())
+		a.filterGames()
+	}, a.window)
+	d.Show()
+}
+
+// tagFilterChoices is one section of the Regions dialog
+type tagFilterChoices struct {
+	form   *widget.Form
+	tags   []string
+	radios []*widget.RadioGroup
+}
+
+func tagFilterForm(tags, only, hide []string) tagFilterChoices {
+	choices := tagFilterChoices{form: widget.NewForm(), tags: tags}
+	for _, tag := range tags {
+		radio := widget.NewRadioGroup([]string{tagFilterAny, tagFilterOnly, tagFilterHide}, nil)
+		radio.Horizontal = true
+		radio.Required = true
+		switch {
+		case containsFold(only, tag):
+			radio.SetSelected(tagFilterOnly)
+		case containsFold(hide, tag):
+			radio.SetSelected(tagFilterHide)
+		default:
+			radio.SetSelected(tagFilterAny)
+		}
+		choices.radios = append(choices.radios, radio)
+		choices.form.Append(tag, radio)
+	}
+	return choices
+}
+
+// rules returns the tags set to Only and to Hide
+func (c tagFilterChoices) rules() (only, hide []string) {
+	for i, radio := range c.radios {
+		switch radio.Selected {
+		case tagFilterOnly:
+			only = append(only, c.tags[i])
+		case tagFilterHide:
+			hide = append(hide, c.tags[i])
+		}
+	}
+	return only, hide
+}
+
+// uniqueSorted returns the distinct strings in list, sorted
+func uniqueSorted(list []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, s := range list {
+		if s != "" && !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
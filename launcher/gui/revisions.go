@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Values of settings.CollapseRevisions
+const (
+	collapseLatest  = "latest"  // show the highest revision, betas included
+	collapseRelease = "release" // show a non-beta dump if there is one, then the highest revision
+)
+
+// revisionGroupKey is what the revisions and pre-release dumps of one game
+// have in common: the system and the lower-cased name without its extension
+// and its Rev, Beta, Proto, Demo and Sample tags. Region, language and disc
+// tags are kept, so "(USA)" and "(Europe)" releases stay separate.
+func revisionGroupKey(game ROM) string {
+	name := game.Name
+	if ext := filepath.Ext(name); !strings.ContainsAny(ext, " ()") {
+		name = strings.TrimSuffix(name, ext)
+	}
+
+	var key strings.Builder
+	rest := name
+	for {
+		start := strings.Index(rest, "(")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(rest[start:], ")")
+		if end < 0 {
+			break
+		}
+		group := rest[start+1 : start+end]
+		if strings.HasPrefix(group, "Rev ") || isBetaTag(group) {
+			key.WriteString(strings.TrimRight(rest[:start], " "))
+		} else {
+			key.WriteString(rest[:start+end+1])
+		}
+		rest = rest[start+end+1:]
+	}
+	key.WriteString(rest)
+	return game.System + "/" + strings.ToLower(strings.TrimSpace(key.String()))
+}
+
+// preferredVariant reports whether a should be shown for its group instead of b
+func preferredVariant(a, b romTags) bool {
+	if settings.CollapseRevisions == collapseRelease && a.Beta != b.Beta {
+		return !a.Beta
+	}
+	if a.Revision != b.Revision {
+		return a.Revision > b.Revision
+	}
+	return !a.Beta && b.Beta
+}
+
+// revisionGroups describes the groups of revisions in the shown games: the
+// size of each group, by revisionGroupKey, and the games listed under an
+// expanded group's preferred dump, by romCacheKey. Both maps are built before
+// they're published and never changed after.
+type revisionGroups struct {
+	counts   map[string]int
+	variants map[string]bool
+}
+
+// collapseRevisions turns the filtered game list into one row per group of
+// revisions, at the place of the group's first game, showing the preferred
+// dump. The rest of the groups in expanded are listed under it. With
+// collapseRevisions off the games are returned as they are.
+func collapseRevisions(games []ROM, expanded map[string]bool) ([]ROM, revisionGroups) {
+	if settings.CollapseRevisions == "" {
+		return games, revisionGroups{}
+	}
+
+	groups := make(map[string][]ROM)
+	var order []string
+	for _, game := range games {
+		key := revisionGroupKey(game)
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], game)
+	}
+	if len(order) == len(games) {
+		return games, revisionGroups{}
+	}
+
+	revisions := revisionGroups{counts: make(map[string]int), variants: make(map[string]bool)}
+	collapsed := make([]ROM, 0, len(order))
+	for _, key := range order {
+		group := groups[key]
+		if len(group) == 1 {
+			collapsed = append(collapsed, group[0])
+			continue
+		}
+		revisions.counts[key] = len(group)
+
+		best := 0
+		bestTags := parseROMTags(group[0].Name)
+		for i := 1; i < len(group); i++ {
+			if tags := parseROMTags(group[i].Name); preferredVariant(tags, bestTags) {
+				best, bestTags = i, tags
+			}
+		}
+		collapsed = append(collapsed, group[best])
+		if !expanded[key] {
+			continue
+		}
+		for i, game := range group {
+			if i != best {
+				collapsed = append(collapsed, game)
+				revisions.variants[romCacheKey(game)] = true
+			}
+		}
+	}
+	return collapsed, revisions
+}
+
+// revisionLabel is the game list's note for a collapsed group: the number of
+// other dumps of the game, or "" for games without any
+func (a *App) revisionLabel(game ROM) string {
+	a.gamesMu.RLock()
+	defer a.gamesMu.RUnlock()
+	if a.revisions.variants[romCacheKey(game)] {
+		return ""
+	}
+	if n := a.revisions.counts[revisionGroupKey(game)]; n > 1 {
+		return fmt.Sprintf("[+%d]", n-1)
+	}
+	return ""
+}
+
+// isRevisionVariant reports whether a game is listed under its group's
+// preferred dump because the group is expanded
+func (a *App) isRevisionVariant(game ROM) bool {
+	a.gamesMu.RLock()
+	defer a.gamesMu.RUnlock()
+	return a.revisions.variants[romCacheKey(game)]
+}
+
+// revisionCount returns the number of dumps in the shown group key
+func (a *App) revisionCount(key string) int {
+	a.gamesMu.RLock()
+	defer a.gamesMu.RUnlock()
+	return a.revisions.counts[key]
+}
+
+// expandedRevisionGroups returns the groups of revisions listed in full. The
+// map is replaced rather than changed, so it can be read after the lock is
+// released.
+func (a *App) expandedRevisionGroups() map[string]bool {
+	a.gamesMu.RLock()
+	defer a.gamesMu.RUnlock()
+	return a.expandedGroups
+}
+
+// setGroupExpanded lists the group key in full, or collapses it again
+func (a *App) setGroupExpanded(key string, expanded bool) {
+	a.gamesMu.Lock()
+	defer a.gamesMu.Unlock()
+	groups := make(map[string]bool, len(a.expandedGroups)+1)
+	for k := range a.expandedGroups {
+		groups[k] = true
+	}
+	if expanded {
+		groups[key] = true
+	} else {
+		delete(groups, key)
+	}
+	a.expandedGroups = groups
+}
+
+// toggleVariants expands or collapses the selected game's group of
+// revisions, keeping the selection on the game
+func (a *App) toggleVariants() {
+	game, ok := a.selectedGame()
+	if !ok || settings.CollapseRevisions == "" {
+		return
+	}
+	key := revisionGroupKey(game)
+	if a.revisionCount(key) < 2 {
+		a.statusBar.SetText("No other revisions of " + trimArchiveExt(game.Name))
+		return
+	}
+	a.setGroupExpanded(key, !a.expandedRevisionGroups()[key])
+
+	a.refilterKeepingSelection()
+	// A variant that was selected is hidden again: select its group's row
+	if selected, ok := a.selectedGame(); !ok || revisionGroupKey(selected) != key {
+		for i, shown := range a.shownGames() {
+			if revisionGroupKey(shown) == key {
+				a.selectGame(i)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Rom folders are listed once per system and then kept up to date by a
+// watcher, so going back to a system doesn't read its folder again (slow on
+// network drives) and a file added or removed outside the launcher updates
+// the Ready/DL badges. Without a watcher every visit lists the folder, as
+// before.
+
+// romDirSettle is how long a folder has to be quiet before its change is
+// passed on, so copying many files rebuilds the badges once
+const romDirSettle = 500 * time.Millisecond
+
+// romDirWatch caches rom folder listings and watches them for changes
+type romDirWatch struct {
+	mu       sync.Mutex
+	watcher  *fsnotify.Watcher
+	failed   bool                     // the watcher couldn't be created
+	listings map[string][]fs.DirEntry // folder -> entries; never changed in place
+	changed  map[string]bool          // folders changed since the last flush
+	timer    *time.Timer
+	// onChange is called (on the watcher's goroutine) with a folder whose
+	// listing changed
+	onChange func(dir string)
+}
+
+var romDirs = &romDirWatch{
+	listings: make(map[string][]fs.DirEntry),
+	changed:  make(map[string]bool),
+}
+
+// list returns a folder's entries, from the cache when it's being watched
+func (w *romDirWatch) list(dir string) ([]fs.DirEntry, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if entries, ok := w.listings[dir]; ok {
+		return entries, nil
+	}
+
+	// Watch before listing so nothing created in between is missed; events
+	// wait for the lock, and apply skips entries the listing already has
+	watched := w.watch(dir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	if watched {
+		w.listings[dir] = entries
+	}
+	return entries, nil
+}
+
+// watch adds a folder to the watcher, creating it on first use; must be
+// called with mu held
+func (w *romDirWatch) watch(dir string) bool {
+	if w.watcher == nil {
+		if w.failed {
+			return false
+		}
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			w.failed = true
+			logWarn("Can't watch rom folders (%v), they'll be rescanned on every visit", err)
+			return false
+		}
+		w.watcher = watcher
+		go w.run(watcher)
+	}
+	if err := w.watcher.Add(dir); err != nil {
+		logDebug("Not watching %s: %v", dir, err)
+		return false
+	}
+	return true
+}
+
+func (w *romDirWatch) run(watcher *fsnotify.Watcher) {
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			w.apply(event)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			// Events may have been lost (e.g. the queue overflowed), so
+			// every folder is listed again on its next visit
+			logWarn("Rom folder watcher: %v", err)
+			w.mu.Lock()
+			w.listings = make(map[string][]fs.DirEntry)
+			w.mu.Unlock()
+		}
+	}
+}
+
+// apply updates a cached listing for one added or removed entry
+func (w *romDirWatch) apply(event fsnotify.Event) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	gone := event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename)
+	if _, ok := w.listings[event.Name]; ok && gone {
+		// The watched folder itself went away
+		delete(w.listings, event.Name)
+		w.markChanged(event.Name)
+		return
+	}
+
+	dir, name := filepath.Dir(event.Name), filepath.Base(event.Name)
+	entries, ok := w.listings[dir]
+	if !ok || !(gone || event.Has(fsnotify.Create)) {
+		return
+	}
+	updated := make([]fs.DirEntry, 0, len(entries)+1)
+	for _, entry := range entries {
+		if entry.Name() != name {
+			updated = append(updated, entry)
+		}
+	}
+	if event.Has(fsnotify.Create) {
+		if info, err := os.Lstat(event.Name); err == nil {
+			updated = append(updated, fs.FileInfoToDirEntry(info))
+		}
+	}
+	w.listings[dir] = updated
+	w.markChanged(dir)
+}
+
+// markChanged queues a folder for onChange once changes settle; must be
+// called with mu held
+func (w *romDirWatch) markChanged(dir string) {
+	w.changed[dir] = true
+	if w.timer == nil {
+		w.timer = time.AfterFunc(romDirSettle, w.flush)
+	} else {
+		w.timer.Reset(romDirSettle)
+	}
+}
+
+func (w *romDirWatch) flush() {
+	w.mu.Lock()
+	changed := w.changed
+	w.changed = make(map[string]bool)
+	w.timer = nil
+	onChange := w.onChange
+	w.mu.Unlock()
+
+	if onChange == nil {
+		return
+	}
+	for dir := range changed {
+		onChange(dir)
+	}
+}
+
+// romDirChanged rebuilds the badges when the shown system's folder changed
+// outside the launcher
+func (a *App) romDirChanged(dir string) {
+	if !isVirtualSystem(a.currentSystem) && !withinDir(dir, filepath.Join(romsDir, systems[a.currentSystem].Dir)) {
+		return
+	}
+	logDebug("Rom folder changed: %s", dir)
+	a.buildROMCache()
+	if a.downloadFilter != "" && a.downloadFilter != filterAll {
+		a.refilterKeepingSelection()
+	} else {
+		a.refreshGameView()
+	}
+	a.updateStatus()
+	a.updateLaunchButton()
+	a.updateLibraryLabel()
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Games may be sorted into subfolders of a system's rom folder, e.g.
+// roms/snes/RPG/Chrono Trigger.sfc. They're found up to romFolderDepth
+// levels down; files directly in the rom folder win over nested ones with
+// the same name. Wii U titles are folders themselves, so only the top level
+// is looked at for them.
+
+const (
+	defaultRomFolderDepth = 2
+	maxRomFolderDepth     = 8
+)
+
+func romFolderDepth() int {
+	if settings.RomFolderDepth > 0 {
+		return settings.RomFolderDepth
+	}
+	return defaultRomFolderDepth
+}
+
+// romFile is a file or folder found in a system's rom folder
+type romFile struct {
+	Path  string // full path
+	Name  string // base name
+	IsDir bool
+	Depth int // 0 for the rom folder itself, 1 for its subfolders, ...
+}
+
+// walkROMDir lists a rom folder and its subfolders, shallowest first. Hidden
+// folders are skipped. Listings come from romDirs, so folders already seen
+// aren't read again.
+func walkROMDir(romDir string, wiiU bool) []romFile {
+	maxDepth := romFolderDepth()
+	if wiiU {
+		maxDepth = 0
+	}
+
+	var files []romFile
+	dirs := []string{romDir}
+	for depth := 0; depth <= maxDepth && len(dirs) > 0; depth++ {
+		var next []string
+		for _, dir := range dirs {
+			entries, err := romDirs.list(dir)
+			if err != nil {
+				continue
+			}
+			for _, entry := range entries {
+				path := filepath.Join(dir, entry.Name())
+				files = append(files, romFile{Path: path, Name: entry.Name(), IsDir: entry.IsDir(), Depth: depth})
+				if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+					next = append(next, path)
+				}
+			}
+		}
+		dirs = next
+	}
+	return files
+}
+
+// findNestedROM looks for a game's file in the rom folder's subfolders,
+// matching it the way findROMFile does at the top level
+func findNestedROM(config SystemConfig, romDir string, game ROM) string {
+	baseName := trimArchiveExt(game.Name)
+	names := make(map[string]bool)
+	for _, ext := range config.FileExtensions {
+		names[strings.ToLower(baseName+ext)] = true
+	}
+	if !config.NeedsExtract {
+		names[strings.ToLower(game.Name)] = true
+	}
+
+	var prefixed string
+	for _, file := range walkROMDir(romDir, false) {
+		if file.Depth == 0 || file.IsDir {
+			continue
+		}
+		lower := strings.ToLower(file.Name)
+		if names[lower] {
+			return file.Path
+		}
+		if prefixed == "" && config.NeedsExtract && strings.HasPrefix(file.Name, baseName) && hasExtension(lower, config.FileExtensions) {
+			prefixed = file.Path
+		}
+	}
+	return prefixed
+}
+
+// hasExtension reports whether a lower-case file name ends in one of exts
+func hasExtension(lower string, exts []string) bool {
+	for _, ext := range exts {
+		if strings.HasSuffix(lower, strings.ToLower(ext)) {
+			return true
+		}
+	}
+	return false
+}
+
+// withinDir reports whether path is dir or somewhere below it
+func withinDir(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
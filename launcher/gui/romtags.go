@@ -0,0 +1,183 @@
+package main
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// knownRegions are the region names used in No-Intro/Redump style tags
+var knownRegions = map[string]bool{
+	"World": true, "USA": true, "Europe": true, "Japan": true, "Asia": true,
+	"Australia": true, "Brazil": true, "Canada": true, "China": true, "France": true,
+	"Germany": true, "Hong Kong": true, "Italy": true, "Korea": true, "Netherlands": true,
+	"Spain": true, "Sweden": true, "Taiwan": true, "UK": true, "Russia": true,
+	"Scandinavia": true, "Latin America": true, "Denmark": true, "Finland": true,
+	"Norway": true, "Poland": true, "Portugal": true, "Greece": true, "India": true,
+	"Mexico": true, "Argentina": true, "Belgium": true, "Switzerland": true, "Austria": true,
+}
+
+// romTags is the information encoded in a ROM file name's parenthesised tags,
+// e.g. "Game (USA, Europe) (En,Fr,De) (Rev 1).zip"
+type romTags struct {
+	Title     string   // name without any tags
+	Regions   []string // USA, Europe, ...
+	Languages []string // En, Fr, ...
+	Revision  int      // 0 for the original release, "Rev 1" = 1, "Rev A" = 1, ...
+	Beta      bool     // Beta, Proto, Demo, Sample
+}
+
+// parseROMTags splits a ROM file name into its title and tags
+func parseROMTags(name string) romTags {
+	// Only strip real extensions - "Mario Bros. 3 (USA)" has no extension
+	if ext := filepath.Ext(name); !strings.ContainsAny(ext, " ()") {
+		name = strings.TrimSuffix(name, ext)
+	}
+
+	var tags romTags
+	title := name
+	if idx := strings.Index(name, " ("); idx >= 0 {
+		title = name[:idx]
+	}
+	tags.Title = strings.TrimSpace(title)
+
+	rest := name[len(title):]
+	for {
+		start := strings.Index(rest, "(")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(rest[start:], ")")
+		if end < 0 {
+			break
+		}
+		group := rest[start+1 : start+end]
+		rest = rest[start+end+1:]
+
+		parts := strings.Split(group, ",")
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+
+		switch {
+		case allMatch(parts, func(p string) bool { return knownRegions[p] }):
+			tags.Regions = append(tags.Regions, parts...)
+		case allMatch(parts, isLanguageCode):
+			tags.Languages = append(tags.Languages, parts...)
+		case strings.HasPrefix(group, "Rev "):
+			tags.Revision = parseRevision(strings.TrimPrefix(group, "Rev "))
+		case isBetaTag(group):
+			tags.Beta = true
+		}
+	}
+	return tags
+}
+
+// isBetaTag matches the tags of pre-release dumps: Beta, Proto, Demo, Sample
+func isBetaTag(group string) bool {
+	return strings.HasPrefix(group, "Beta") || strings.HasPrefix(group, "Proto") ||
+		strings.HasPrefix(group, "Demo") || strings.HasPrefix(group, "Sample")
+}
+
+func allMatch(parts []string, match func(string) bool) bool {
+	if len(parts) == 0 {
+		return false
+	}
+	for _, p := range parts {
+		if !match(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// isLanguageCode matches "En", "Fr", "Zh-Hant", ...
+func isLanguageCode(s string) bool {
+	code := s
+	if idx := strings.Index(s, "-"); idx > 0 {
+		code = s[:idx]
+	}
+	return len(code) == 2 && code[0] >= 'A' && code[0] <= 'Z' && code[1] >= 'a' && code[1] <= 'z'
+}
+
+// parseRevision turns "1", "2" or "A", "B" into a comparable number
+func parseRevision(rev string) int {
+	rev = strings.TrimSpace(rev)
+	if n, err := strconv.Atoi(rev); err == nil {
+		return n
+	}
+	if len(rev) == 1 && rev[0] >= 'A' && rev[0] <= 'Z' {
+		return int(rev[0]-'A') + 1
+	}
+	return 0
+}
+
+// regionCodes maps the short region codes of the JSON Region field (Wii U
+// sets) to the names used in tags
+var regionCodes = map[string]string{"USA": "USA", "EUR": "Europe", "JPN": "Japan", "ALL": "World"}
+
+// gameRegions returns a game's regions from its name tags, falling back to the JSON Region field
+func gameRegions(game ROM) []string {
+	return tagRegions(game, parseROMTags(game.Name))
+}
+
+// tagRegions is gameRegions for a name that's already been parsed
+func tagRegions(game ROM, tags romTags) []string {
+	regions := tags.Regions
+	if len(regions) == 0 && game.Region != "" {
+		region := game.Region
+		if name, ok := regionCodes[strings.ToUpper(region)]; ok {
+			region = name
+		}
+		regions = []string{region}
+	}
+	return regions
+}
+
+// matchesPreferredRegion reports whether any of the regions is in the user's
+// preferred list. "World" releases match any preference.
+func matchesPreferredRegion(regions []string) bool {
+	for _, r := range regions {
+		if r == "World" {
+			return true
+		}
+		for _, pref := range settings.PreferredRegions {
+			if strings.EqualFold(r, pref) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Availability badges shown next to downloaded games
+const (
+	badgePreferred = "[Pref]"   // downloaded and from a preferred region
+	badgeRegion    = "[Region]" // downloaded but not from a preferred region
+	badgeOldRev    = "[OldRev]" // an older revision of this game is on disk
+)
+
+// romBadge works out the badge for a game. localTitles maps a lower-case title
+// to the highest revision found among local files with that title.
+func romBadge(game ROM, downloaded bool, localTitles map[string]int) string {
+	if !downloaded {
+		tags := parseROMTags(game.Name)
+		if rev, ok := localTitles[strings.ToLower(tags.Title)]; ok && rev < tags.Revision {
+			return badgeOldRev
+		}
+		return ""
+	}
+
+	// Without a preference there's nothing to compare against
+	if len(settings.PreferredRegions) == 0 {
+		return ""
+	}
+	regions := gameRegions(game)
+	if len(regions) == 0 {
+		return ""
+	}
+	if matchesPreferredRegion(regions) {
+		return badgePreferred
+	}
+	return badgeRegion
+}
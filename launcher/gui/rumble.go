@@ -0,0 +1,53 @@
+package main
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// rumblePulse is one haptic pulse: motor strength from 0 to 1, and how long
+type rumblePulse struct {
+	strength float64
+	duration time.Duration
+}
+
+var (
+	pulseLaunch   = rumblePulse{strength: 0.6, duration: 200 * time.Millisecond}
+	pulseFavorite = rumblePulse{strength: 0.35, duration: 80 * time.Millisecond}
+	pulseListEnd  = rumblePulse{strength: 0.25, duration: 50 * time.Millisecond}
+)
+
+// listEndBumpInterval keeps a stick held against the end of a list from
+// bumping on every repeat
+const listEndBumpInterval = 400 * time.Millisecond
+
+// rumbleUnsupported is set once a pulse fails, so an unsupported pad is
+// logged once and not tried again
+var rumbleUnsupported atomic.Bool
+
+// rumble plays a pulse on the open controller if rumble is turned on. It
+// returns straight away: the pulse is sent from its own goroutine so a slow
+// or missing driver never holds up input handling.
+func (a *App) rumble(pulse rumblePulse) {
+	if !settings.Rumble || rumbleUnsupported.Load() {
+		return
+	}
+	index := int(a.controllerIndex.Load())
+	go func() {
+		if err := vibrate(index, pulse.strength, pulse.duration); err != nil {
+			if !rumbleUnsupported.Swap(true) {
+				logWarn("Controller rumble unavailable: %v", err)
+			}
+		}
+	}()
+}
+
+// bumpListEnd plays a short pulse when navigation can't go past the top or
+// bottom of a list
+func (a *App) bumpListEnd() {
+	if time.Since(a.lastEdgeBump) < listEndBumpInterval {
+		return
+	}
+	a.lastEdgeBump = time.Now()
+	a.rumble(pulseListEnd)
+}
@@ -0,0 +1,114 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// The joystick library reads /dev/input/jsN, which can't rumble. Rumble goes
+// through the same pad's evdev node (/dev/input/eventM) with the kernel's
+// force feedback API: upload an FF_RUMBLE effect, then play it.
+
+const (
+	evFF     = 0x15 // EV_FF
+	ffRumble = 0x50 // FF_RUMBLE
+)
+
+// ffEffect is struct ff_effect from linux/input.h, holding the rumble member
+// of its union. The union's largest member ends in a pointer, which the
+// trailing uintptr stands in for so the size and alignment match the kernel's.
+type ffEffect struct {
+	effectType uint16
+	id         int16
+	direction  uint16
+	trigger    [2]uint16 // button, interval
+	replay     [2]uint16 // length (ms), delay (ms)
+	rumble     ffUnion
+}
+
+type ffUnion struct {
+	strongMagnitude uint16
+	weakMagnitude   uint16
+	_               [20]byte
+	_               uintptr
+}
+
+// inputEvent is struct input_event
+type inputEvent struct {
+	time  syscall.Timeval
+	typ   uint16
+	code  uint16
+	value int32
+}
+
+// eviocsff is EVIOCSFF, _IOW('E', 0x80, struct ff_effect)
+var eviocsff = uintptr(1<<30 | unsafe.Sizeof(ffEffect{})<<16 | 'E'<<8 | 0x80)
+
+// rumbleDevice is the evdev node opened for rumble, kept open between pulses
+var rumbleDevice struct {
+	sync.Mutex
+	index    int
+	file     *os.File
+	effectID int16
+}
+
+// eventDevice finds the evdev node of joystick index
+func eventDevice(index int) (string, error) {
+	matches, _ := filepath.Glob(fmt.Sprintf("/sys/class/input/js%d/device/event*", index))
+	if len(matches) == 0 {
+		return "", fmt.Errorf("no event device for js%d", index)
+	}
+	return filepath.Join("/dev/input", filepath.Base(matches[0])), nil
+}
+
+// vibrate runs both motors of joystick index at strength for d
+func vibrate(index int, strength float64, d time.Duration) error {
+	dev := &rumbleDevice
+	dev.Lock()
+	defer dev.Unlock()
+
+	if dev.file == nil || dev.index != index {
+		if dev.file != nil {
+			dev.file.Close()
+			dev.file = nil
+		}
+		path, err := eventDevice(index)
+		if err != nil {
+			return err
+		}
+		f, err := os.OpenFile(path, os.O_RDWR, 0)
+		if err != nil {
+			return err
+		}
+		dev.file, dev.index, dev.effectID = f, index, -1
+	}
+
+	// Uploading with the previous effect's id replaces it, so the pad
+	// holds one effect however many pulses are played
+	magnitude := uint16(strength * 0xFFFF)
+	effect := ffEffect{effectType: ffRumble, id: dev.effectID}
+	effect.replay[0] = uint16(d / time.Millisecond)
+	effect.rumble.strongMagnitude = magnitude
+	effect.rumble.weakMagnitude = magnitude
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, dev.file.Fd(), eviocsff, uintptr(unsafe.Pointer(&effect))); errno != 0 {
+		dev.file.Close()
+		dev.file = nil
+		return fmt.Errorf("upload rumble effect: %w", errno)
+	}
+	dev.effectID = effect.id
+
+	play := inputEvent{typ: evFF, code: uint16(effect.id), value: 1}
+	if _, err := dev.file.Write((*[unsafe.Sizeof(play)]byte)(unsafe.Pointer(&play))[:]); err != nil {
+		dev.file.Close()
+		dev.file = nil
+		return fmt.Errorf("play rumble effect: %w", err)
+	}
+	return nil
+}
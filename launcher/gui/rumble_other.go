@@ -0,0 +1,13 @@
+//go:build !windows && !linux
+
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+// vibrate is unsupported here: the joystick library has no rumble on macOS
+func vibrate(index int, strength float64, d time.Duration) error {
+	return errors.New("rumble is not supported on this platform")
+}
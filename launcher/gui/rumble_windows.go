@@ -0,0 +1,72 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// XInput drives the rumble motors of Xbox-style pads. xinput1_4.dll ships
+// with Windows 8 and later; xinput9_1_0.dll is the fallback on Windows 7.
+var (
+	xinputOnce         sync.Once
+	procXInputSetState *syscall.LazyProc
+	vibrateMu          sync.Mutex
+	vibrateStop        *time.Timer
+)
+
+// xinputVibration is XINPUT_VIBRATION
+type xinputVibration struct {
+	leftMotorSpeed  uint16
+	rightMotorSpeed uint16
+}
+
+func loadXInput() *syscall.LazyProc {
+	xinputOnce.Do(func() {
+		for _, dll := range []string{"xinput1_4.dll", "xinput9_1_0.dll"} {
+			proc := syscall.NewLazyDLL(dll).NewProc("XInputSetState")
+			if proc.Find() == nil {
+				procXInputSetState = proc
+				return
+			}
+		}
+	})
+	return procXInputSetState
+}
+
+func setXInputState(index int, speed uint16) error {
+	vibration := xinputVibration{leftMotorSpeed: speed, rightMotorSpeed: speed}
+	ret, _, _ := procXInputSetState.Call(uintptr(index), uintptr(unsafe.Pointer(&vibration)))
+	if ret != 0 {
+		return fmt.Errorf("XInputSetState(%d): %w", index, syscall.Errno(ret))
+	}
+	return nil
+}
+
+// vibrate runs both motors of XInput pad index at strength for d. The
+// joystick index is taken as the XInput slot, which matches for the first
+// pad and usually for the others.
+func vibrate(index int, strength float64, d time.Duration) error {
+	if loadXInput() == nil {
+		return fmt.Errorf("XInput is not available")
+	}
+	vibrateMu.Lock()
+	defer vibrateMu.Unlock()
+	if err := setXInputState(index, uint16(strength*0xFFFF)); err != nil {
+		return err
+	}
+	// XInput keeps the motors running until told otherwise
+	if vibrateStop != nil {
+		vibrateStop.Stop()
+	}
+	vibrateStop = time.AfterFunc(d, func() {
+		vibrateMu.Lock()
+		defer vibrateMu.Unlock()
+		setXInputState(index, 0)
+	})
+	return nil
+}
@@ -0,0 +1,473 @@
+package main
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Save data (RetroArch saves and states, PCSX2 memory cards and states,
+// Dolphin's GameCube/Wii saves) is backed up into a timestamped zip in
+// Backups/ and restored from one. The folders are found from where each
+// emulator is installed, the same way it's launched, so the portable installs
+// from the installer are covered. In the zip each folder is stored under
+// "<emulator id>/<folder name>/", and restored to wherever that folder is on
+// the machine doing the restoring.
+
+const (
+	backupsDirName = "Backups"
+	backupPrefix   = "saves-"
+	backupTimeFmt  = "20060102-150405"
+)
+
+// saveFolder is one folder of an emulator's save data on this machine
+type saveFolder struct {
+	Emulator string // emulator ID from systems.json
+	Name     string // e.g. "saves", "memcards"
+	Dir      string
+}
+
+// zipPrefix is where the folder's files go in a backup
+func (f saveFolder) zipPrefix() string {
+	return f.Emulator + "/" + f.Name + "/"
+}
+
+func backupsDir() string {
+	return filepath.Join(baseDir, backupsDirName)
+}
+
+// saveFolders returns the save folders of the installed emulators that have
+// save data the launcher knows about
+func saveFolders() []saveFolder {
+	var folders []saveFolder
+	add := func(id string, dirs func(emulatorTarget) map[string]string) {
+		def, ok := emulatorDefs[id]
+		if !ok || emulatorIDs[def.keyPath()] != id {
+			return // no system uses it
+		}
+		for name, dir := range dirs(resolveEmulator(def.keyPath())) {
+			folders = append(folders, saveFolder{Emulator: id, Name: name, Dir: dir})
+		}
+	}
+	add("retroarch", func(t emulatorTarget) map[string]string {
+		return map[string]string{
+			"saves":  retroArchDir(t, "savefile_directory", "saves"),
+			"states": retroArchDir(t, "savestate_directory", "states"),
+		}
+	})
+	add("pcsx2", func(t emulatorTarget) map[string]string {
+		dir := pcsx2UserDir(t)
+		return map[string]string{
+			"memcards": filepath.Join(dir, "memcards"),
+			"sstates":  filepath.Join(dir, "sstates"),
+		}
+	})
+	add("dolphin", func(t emulatorTarget) map[string]string {
+		dir := dolphinUserDir(t)
+		return map[string]string{
+			"GC":         filepath.Join(dir, "GC"),
+			"Wii":        filepath.Join(dir, "Wii"),
+			"StateSaves": filepath.Join(dir, "StateSaves"),
+		}
+	})
+	sort.Slice(folders, func(i, j int) bool { return folders[i].zipPrefix() < folders[j].zipPrefix() })
+	return folders
+}
+
+// pcsx2UserDir is PCSX2's data folder: its own folder when installed portable
+// (portable.txt, as the installer does), otherwise the per-user one
+func pcsx2UserDir(t emulatorTarget) string {
+	folder := emulatorFolder(t.Dir)
+	if fileExists(filepath.Join(folder, "portable.txt")) {
+		return folder
+	}
+	home, _ := os.UserHomeDir()
+	switch runtime.GOOS {
+	case "windows":
+		return filepath.Join(home, "Documents", "PCSX2")
+	case "darwin":
+		return filepath.Join(home, "Library", "Application Support", "PCSX2")
+	}
+	if t.Style == launchFlatpak {
+		return filepath.Join(home, ".var", "app", t.FlatpakID, "config", "PCSX2")
+	}
+	return filepath.Join(home, ".config", "PCSX2")
+}
+
+// dolphinUserDir is Dolphin's User folder: next to it when installed
+// portable, otherwise the per-user one
+func dolphinUserDir(t emulatorTarget) string {
+	home, _ := os.UserHomeDir()
+	if t.Style == launchFlatpak {
+		return filepath.Join(home, ".var", "app", t.FlatpakID, "data", "dolphin-emu")
+	}
+	if fileExists(filepath.Join(t.Dir, "portable.txt")) {
+		return filepath.Join(t.Dir, "User")
+	}
+	switch runtime.GOOS {
+	case "windows":
+		return filepath.Join(home, "Documents", "Dolphin Emulator")
+	case "darwin":
+		return filepath.Join(home, "Library", "Application Support", "Dolphin")
+	}
+	if xdg := os.Getenv("XDG_DATA_HOME"); xdg != "" {
+		return filepath.Join(xdg, "dolphin-emu")
+	}
+	return filepath.Join(home, ".local", "share", "dolphin-emu")
+}
+
+// backupSaves zips every save folder into a new backup in Backups/, and
+// returns its path and how many files it holds. progress, if set, is called
+// with each file's name.
+func backupSaves(progress func(name string)) (string, int, error) {
+	if err := os.MkdirAll(backupsDir(), 0755); err != nil {
+		return "", 0, err
+	}
+	// Never overwrite a backup, e.g. the one being restored when a restore
+	// backs up the current saves within the same second
+	stamp := backupPrefix + time.Now().Format(backupTimeFmt)
+	dest := filepath.Join(backupsDir(), stamp+".zip")
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	for n := 2; errors.Is(err, fs.ErrExist); n++ {
+		dest = filepath.Join(backupsDir(), fmt.Sprintf("%s-%d.zip", stamp, n))
+		out, err = os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	zw := zip.NewWriter(out)
+
+	count := 0
+	for _, folder := range saveFolders() {
+		err := filepath.WalkDir(folder.Dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if p == folder.Dir && errors.Is(err, fs.ErrNotExist) {
+					return filepath.SkipDir // emulator never saved anything
+				}
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			rel, err := filepath.Rel(folder.Dir, p)
+			if err != nil {
+				return err
+			}
+			if progress != nil {
+				progress(rel)
+			}
+			if err := addFileToZip(zw, p, folder.zipPrefix()+filepath.ToSlash(rel)); err != nil {
+				return err
+			}
+			count++
+			return nil
+		})
+		if err != nil {
+			zw.Close()
+			out.Close()
+			os.Remove(dest)
+			return "", 0, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		out.Close()
+		os.Remove(dest)
+		return "", 0, err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dest)
+		return "", 0, err
+	}
+	logInfo("Backed up %d save files to %s", count, dest)
+	return dest, count, nil
+}
+
+func addFileToZip(zw *zip.Writer, src, name string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+	w, err := zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// restoreSaves unpacks a backup into this machine's save folders, replacing
+// files with the same name. Entries for emulators or folders it doesn't know
+// are skipped. It returns how many files were restored.
+func restoreSaves(backup string, progress func(name string)) (int, error) {
+	zr, err := zip.OpenReader(backup)
+	if err != nil {
+		return 0, err
+	}
+	defer zr.Close()
+
+	folders := make(map[string]string) // zip prefix -> folder
+	for _, folder := range saveFolders() {
+		folders[folder.zipPrefix()] = folder.Dir
+	}
+
+	count := 0
+	for _, entry := range zr.File {
+		if entry.FileInfo().IsDir() {
+			continue
+		}
+		parts := strings.SplitN(entry.Name, "/", 3)
+		if len(parts) < 3 {
+			continue
+		}
+		dir, ok := folders[parts[0]+"/"+parts[1]+"/"]
+		rel := path.Clean(parts[2])
+		if !ok || rel == ".." || strings.HasPrefix(rel, "../") || path.IsAbs(rel) {
+			logWarn("Skipping %s from %s", entry.Name, backup)
+			continue
+		}
+		if progress != nil {
+			progress(rel)
+		}
+		if err := extractZipEntry(entry, filepath.Join(dir, filepath.FromSlash(rel))); err != nil {
+			return count, err
+		}
+		count++
+	}
+	logInfo("Restored %d save files from %s", count, backup)
+	return count, nil
+}
+
+func extractZipEntry(entry *zip.File, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	r, err := entry.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	os.Chtimes(dest, entry.Modified, entry.Modified)
+	return nil
+}
+
+// listBackups returns the backups in Backups/, newest first
+func listBackups() []string {
+	entries, err := os.ReadDir(backupsDir())
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), backupPrefix) && strings.HasSuffix(entry.Name(), ".zip") {
+			names = append(names, entry.Name())
+		}
+	}
+	// The timestamp in the name sorts by date
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	return names
+}
+
+// backupTime reads the time from a backup's file name
+func backupTime(name string) (time.Time, bool) {
+	stamp := strings.TrimSuffix(strings.TrimPrefix(name, backupPrefix), ".zip")
+	if len(stamp) > len(backupTimeFmt) {
+		stamp = stamp[:len(backupTimeFmt)] // "-2" for a second one that second
+	}
+	t, err := time.ParseInLocation(backupTimeFmt, stamp, time.Local)
+	return t, err == nil
+}
+
+// lastBackupText describes the newest backup, for the Saves dialog
+func lastBackupText() string {
+	backups := listBackups()
+	if len(backups) == 0 {
+		return "Last backup: never"
+	}
+	if t, ok := backupTime(backups[0]); ok {
+		return "Last backup: " + t.Format("2006-01-02 15:04")
+	}
+	return "Last backup: " + backups[0]
+}
+
+// showSavesDialog offers backing up save data and restoring a backup
+func (a *App) showSavesDialog() {
+	lastLabel := widget.NewLabel(lastBackupText())
+
+	var folderLines []string
+	for _, folder := range saveFolders() {
+		if fileExists(folder.Dir) {
+			folderLines = append(folderLines, folder.Dir)
+		}
+	}
+	foldersText := "No save folders found yet"
+	if len(folderLines) > 0 {
+		foldersText = "Backs up:\n" + strings.Join(folderLines, "\n")
+	}
+	foldersLabel := widget.NewLabel(foldersText)
+
+	backupSel := widget.NewSelect(listBackups(), nil)
+	backupSel.PlaceHolder = "Choose a backup to restore"
+
+	var d dialog.Dialog
+	backupBtn := widget.NewButton("Back up now", func() {
+		d.Hide()
+		a.runSaveTask("Backing up saves", func(progress func(string)) (string, error) {
+			dest, count, err := backupSaves(progress)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Backed up %d save files to %s", count, filepath.Base(dest)), nil
+		})
+	})
+	restoreBtn := widget.NewButton("Restore...", func() {
+		name := backupSel.Selected
+		if name == "" {
+			return
+		}
+		d.Hide()
+		a.confirmRestoreSaves(filepath.Join(backupsDir(), name))
+	})
+	restoreBtn.Disable()
+	backupSel.OnChanged = func(string) { restoreBtn.Enable() }
+
+	content := container.NewVBox(
+		lastLabel,
+		foldersLabel,
+		backupBtn,
+		widget.NewSeparator(),
+		container.NewBorder(nil, nil, nil, restoreBtn, backupSel),
+	)
+	a.dialogOpen = true
+	d = dialog.NewCustom("Save Data", "Close", content, a.window)
+	d.SetOnClosed(func() {
+		a.dialogOpen = false
+	})
+	d.Resize(fyne.NewSize(560, 0))
+	d.Show()
+}
+
+// confirmRestoreSaves asks before a backup overwrites the current saves. The
+// current saves are backed up first, so a restore can be undone.
+func (a *App) confirmRestoreSaves(backup string) {
+	message := fmt.Sprintf("Restore saves from %s?\n\nSave files with the same names are replaced. "+
+		"Your current saves are backed up first.", filepath.Base(backup))
+	a.dialogOpen = true
+	confirm := dialog.NewConfirm("Restore Saves", message, func(ok bool) {
+		a.dialogOpen = false
+		if !ok {
+			return
+		}
+		a.runSaveTask("Restoring saves", func(progress func(string)) (string, error) {
+			if _, _, err := backupSaves(nil); err != nil {
+				return "", fmt.Errorf("backing up current saves: %w", err)
+			}
+			count, err := restoreSaves(backup, progress)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Restored %d save files from %s", count, filepath.Base(backup)), nil
+		})
+	}, a.window)
+	confirm.SetConfirmText("Restore")
+	confirm.Show()
+}
+
+// runSaveTask runs a backup or restore off the UI goroutine, showing the file
+// it's on in the status bar
+func (a *App) runSaveTask(what string, task func(progress func(string)) (string, error)) {
+	var throttle uiThrottle
+	a.statusBar.SetText(what + "...")
+	go func() {
+		result, err := task(func(name string) {
+			if throttle.ready(false) {
+				runOnEvents(func() { a.statusBar.SetText(fmt.Sprintf("%s: %s", what, name)) })
+			}
+		})
+		runOnEvents(func() {
+			if err != nil {
+				logError("%s: %v", what, err)
+				a.statusBar.SetText(fmt.Sprintf("%s failed: %v", what, err))
+				dialog.ShowError(err, a.window)
+				return
+			}
+			a.statusBar.SetText(result)
+		})
+	}()
+}
+
+// backupSavesHeadless backs up save data without the GUI.
+// Usage: --backup-saves
+func backupSavesHeadless() {
+	dest, count, err := backupSaves(nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "Backed up %d save files\n", count)
+	fmt.Println(dest)
+}
+
+// restoreSavesHeadless restores a backup without the GUI; with no backup
+// given it lists them.
+// Usage: --restore-saves <backup.zip>
+func restoreSavesHeadless(backup string) {
+	if backup == "" {
+		fmt.Fprintf(os.Stderr, "Usage: %s --restore-saves <backup.zip>\n", os.Args[0])
+		if backups := listBackups(); len(backups) > 0 {
+			fmt.Fprintln(os.Stderr, "Backups in "+backupsDir()+":")
+			for _, name := range backups {
+				fmt.Fprintln(os.Stderr, "  "+name)
+			}
+		}
+		os.Exit(1)
+	}
+	if !fileExists(backup) && fileExists(filepath.Join(backupsDir(), backup)) {
+		backup = filepath.Join(backupsDir(), backup)
+	}
+	if _, _, err := backupSaves(nil); err != nil {
+		fmt.Fprintf(os.Stderr, "Error backing up current saves: %v\n", err)
+		os.Exit(1)
+	}
+	count, err := restoreSaves(backup, nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "Restored %d save files\n", count)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+// Right-stick scrolling. A light push moves one game at a time; pushing
+// further repeats faster, and holding it skips more games per repeat, so one
+// game can be picked out precisely and a 20,000 game set still flies past.
+const (
+	defaultStickDeadzone      = 10000 // of 32767
+	maxStickDeadzone          = 30000
+	defaultStickMaxScrollStep = 50
+	maxStickMaxScrollStep     = 1000
+	defaultStickAcceleration  = 1.0
+	minStickAcceleration      = 0.1
+	maxStickAcceleration      = 10.0
+
+	stickAxisMax = 32767
+	// stickInitialDelay is the pause after the first move, so a nudge moves
+	// exactly one game
+	stickInitialDelay = 300 * time.Millisecond
+	// stickSlowRepeat and stickFastRepeat are the repeat delays just past the
+	// deadzone and at full deflection
+	stickSlowRepeat = 200 * time.Millisecond
+	stickFastRepeat = 40 * time.Millisecond
+	// stickRampTime is how long the stick is held (at acceleration 1) before
+	// a repeat reaches the full step
+	stickRampTime = 3 * time.Second
+	// stickListFraction limits a step to this fraction of the list, so short
+	// lists aren't overshot
+	stickListFraction = 20
+)
+
+// stickDeadzone returns how far a stick must move before it counts
+func stickDeadzone() int {
+	if settings.StickDeadzone > 0 {
+		return settings.StickDeadzone
+	}
+	return defaultStickDeadzone
+}
+
+func stickMaxScrollStep() int {
+	if settings.StickMaxScrollStep > 0 {
+		return settings.StickMaxScrollStep
+	}
+	return defaultStickMaxScrollStep
+}
+
+func stickAcceleration() float64 {
+	if settings.StickAcceleration > 0 {
+		return settings.StickAcceleration
+	}
+	return defaultStickAcceleration
+}
+
+// stickDeflection returns how far past the deadzone an axis is pushed, from
+// 0 (inside it) to 1 (all the way)
+func stickDeflection(axis, deadzone int) float64 {
+	if axis < 0 {
+		axis = -axis
+	}
+	if axis <= deadzone {
+		return 0
+	}
+	return math.Min(1, float64(axis-deadzone)/float64(stickAxisMax-deadzone))
+}
+
+// stickScroll returns how many games to move and how long to wait before the
+// next move, for a stick pushed deflection (0-1) and held for held. The
+// delay shrinks with deflection; the step grows with deflection and hold
+// time up to the max step, and never passes a twentieth of the list.
+func stickScroll(deflection float64, held time.Duration, listLen int) (int, time.Duration) {
+	if held < stickInitialDelay {
+		return 1, stickInitialDelay
+	}
+	delay := stickSlowRepeat - time.Duration(deflection*float64(stickSlowRepeat-stickFastRepeat))
+
+	ramp := math.Min(1, (held-stickInitialDelay).Seconds()*stickAcceleration()/stickRampTime.Seconds())
+	step := 1 + int(math.Round(float64(stickMaxScrollStep()-1)*deflection*deflection*ramp))
+	if limit := listLen / stickListFraction; step > limit {
+		step = limit
+	}
+	if step < 1 {
+		step = 1
+	}
+	return step, delay
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Search match ranks, best first
+const (
+	rankPrefix    = iota // name starts with the query
+	rankSubstring        // query appears as typed
+	rankTokens           // every query word appears, in any order
+	rankFuzzy            // subsequence ("smb3") or one typo per word
+)
+
+// minFuzzyLength keeps very short queries from matching nearly everything
+const minFuzzyLength = 3
+
+// searchQuery is a lower-cased search split into words
+type searchQuery struct {
+	text    string
+	tokens  []string
+	compact string // text without separators, for subsequence matching
+}
+
+func isSearchSeparator(r rune) bool {
+	return unicode.IsSpace(r) || r == ',' || r == '-' || r == '_' || r == '.' || r == ':'
+}
+
+func newSearchQuery(query string) searchQuery {
+	text := strings.TrimSpace(strings.ToLower(query))
+	tokens := strings.FieldsFunc(text, isSearchSeparator)
+	return searchQuery{text: text, tokens: tokens, compact: strings.Join(tokens, "")}
+}
+
+func (q searchQuery) empty() bool {
+	return len(q.tokens) == 0
+}
+
+// match ranks a lower-cased name against the query without fuzzy matching.
+// Returns -1 if it doesn't match.
+func (q searchQuery) match(name string) int {
+	switch {
+	case strings.HasPrefix(name, q.text):
+		return rankPrefix
+	case strings.Contains(name, q.text):
+		return rankSubstring
+	}
+	for _, token := range q.tokens {
+		if !strings.Contains(name, token) {
+			return -1
+		}
+	}
+	return rankTokens
+}
+
+// fuzzyMatch is the fallback used when nothing matches exactly: the query's
+// letters in order ("smb3" -> "Super Mario Bros. 3"), or every word found
+// allowing one typo in words of four letters or more
+func (q searchQuery) fuzzyMatch(name string) bool {
+	if len(q.compact) < minFuzzyLength {
+		return false
+	}
+	if isSubsequence(q.compact, name) {
+		return true
+	}
+
+	words := strings.FieldsFunc(name, isSearchSeparator)
+	for _, token := range q.tokens {
+		if strings.Contains(name, token) {
+			continue
+		}
+		if len(token) < 4 {
+			return false
+		}
+		found := false
+		for _, word := range words {
+			if withinOneEdit(token, word) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func isSubsequence(needle, haystack string) bool {
+	i := 0
+	for j := 0; i < len(needle) && j < len(haystack); j++ {
+		if needle[i] == haystack[j] {
+			i++
+		}
+	}
+	return i == len(needle)
+}
+
+// withinOneEdit reports whether a and b differ by at most one insertion,
+// deletion or substitution
+func withinOneEdit(a, b string) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	if len(b)-len(a) > 1 {
+		return false
+	}
+	i, j, edits := 0, 0, 0
+	for i < len(a) && j < len(b) {
+		if a[i] == b[j] {
+			i++
+			j++
+			continue
+		}
+		edits++
+		if edits > 1 {
+			return false
+		}
+		if len(a) == len(b) {
+			i++
+		}
+		j++
+	}
+	return edits+(len(b)-j)+(len(a)-i) <= 1
+}
+
+// rankResults moves better matches to the top. The sort is stable, so games
+// of equal rank keep the order chosen by the sort setting.
+func rankResults(games []ROM, ranks map[string]int) {
+	sort.SliceStable(games, func(i, j int) bool {
+		return ranks[games[i].Name] < ranks[games[j].Name]
+	})
+}
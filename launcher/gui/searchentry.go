@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+)
+
+// SearchEntry is the search box. While it has focus the window's shortcuts
+// are off, so typing "d" or "f" or pressing Enter never reaches the game list;
+// Escape, Enter and Down hand the keyboard back to the list instead.
+type SearchEntry struct {
+	widget.Entry
+	onLeave func()
+}
+
+func NewSearchEntry(onLeave func()) *SearchEntry {
+	e := &SearchEntry{onLeave: onLeave}
+	e.ExtendBaseWidget(e)
+	return e
+}
+
+func (e *SearchEntry) TypedKey(key *fyne.KeyEvent) {
+	switch key.Name {
+	case fyne.KeyEscape, fyne.KeyReturn, fyne.KeyEnter, fyne.KeyDown:
+		if e.onLeave != nil {
+			e.onLeave()
+		}
+		return
+	}
+	e.Entry.TypedKey(key)
+}
+
+// searchFocused reports whether the search box has the keyboard
+func (a *App) searchFocused() bool {
+	return a.window.Canvas().Focused() == a.searchEntry
+}
+
+// focusSearch gives the keyboard to the search box (the / key)
+func (a *App) focusSearch() {
+	if a.choosingEmulator {
+		return
+	}
+	a.window.Canvas().Focus(a.searchEntry)
+}
+
+// leaveSearch takes the keyboard from the search box back to the game list,
+// on the first result if nothing is selected. A search still waiting out its
+// debounce is applied first, so the list matches what was typed.
+func (a *App) leaveSearch() {
+	a.window.Canvas().Unfocus()
+	if a.searchTimer != nil {
+		a.searchTimer.Stop()
+		a.searchTimer = nil // drops its filter if it has already fired
+		a.filterGames()
+	}
+	a.focusOnGames = true
+	if _, ok := a.selectedGame(); !ok && len(a.shownGames()) > 0 {
+		a.selectGame(0)
+	}
+	a.systemList.Refresh()
+	a.refreshGameView()
+}
@@ -0,0 +1,389 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/emubuddy/download"
+)
+
+// Values of settings.Theme
+const (
+	themeDark   = "dark"
+	themeLight  = "light"
+	themeSystem = "system" // follow the OS light/dark preference
+)
+
+// Labels for the choice-style settings in the settings dialog
+const (
+	themeDarkLabel   = "Dark"
+	themeLightLabel  = "Light"
+	themeSystemLabel = "System"
+
+	emulatorUseDefaultLabel = "Use saved default"
+	emulatorAlwaysAskLabel  = "Always ask"
+
+	layoutXboxLabel     = "Xbox (A confirms, B goes back)"
+	layoutNintendoLabel = "Nintendo (B confirms, A goes back)"
+
+	inputAlwaysLabel  = "Always"
+	inputFocusedLabel = "Only when EmuBuddy has focus"
+
+	revisionsAllLabel     = "List every dump"
+	revisionsLatestLabel  = "One row per game, latest revision"
+	revisionsReleaseLabel = "One row per game, releases before betas"
+)
+
+// bytesPerMB converts the speed limit between MB/s in the dialog and bytes/s in settings.json
+const bytesPerMB = 1000 * 1000
+
+// revisionLabels maps settings.CollapseRevisions values to their names in the settings dialog
+var revisionLabels = map[string]string{
+	"":              revisionsAllLabel,
+	collapseLatest:  revisionsLatestLabel,
+	collapseRelease: revisionsReleaseLabel,
+}
+
+// themeLabels maps settings.Theme values to their names in the settings dialog
+var themeLabels = map[string]string{
+	"":          themeDarkLabel,
+	themeDark:   themeDarkLabel,
+	themeLight:  themeLightLabel,
+	themeSystem: themeSystemLabel,
+}
+
+// applyTheme switches the app to the theme chosen in settings. Fyne's default
+// theme follows the OS appearance on Windows and macOS (and FYNE_THEME
+// elsewhere), and updates itself when that changes.
+func applyTheme(fyneApp fyne.App) {
+	switch settings.Theme {
+	case themeLight:
+		fyneApp.Settings().SetTheme(theme.LightTheme())
+	case themeSystem:
+		fyneApp.Settings().SetTheme(theme.DefaultTheme())
+	default:
+		fyneApp.Settings().SetTheme(theme.DarkTheme())
+	}
+}
+
+// watchTheme recolours the text drawn with canvas.Text, which keeps the
+// colour it was created with, whenever the theme or OS appearance changes
+func (a *App) watchTheme(title *canvas.Text) {
+	changes := make(chan fyne.Settings)
+	fyne.CurrentApp().Settings().AddChangeListener(changes)
+	go func() {
+		for range changes {
+			runOnEvents(func() {
+				title.Color = theme.ForegroundColor()
+				title.Refresh()
+				a.refreshGameView()
+			})
+		}
+	}()
+}
+
+// validateSettings resets values from settings.json that are out of range,
+// returning a description of each one
+func validateSettings() []string {
+	var problems []string
+	if settings.DownloadWorkers < 0 || settings.DownloadWorkers > maxDownloadWorkers {
+		problems = append(problems, fmt.Sprintf("downloadWorkers %d is not between 1 and %d, using the default", settings.DownloadWorkers, maxDownloadWorkers))
+		settings.DownloadWorkers = 0
+	}
+	if settings.DownloadConcurrency < 0 || settings.DownloadConcurrency > maxDownloadConcurrency {
+		problems = append(problems, fmt.Sprintf("downloadConcurrency %d is not between 1 and %d, using the default", settings.DownloadConcurrency, maxDownloadConcurrency))
+		settings.DownloadConcurrency = 0
+	}
+	if settings.MaxDownloadBytesPerSec < 0 {
+		problems = append(problems, fmt.Sprintf("maxDownloadBytesPerSec %d is negative, downloads are unlimited", settings.MaxDownloadBytesPerSec))
+		settings.MaxDownloadBytesPerSec = 0
+	}
+	if settings.StickDeadzone < 0 || settings.StickDeadzone > maxStickDeadzone {
+		problems = append(problems, fmt.Sprintf("stickDeadzone %d is not between 1 and %d, using the default", settings.StickDeadzone, maxStickDeadzone))
+		settings.StickDeadzone = 0
+	}
+	if settings.StickMaxScrollStep < 0 || settings.StickMaxScrollStep > maxStickMaxScrollStep {
+		problems = append(problems, fmt.Sprintf("stickMaxScrollStep %d is not between 1 and %d, using the default", settings.StickMaxScrollStep, maxStickMaxScrollStep))
+		settings.StickMaxScrollStep = 0
+	}
+	if settings.StickAcceleration != 0 && (settings.StickAcceleration < minStickAcceleration || settings.StickAcceleration > maxStickAcceleration) {
+		problems = append(problems, fmt.Sprintf("stickAcceleration %g is not between %g and %g, using the default", settings.StickAcceleration, minStickAcceleration, maxStickAcceleration))
+		settings.StickAcceleration = 0
+	}
+	if settings.RomFolderDepth < 0 || settings.RomFolderDepth > maxRomFolderDepth {
+		problems = append(problems, fmt.Sprintf("romFolderDepth %d is not between 1 and %d, using the default", settings.RomFolderDepth, maxRomFolderDepth))
+		settings.RomFolderDepth = 0
+	}
+	if settings.DoubleClickMs != 0 && (settings.DoubleClickMs < minDoubleClickMs || settings.DoubleClickMs > maxDoubleClickMs) {
+		problems = append(problems, fmt.Sprintf("doubleClickMs %d is not between %d and %d, using the default", settings.DoubleClickMs, minDoubleClickMs, maxDoubleClickMs))
+		settings.DoubleClickMs = 0
+	}
+	if settings.HookTimeoutSeconds < 0 {
+		problems = append(problems, fmt.Sprintf("hookTimeoutSeconds %d is negative, using the default", settings.HookTimeoutSeconds))
+		settings.HookTimeoutSeconds = 0
+	}
+	for host, headers := range settings.HostHeaders {
+		for name, value := range headers {
+			if !validHeader(name, value) {
+				problems = append(problems, fmt.Sprintf("hostHeaders %s: %q is not a valid header, ignoring it", host, name))
+				delete(headers, name)
+			}
+		}
+	}
+	if settings.Proxy != "" {
+		if _, err := download.ParseProxy(settings.Proxy); err != nil {
+			problems = append(problems, fmt.Sprintf("proxy: %v, using the environment's proxy settings", err))
+			settings.Proxy = ""
+		}
+	}
+	if settings.UpdateManifestURL != "" {
+		if u, err := url.Parse(settings.UpdateManifestURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			problems = append(problems, fmt.Sprintf("updateManifestURL %q is not an http(s) URL, ignoring it", settings.UpdateManifestURL))
+			settings.UpdateManifestURL = ""
+		}
+	}
+	if _, ok := logLevelNames[strings.ToLower(settings.LogLevel)]; !ok && settings.LogLevel != "" {
+		problems = append(problems, fmt.Sprintf("unknown logLevel %q, using info", settings.LogLevel))
+		settings.LogLevel = ""
+	}
+	switch settings.Theme {
+	case "", themeDark, themeLight, themeSystem:
+	default:
+		problems = append(problems, fmt.Sprintf("unknown theme %q, using dark", settings.Theme))
+		settings.Theme = ""
+	}
+	if _, ok := revisionLabels[settings.CollapseRevisions]; !ok {
+		problems = append(problems, fmt.Sprintf("unknown collapseRevisions %q, listing every dump", settings.CollapseRevisions))
+		settings.CollapseRevisions = ""
+	}
+	return problems
+}
+
+// parseSpeedLimit reads the MB/s entry; empty or 0 means unlimited
+func parseSpeedLimit(text string) (int64, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return 0, nil
+	}
+	mb, err := strconv.ParseFloat(text, 64)
+	if err != nil || mb < 0 || math.IsNaN(mb) || math.IsInf(mb, 0) {
+		return 0, fmt.Errorf("speed limit must be a number of MB/s, or 0 for unlimited")
+	}
+	return int64(mb * bytesPerMB), nil
+}
+
+// formatSpeedLimit shows a bytes/s limit as MB/s for the dialog
+func formatSpeedLimit(bytesPerSec int64) string {
+	if bytesPerSec <= 0 {
+		return "0"
+	}
+	return strconv.FormatFloat(float64(bytesPerSec)/bytesPerMB, 'f', -1, 64)
+}
+
+// showSettingsDialog edits the common settings.json options. Changes are
+// validated and saved when the user presses Save, and applied right away.
+func (a *App) showSettingsDialog() {
+	workerOptions := make([]string, maxDownloadWorkers)
+	for i := range workerOptions {
+		workerOptions[i] = strconv.Itoa(i + 1)
+	}
+	workersSel := widget.NewSelect(workerOptions, nil)
+	workers := settings.DownloadWorkers
+	if workers <= 0 {
+		workers = defaultDownloadWorkers
+	}
+	workersSel.SetSelected(strconv.Itoa(workers))
+
+	concurrencyOptions := make([]string, maxDownloadConcurrency)
+	for i := range concurrencyOptions {
+		concurrencyOptions[i] = strconv.Itoa(i + 1)
+	}
+	concurrencySel := widget.NewSelect(concurrencyOptions, nil)
+	concurrencySel.SetSelected(strconv.Itoa(downloadConcurrency()))
+
+	speedEntry := widget.NewEntry()
+	speedEntry.SetText(formatSpeedLimit(settings.MaxDownloadBytesPerSec))
+
+	romsEntry := widget.NewEntry()
+	romsEntry.SetPlaceHolder("roms (next to EmuBuddy)")
+	romsEntry.SetText(settings.RomsDir)
+	romsBrowse := widget.NewButton("Browse...", func() {
+		dialog.ShowFolderOpen(func(dir fyne.ListableURI, err error) {
+			if err == nil && dir != nil {
+				romsEntry.SetText(dir.Path())
+			}
+		}, a.window)
+	})
+
+	themeSel := widget.NewSelect([]string{themeDarkLabel, themeLightLabel, themeSystemLabel}, nil)
+	themeSel.SetSelected(themeLabels[settings.Theme])
+
+	boxArtCheck := widget.NewCheck("Show box art thumbnails", nil)
+	boxArtCheck.SetChecked(!settings.HideBoxArt)
+
+	revisionsSel := widget.NewSelect([]string{revisionsAllLabel, revisionsLatestLabel, revisionsReleaseLabel}, nil)
+	revisionsSel.SetSelected(revisionLabels[settings.CollapseRevisions])
+
+	emulatorSel := widget.NewSelect([]string{emulatorUseDefaultLabel, emulatorAlwaysAskLabel}, nil)
+	emulatorSel.SetSelected(emulatorUseDefaultLabel)
+	if settings.AlwaysAskEmulator {
+		emulatorSel.SetSelected(emulatorAlwaysAskLabel)
+	}
+	clearDefaults := widget.NewButton("Clear saved defaults", nil)
+	clearDefaults.OnTapped = func() {
+		clearEmulatorDefaults()
+		saveSettings()
+		clearDefaults.SetText("Defaults cleared")
+		clearDefaults.Disable()
+	}
+	if !hasEmulatorDefaults() {
+		clearDefaults.Disable()
+	}
+
+	layoutSel := widget.NewSelect([]string{layoutXboxLabel, layoutNintendoLabel}, nil)
+	layoutSel.SetSelected(layoutXboxLabel)
+	if settings.SwapABButtons {
+		layoutSel.SetSelected(layoutNintendoLabel)
+	}
+
+	doubleClickEntry := widget.NewEntry()
+	doubleClickEntry.SetText(strconv.Itoa(int(doubleClickInterval() / time.Millisecond)))
+
+	rumbleCheck := widget.NewCheck("Rumble on launch, favorite and list ends", nil)
+	rumbleCheck.SetChecked(settings.Rumble)
+
+	chdCheck := widget.NewCheck("Compress disc images to CHD after downloading", nil)
+	chdCheck.SetChecked(settings.CompressToCHD)
+
+	inputSel := widget.NewSelect([]string{inputAlwaysLabel, inputFocusedLabel}, nil)
+	inputSel.SetSelected(inputAlwaysLabel)
+	if controllerNeedsFocus() {
+		inputSel.SetSelected(inputFocusedLabel)
+	}
+
+	form := widget.NewForm(
+		widget.NewFormItem("Connections per download", workersSel),
+		widget.NewFormItem("Downloads at once", concurrencySel),
+		&widget.FormItem{Text: "Speed limit (MB/s)", Widget: speedEntry, HintText: "0 for unlimited"},
+		widget.NewFormItem("ROMs folder", container.NewBorder(nil, nil, nil, romsBrowse, romsEntry)),
+		widget.NewFormItem("Theme", themeSel),
+		widget.NewFormItem("", boxArtCheck),
+		widget.NewFormItem("Revisions", revisionsSel),
+		widget.NewFormItem("", chdCheck),
+		widget.NewFormItem("Emulator choice", container.NewHBox(emulatorSel, clearDefaults)),
+		&widget.FormItem{Text: "Double-click (ms)", Widget: doubleClickEntry, HintText: fmt.Sprintf("%d-%d, default %d", minDoubleClickMs, maxDoubleClickMs, defaultDoubleClickMs)},
+		widget.NewFormItem("Controller layout", layoutSel),
+		widget.NewFormItem("Controller input", inputSel),
+		widget.NewFormItem("", rumbleCheck),
+	)
+
+	a.dialogOpen = true
+	d := dialog.NewCustomConfirm("Settings", "Save", "Cancel", form, func(ok bool) {
+		a.dialogOpen = false
+		if !ok {
+			return
+		}
+
+		speed, err := parseSpeedLimit(speedEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, a.window)
+			return
+		}
+		workers, err := strconv.Atoi(workersSel.Selected)
+		if err != nil || workers < 1 || workers > maxDownloadWorkers {
+			dialog.ShowError(fmt.Errorf("connections per download must be between 1 and %d", maxDownloadWorkers), a.window)
+			return
+		}
+		doubleClick, err := strconv.Atoi(strings.TrimSpace(doubleClickEntry.Text))
+		if err != nil || doubleClick < minDoubleClickMs || doubleClick > maxDoubleClickMs {
+			dialog.ShowError(fmt.Errorf("double-click must be between %d and %d ms", minDoubleClickMs, maxDoubleClickMs), a.window)
+			return
+		}
+		concurrency, err := strconv.Atoi(concurrencySel.Selected)
+		if err != nil || concurrency < 1 || concurrency > maxDownloadConcurrency {
+			dialog.ShowError(fmt.Errorf("downloads at once must be between 1 and %d", maxDownloadConcurrency), a.window)
+			return
+		}
+
+		// Only store values that differ from the defaults, so settings.json stays short
+		if workers == defaultDownloadWorkers {
+			workers = 0
+		}
+		if concurrency == defaultDownloadConcurrency {
+			concurrency = 0
+		}
+		if doubleClick == defaultDoubleClickMs {
+			doubleClick = 0
+		}
+		settings.DownloadWorkers = workers
+		settings.DownloadConcurrency = concurrency
+		settings.MaxDownloadBytesPerSec = speed
+		settings.DoubleClickMs = doubleClick
+		settings.HideBoxArt = !boxArtCheck.Checked
+		settings.AlwaysAskEmulator = emulatorSel.Selected == emulatorAlwaysAskLabel
+		settings.SwapABButtons = layoutSel.Selected == layoutNintendoLabel
+		settings.Rumble = rumbleCheck.Checked
+		settings.CompressToCHD = chdCheck.Checked
+		settings.ControllerInput = ""
+		if inputSel.Selected == inputFocusedLabel {
+			settings.ControllerInput = controllerInputFocused
+		}
+
+		themeChanged := themeLabels[settings.Theme] != themeSel.Selected
+		switch themeSel.Selected {
+		case themeLightLabel:
+			settings.Theme = themeLight
+		case themeSystemLabel:
+			settings.Theme = themeSystem
+		default:
+			settings.Theme = ""
+		}
+
+		revisionsChanged := revisionLabels[settings.CollapseRevisions] != revisionsSel.Selected
+		switch revisionsSel.Selected {
+		case revisionsLatestLabel:
+			settings.CollapseRevisions = collapseLatest
+		case revisionsReleaseLabel:
+			settings.CollapseRevisions = collapseRelease
+		default:
+			settings.CollapseRevisions = ""
+		}
+
+		romsChanged := strings.TrimSpace(romsEntry.Text) != settings.RomsDir
+		settings.RomsDir = strings.TrimSpace(romsEntry.Text)
+
+		saveSettings()
+
+		if themeChanged {
+			applyTheme(fyne.CurrentApp())
+		}
+		if romsChanged {
+			resolveDataDirs()
+			if len(dataDirWarnings) > 0 {
+				dialog.ShowError(fmt.Errorf("%s", strings.Join(dataDirWarnings, "\n")), a.window)
+			}
+			if a.currentSystem != "" {
+				a.selectSystem(a.currentSystem)
+			}
+			a.refreshLibraryUsage()
+		} else if revisionsChanged {
+			a.refilterKeepingSelection()
+		}
+		a.pumpQueue()
+		a.refreshGameView()
+		a.statusBar.SetText("Settings saved")
+	}, a.window)
+	d.Resize(fyne.NewSize(560, 480))
+	d.Show()
+}
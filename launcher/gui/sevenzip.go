@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errNo7Zip is returned when a .7z/.rar download needs extracting and no
+// suitable 7-Zip can be found
+var errNo7Zip = errors.New("7-Zip not found")
+
+// bundled7ZipPath is where the installer puts 7-Zip. Keep in sync with
+// get7ZipPath in installer/main.go.
+func bundled7ZipPath() string {
+	toolsDir := filepath.Join(baseDir, "Tools", "7zip")
+	switch runtime.GOOS {
+	case "windows":
+		return filepath.Join(toolsDir, "7za.exe")
+	case "linux", "darwin":
+		return filepath.Join(toolsDir, "7zz")
+	default:
+		return ""
+	}
+}
+
+// find7Zip returns a 7-Zip that can open the archive: the installer's copy,
+// then one on PATH. On Windows the installer ships 7zr, which only reads .7z,
+// so RAR needs a full 7-Zip install.
+func find7Zip(archivePath string) (string, error) {
+	rar := archiveExt(archivePath) == ".rar"
+
+	var candidates []string
+	if p := bundled7ZipPath(); p != "" && !(rar && runtime.GOOS == "windows") {
+		candidates = append(candidates, p)
+	}
+	if runtime.GOOS == "windows" {
+		for _, env := range []string{"ProgramFiles", "ProgramFiles(x86)"} {
+			if dir := os.Getenv(env); dir != "" {
+				candidates = append(candidates, filepath.Join(dir, "7-Zip", "7z.exe"))
+			}
+		}
+	}
+	for _, p := range candidates {
+		if fileExists(p) {
+			return p, nil
+		}
+	}
+	for _, name := range []string{"7zz", "7z", "7za"} {
+		if p, err := exec.LookPath(name); err == nil {
+			return p, nil
+		}
+	}
+
+	if rar && runtime.GOOS == "windows" {
+		return "", fmt.Errorf("%w: .rar files need 7-Zip from https://www.7-zip.org", errNo7Zip)
+	}
+	return "", fmt.Errorf("%w: run the EmuBuddy installer again, or install 7-Zip and make sure it's on your PATH", errNo7Zip)
+}
+
+// sevenZipEntry is one file or folder listed by "7z l -slt"
+type sevenZipEntry struct {
+	Path string
+	Size int64
+	Dir  bool
+	// Link is set for symbolic and hard links, which 7-Zip would create
+	// pointing wherever the archive says
+	Link bool
+}
+
+// errArchiveLink is returned for .7z and .rar archives holding links. 7-Zip
+// can't be told to skip them the way extractZip does, so the archive is
+// refused rather than trusting where they point.
+var errArchiveLink = errors.New("archive contains links")
+
+// list7Zip returns the files and folders in an archive
+func list7Zip(ctx context.Context, exe, archivePath string) ([]sevenZipEntry, error) {
+	out, err := exec.CommandContext(ctx, exe, "l", "-slt", archivePath).Output()
+	if err != nil {
+		return nil, fmt.Errorf("listing %s: %w", filepath.Base(archivePath), err)
+	}
+	return parse7ZipListing(out), nil
+}
+
+// parse7ZipListing reads the entries from the output of "7z l -slt"
+func parse7ZipListing(out []byte) []sevenZipEntry {
+	// The technical listing is "Key = Value" blocks separated by blank lines;
+	// entries start after the "----------" line that ends the archive's own block
+	var entries []sevenZipEntry
+	var cur sevenZipEntry
+	started := false
+	flush := func() {
+		if cur.Path != "" {
+			entries = append(entries, cur)
+		}
+		cur = sevenZipEntry{}
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if !started {
+			started = strings.HasPrefix(line, "----------")
+			continue
+		}
+		if line == "" {
+			flush()
+			continue
+		}
+		key, value, ok := strings.Cut(line, " = ")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "Path":
+			cur.Path = value
+		case "Size":
+			cur.Size, _ = strconv.ParseInt(value, 10, 64)
+		case "Folder":
+			cur.Dir = cur.Dir || value == "+"
+		case "Attributes":
+			// Windows attributes, then the Unix mode if the archive has one:
+			// "D_ drwxr-xr-x", "A_ -rw-r--r--", "_ lrwxrwxrwx"
+			cur.Dir = cur.Dir || strings.HasPrefix(value, "D")
+			for _, field := range strings.Fields(value) {
+				if len(field) == 10 && field[0] == 'l' {
+					cur.Link = true
+				}
+			}
+		case "Symbolic Link", "Hard Link", "Link":
+			cur.Link = cur.Link || value != ""
+		}
+	}
+	flush()
+	return entries
+}
+
+// extractWith7Zip extracts a .7z or .rar with 7-Zip, with the same contract
+// as extractZip: progress is estimated from the sizes of the files written
+// so far, and cancelling or failing removes them.
+func extractWith7Zip(ctx context.Context, archivePath, destDir string, progress func(done, total int64)) (string, error) {
+	exe, err := find7Zip(archivePath)
+	if err != nil {
+		return "", err
+	}
+
+	entries, err := list7Zip(ctx, exe, archivePath)
+	if err != nil {
+		return "", err
+	}
+
+	// Every entry is checked before 7-Zip writes anything: one leading outside
+	// destDir would be written there, and removed from there on failure, and
+	// a link could lead later entries outside it
+	var total int64
+	var paths []string
+	for _, e := range entries {
+		if e.Link {
+			return "", fmt.Errorf("%s: %w: %s", filepath.Base(archivePath), errArchiveLink, e.Path)
+		}
+		path, err := safeJoin(destDir, e.Path)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", filepath.Base(archivePath), err)
+		}
+		if !e.Dir {
+			total += e.Size
+			paths = append(paths, path)
+		}
+	}
+	if free, err := diskFree(destDir); err == nil && free < total {
+		return "", fmt.Errorf("%w to extract %s: needs %s, %s free", errDiskFull, filepath.Base(archivePath), formatBytes(total), formatBytes(free))
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, exe, "x", archivePath, "-o"+destDir, "-y")
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return "", err
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+wait:
+	for {
+		select {
+		case err = <-done:
+			break wait
+		case <-ticker.C:
+			if progress != nil {
+				var written int64
+				for _, p := range paths {
+					if info, err := os.Stat(p); err == nil {
+						written += info.Size()
+					}
+				}
+				progress(written, total)
+			}
+		}
+	}
+
+	if err != nil || ctx.Err() != nil {
+		for _, p := range paths {
+			os.Remove(p)
+		}
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		msg := strings.TrimSpace(stderr.String())
+		logError("7-Zip failed on %s: %v: %s", archivePath, err, msg)
+		lower := strings.ToLower(msg)
+		if strings.Contains(lower, "no space") || strings.Contains(lower, "not enough space") {
+			return "", fmt.Errorf("%w to extract %s (%s)", errDiskFull, filepath.Base(archivePath), formatBytes(total))
+		}
+		if msg != "" {
+			return "", fmt.Errorf("extracting %s: %s", filepath.Base(archivePath), msg)
+		}
+		return "", fmt.Errorf("extracting %s: %w", filepath.Base(archivePath), err)
+	}
+
+	if progress != nil {
+		progress(total, total)
+	}
+	if len(paths) == 0 {
+		return "", nil
+	}
+	return paths[len(paths)-1], nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse7ZipListing(t *testing.T) {
+	listing := strings.Join([]string{
+		"7-Zip 23.01 (x64) : Copyright (c) 1999-2023 Igor Pavlov : 2023-06-20",
+		"",
+		"Listing archive: game.7z",
+		"",
+		"--",
+		"Path = game.7z",
+		"Type = 7z",
+		"Physical Size = 1234",
+		"",
+		"----------",
+		"Path = Game (USA)",
+		"Size = 0",
+		"Folder = +",
+		"Attributes = D_ drwxr-xr-x",
+		"",
+		"Path = Game (USA)/Game (USA).cue",
+		"Size = 120",
+		"Folder = -",
+		"Attributes = A_ -rw-r--r--",
+		"",
+		"Path = Game (USA)/escape",
+		"Size = 4",
+		"Folder = -",
+		"Attributes = A_ lrwxrwxrwx",
+		"",
+		"Path = Game (USA)\\Track 01.bin",
+		"Size = 7340032",
+		"Attributes = A",
+		"",
+		"Path = Game (USA)/hard",
+		"Size = 0",
+		"Hard Link = Game (USA)/Game (USA).cue",
+		"",
+		"Path = Game (USA)/soft",
+		"Size = 0",
+		"Symbolic Link = /etc/passwd",
+		"",
+	}, "\r\n")
+
+	want := []sevenZipEntry{
+		{Path: "Game (USA)", Dir: true},
+		{Path: "Game (USA)/Game (USA).cue", Size: 120},
+		{Path: "Game (USA)/escape", Size: 4, Link: true},
+		{Path: "Game (USA)\\Track 01.bin", Size: 7340032},
+		{Path: "Game (USA)/hard", Link: true},
+		{Path: "Game (USA)/soft", Link: true},
+	}
+	if got := parse7ZipListing([]byte(listing)); !reflect.DeepEqual(got, want) {
+		t.Errorf("parse7ZipListing:\n got %+v\nwant %+v", got, want)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Game list sort modes (settings.json sortMode)
+const (
+	sortDefault        = "Default order"
+	sortNameAsc        = "Name (A-Z)"
+	sortNameDesc       = "Name (Z-A)"
+	sortSizeDesc       = "Size (largest)"
+	sortSizeAsc        = "Size (smallest)"
+	sortDateDesc       = "Date (newest)"
+	sortRecentlyPlayed = "Recently played"
+)
+
+var sortOptions = []string{
+	sortDefault, sortNameAsc, sortNameDesc, sortSizeDesc, sortSizeAsc, sortDateDesc, sortRecentlyPlayed,
+}
+
+// romDateLayout is the format of the set JSON "date" field ("15-Mar-2024 21:46")
+const romDateLayout = "02-Jan-2006 15:04"
+
+func isSortOption(mode string) bool {
+	for _, option := range sortOptions {
+		if option == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// sortGames orders games in place by the chosen sort mode. Games without a
+// value for the sort key (unknown size, no date, never played) keep their
+// original order after the rest.
+func sortGames(games []ROM, mode string) {
+	switch mode {
+	case sortNameAsc, sortNameDesc:
+		names := make(map[string]string, len(games))
+		for _, game := range games {
+			names[game.Name] = strings.ToLower(game.Name)
+		}
+		desc := mode == sortNameDesc
+		sort.SliceStable(games, func(i, j int) bool {
+			if desc {
+				return names[games[i].Name] > names[games[j].Name]
+			}
+			return names[games[i].Name] < names[games[j].Name]
+		})
+
+	case sortSizeDesc, sortSizeAsc:
+		sizes := make(map[string]int64, len(games))
+		for _, game := range games {
+			sizes[game.Name] = parseROMSize(game.Size)
+		}
+		desc := mode == sortSizeDesc
+		sort.SliceStable(games, func(i, j int) bool {
+			a, b := sizes[games[i].Name], sizes[games[j].Name]
+			if a < 0 || b < 0 {
+				return a >= 0 && b < 0
+			}
+			if desc {
+				return a > b
+			}
+			return a < b
+		})
+
+	case sortDateDesc:
+		dates := make(map[string]time.Time, len(games))
+		for _, game := range games {
+			if t, err := time.Parse(romDateLayout, game.Date); err == nil {
+				dates[game.Name] = t
+			}
+		}
+		sort.SliceStable(games, func(i, j int) bool {
+			return dates[games[i].Name].After(dates[games[j].Name])
+		})
+
+	case sortRecentlyPlayed:
+		lastPlayed := make(map[string]time.Time, len(games))
+		playStatsMu.Lock()
+		for _, game := range games {
+			if stat := playStats[game.System][game.Name]; stat != nil {
+				lastPlayed[game.Name] = stat.LastPlayed
+			}
+		}
+		playStatsMu.Unlock()
+		sort.SliceStable(games, func(i, j int) bool {
+			return lastPlayed[games[i].Name].After(lastPlayed[games[j].Name])
+		})
+	}
+}
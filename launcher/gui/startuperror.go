@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// openFolder shows a folder in the platform's file manager
+func openFolder(path string) error {
+	switch runtime.GOOS {
+	case "windows":
+		return exec.Command("explorer", path).Start()
+	case "darwin":
+		return exec.Command("open", path).Start()
+	default:
+		return exec.Command("xdg-open", path).Start()
+	}
+}
+
+// showSystemsConfigError replaces the launcher with a window explaining that
+// systems.json couldn't be loaded, offering to open the folder or re-run
+// setup. It returns once the window is closed.
+func showSystemsConfigError(myApp fyne.App, err error) {
+	window := myApp.NewWindow("EmuBuddy")
+	window.Resize(fyne.NewSize(560, 260))
+
+	reason := "systems.json is missing from the EmuBuddy folder."
+	if !errors.Is(err, fs.ErrNotExist) {
+		reason = fmt.Sprintf("systems.json couldn't be used:\n%v", err)
+	}
+	message := widget.NewLabel(reason + "\n\n" +
+		"It lists the systems and emulators EmuBuddy shows. Re-run setup or copy systems.json " +
+		"back from the EmuBuddy download, into:\n" + baseDir)
+	message.Wrapping = fyne.TextWrapWord
+
+	openBtn := widget.NewButton("Open Folder", func() {
+		if err := openFolder(baseDir); err != nil {
+			dialog.ShowError(err, window)
+		}
+	})
+	setupBtn := widget.NewButton("Run Setup", func() {
+		if err := startSetup(); err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		window.Close()
+	})
+	quitBtn := widget.NewButton("Quit", window.Close)
+
+	window.SetContent(container.NewBorder(nil,
+		container.NewHBox(openBtn, setupBtn, quitBtn), nil, nil,
+		container.NewVScroll(message)))
+	window.ShowAndRun()
+}
+
+// showSetError explains that a system's game list couldn't be loaded
+// (once per system per session)
+func (a *App) showSetError(config SystemConfig, err error) {
+	if a.setErrorShown == nil {
+		a.setErrorShown = make(map[string]bool)
+	}
+	if a.setErrorShown[config.ID] {
+		return
+	}
+	a.setErrorShown[config.ID] = true
+
+	path := filepath.Join("1g1rsets", config.RomJsonFile)
+	var message string
+	if errors.Is(err, fs.ErrNotExist) {
+		message = fmt.Sprintf("The game list for %s is missing:\n%s\n\n"+
+			"Check the file was copied with EmuBuddy, or fix romJsonFile in systems.json.", config.Name, path)
+	} else {
+		message = fmt.Sprintf("The game list for %s (%s) couldn't be read:\n%v\n\n"+
+			"It should be a JSON array of games; see SYSTEMS_CONFIG_GUIDE.md.", config.Name, path, err)
+	}
+
+	a.dialogOpen = true
+	d := dialog.NewInformation("Game list not loaded", message, a.window)
+	d.SetOnClosed(func() {
+		a.dialogOpen = false
+	})
+	d.Show()
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// TestSharedStateRace changes the state the game list reads while drawing,
+// the way the event goroutine and the set loader do, while other goroutines
+// read it the way Fyne's list callbacks do. It finds nothing by itself; run
+// it with -race.
+func TestSharedStateRace(t *testing.T) {
+	useTempBaseDir(t)
+	oldFavorites, oldPath, oldCollapse := favorites, favoritesPath, settings.CollapseRevisions
+	favorites = make(map[string]map[string]bool)
+	favoritesPath = filepath.Join(baseDir, "favorites.json")
+	settings.CollapseRevisions = collapseLatest
+	t.Cleanup(func() {
+		favorites, favoritesPath, settings.CollapseRevisions = oldFavorites, oldPath, oldCollapse
+	})
+
+	var games []ROM
+	for i := 0; i < 50; i++ {
+		games = append(games,
+			ROM{System: "snes", Name: fmt.Sprintf("Game %d (USA).zip", i)},
+			ROM{System: "snes", Name: fmt.Sprintf("Game %d (USA) (Rev 1).zip", i)})
+	}
+
+	a := &App{currentSystem: "snes"}
+	a.setROMCache("snes", make(map[string]bool), make(map[string]string))
+	const rounds = 200
+	var wg sync.WaitGroup
+
+	// The event goroutine: filtering, expanding groups, ticking, favorites
+	// and download results
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < rounds; i++ {
+			game := games[i%len(games)]
+			a.setGroupExpanded(revisionGroupKey(game), i%2 == 0)
+			shown, revisions := collapseRevisions(games, a.expandedRevisionGroups())
+			a.setShownGames(shown, revisions)
+			a.setSelectedIndex(i % len(shown))
+
+			a.setChecked(game, i%3 != 0)
+			setFavorite(game, i%2 == 0)
+			saveFavorites()
+			a.setDownloaded(game, i%2 == 0)
+
+			err := errors.New("connection reset")
+			if i%4 == 0 {
+				err = nil
+			}
+			a.recordDownloadResult(game.System, game, err)
+			a.retryableFailures()
+			a.permanentFailures()
+		}
+	}()
+
+	// loadSystemGames, publishing a set as it's read
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		load := a.gamesLoad.Load()
+		for i := 0; i < rounds; i++ {
+			loaded, lowerNames := prepareGames("snes", games[:i%len(games)+1], false)
+			a.publishLoadedGames(load, loaded, lowerNames)
+			a.publishROMCache(load, "snes", make(map[string]bool), make(map[string]string))
+		}
+	}()
+
+	// Fyne's list callbacks, on the render goroutine
+	for r := 0; r < 2; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				for _, game := range a.shownGames() {
+					a.revisionLabel(game)
+					a.isRevisionVariant(game)
+					a.isFavorite(game)
+					a.isChecked(game)
+					a.isDownloaded(game)
+				}
+				a.loadedGames()
+				a.selectedGame()
+				a.checkedCount()
+				favoriteNames("snes")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := len(a.checkedList()); n != a.checkedCount() {
+		t.Errorf("checkedList has %d games, checkedCount says %d", n, a.checkedCount())
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// specialDownloadWiiU marks a system whose games are fetched from Nintendo's
+// CDN by title ID and stored as a title folder instead of a ROM file
+const specialDownloadWiiU = "wiiu"
+
+// systemsConfigProblems lists the systems.json entries that were skipped or
+// adjusted on load; they're also written to launcher_debug.log
+var systemsConfigProblems []string
+
+// isWiiU reports whether the system's games are Wii U title folders
+func (c SystemConfig) isWiiU() bool {
+	return c.SpecialDownload == specialDownloadWiiU
+}
+
+// hasEmulator reports whether the system has anything to launch its games with
+func (c SystemConfig) hasEmulator() bool {
+	if c.Emulator.Path != "" {
+		return true
+	}
+	return c.StandaloneEmulator != nil && c.StandaloneEmulator.Path != ""
+}
+
+// normalizeSystem fills in defaults for a systems.json entry so a system
+// defined only by dir, romJsonFile, fileExtensions, needsExtract and an
+// emulator works like the built-in ones. It returns an error if the entry
+// can't be used, and warnings for entries that load but won't fully work.
+func normalizeSystem(sys *SystemConfig) (warnings []string, err error) {
+	if sys.ID == "" {
+		return nil, fmt.Errorf("missing id")
+	}
+	if isVirtualSystem(sys.ID) {
+		return nil, fmt.Errorf("id %q is reserved", sys.ID)
+	}
+	if sys.RomJsonFile == "" {
+		return nil, fmt.Errorf("missing romJsonFile")
+	}
+	if sys.Name == "" {
+		sys.Name = sys.ID
+	}
+	if sys.Dir == "" {
+		sys.Dir = sys.ID
+	}
+
+	// Extensions are matched case-insensitively; accept "iso" as ".iso"
+	for i, ext := range sys.FileExtensions {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext != "" && !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		sys.FileExtensions[i] = ext
+	}
+
+	switch sys.SpecialDownload {
+	case "", specialDownloadWiiU:
+	default:
+		warnings = append(warnings, fmt.Sprintf("unknown specialDownload %q, downloading by URL", sys.SpecialDownload))
+		sys.SpecialDownload = ""
+	}
+
+	if err := normalizeEmulator(&sys.Emulator); err != nil {
+		return nil, err
+	}
+	if sys.StandaloneEmulator != nil {
+		if err := normalizeEmulator(sys.StandaloneEmulator); err != nil {
+			return nil, err
+		}
+	}
+
+	if !sys.hasEmulator() {
+		warnings = append(warnings, "no emulator path, games can be downloaded but not launched")
+	}
+	if sys.NeedsExtract && len(sys.FileExtensions) == 0 && !sys.isWiiU() {
+		warnings = append(warnings, "needsExtract is set but fileExtensions is empty, so extracted games can't be found")
+	}
+	return warnings, nil
+}
+
+// validROMName reports whether a set entry's name is safe to use as a file
+// name under the system's ROM folder
+func validROMName(name string) bool {
+	if name == "" || name == "." || name == ".." {
+		return false
+	}
+	return !strings.ContainsAny(name, `/\`)
+}
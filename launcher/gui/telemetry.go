@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// downloadTelemetryInterval is how often a running download logs its throughput
+const downloadTelemetryInterval = 5 * time.Second
+
+// urlHost returns just the host of a URL for logging
+func urlHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	return u.Host
+}
+
+// formatRate renders a bytes-per-second figure
+func formatRate(bytes int64, elapsed time.Duration) string {
+	if elapsed <= 0 {
+		return "n/a"
+	}
+	return formatBytes(int64(float64(bytes)/elapsed.Seconds())) + "/s"
+}
+
+// logSize is formatBytes for sizes the server may not have reported
+func logSize(bytes int64) string {
+	if bytes < 0 {
+		return "unknown"
+	}
+	return formatBytes(bytes)
+}
+
+// exitStatus describes how a process ended for the debug log
+func exitStatus(err error) string {
+	if err == nil {
+		return "0"
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return strconv.Itoa(exitErr.ExitCode())
+	}
+	return err.Error()
+}
+
+// downloadTelemetry writes structured debug log lines for a single download:
+// throughput while it runs and a summary when it ends. Bytes that were
+// already on disk from a resumed .part file don't count towards the speed.
+type downloadTelemetry struct {
+	host    string
+	started time.Time
+
+	mu         sync.Mutex
+	firstBytes int64
+	lastBytes  int64
+	total      int64
+	seen       bool
+	lastLog    time.Time
+}
+
+func newDownloadTelemetry(rawURL string) *downloadTelemetry {
+	now := time.Now()
+	return &downloadTelemetry{host: urlHost(rawURL), started: now, lastLog: now}
+}
+
+// wrap returns a progress callback that records throughput before calling progress
+func (t *downloadTelemetry) wrap(progress func(downloaded, total int64)) func(downloaded, total int64) {
+	return func(downloaded, total int64) {
+		t.mu.Lock()
+		if !t.seen {
+			t.seen = true
+			t.firstBytes = downloaded
+		}
+		t.lastBytes = downloaded
+		t.total = total
+		if time.Since(t.lastLog) >= downloadTelemetryInterval {
+			t.lastLog = time.Now()
+			logDebug("download progress: host=%s downloaded=%s total=%s speed=%s",
+				t.host, formatBytes(downloaded), logSize(total), formatRate(downloaded-t.firstBytes, time.Since(t.started)))
+		}
+		t.mu.Unlock()
+		progress(downloaded, total)
+	}
+}
+
+// finish logs the outcome of the download
+func (t *downloadTelemetry) finish(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	elapsed := time.Since(t.started)
+	transferred := t.lastBytes - t.firstBytes
+	if err != nil {
+		logWarn("download failed: host=%s elapsed=%s transferred=%s avg=%s err=%v",
+			t.host, elapsed.Round(time.Millisecond), formatBytes(transferred), formatRate(transferred, elapsed), err)
+		return
+	}
+	logInfo("download complete: host=%s elapsed=%s size=%s resumed=%s avg=%s",
+		t.host, elapsed.Round(time.Millisecond), formatBytes(t.lastBytes), formatBytes(t.firstBytes), formatRate(transferred, elapsed))
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+// typeAheadTimeout is how long after a letter the next one still adds to the
+// same type-ahead jump, rather than starting a new one
+const typeAheadTimeout = time.Second
+
+// shortcutLetters are the letter keys with a shortcut in the game list. They
+// only start a type-ahead jump with Shift held, but continue one as letters.
+// Keep in step with the SetOnTypedKey switch in buildUI.
+var shortcutLetters = map[fyne.KeyName]bool{
+	fyne.KeyD: true, fyne.KeyE: true, fyne.KeyF: true, fyne.KeyG: true, fyne.KeyV: true,
+}
+
+// typeAheadChar returns the character a key types into a type-ahead jump:
+// letters (lower-cased), digits and, within a jump, space
+func typeAheadChar(key fyne.KeyName) (byte, bool) {
+	if key == fyne.KeySpace {
+		return ' ', true
+	}
+	if len(key) != 1 {
+		return 0, false
+	}
+	c := key[0]
+	switch {
+	case c >= 'A' && c <= 'Z':
+		return c - 'A' + 'a', true
+	case c >= '0' && c <= '9':
+		return c, true
+	}
+	return 0, false
+}
+
+// typeAheadKey handles a key pressed in the game list as type-ahead: a letter
+// jumps to the next game whose name starts with it, letters typed within
+// typeAheadTimeout of each other narrow that to games starting with all of
+// them, and the same letter again moves on to the one after. It reports
+// whether the key was used, in which case it isn't a shortcut too.
+func (a *App) typeAheadKey(key fyne.KeyName) bool {
+	c, ok := typeAheadChar(key)
+	if !ok {
+		return false
+	}
+	now := time.Now()
+	if a.typeAhead == "" || now.Sub(a.typeAheadAt) > typeAheadTimeout {
+		a.typeAhead = ""
+		if c == ' ' || (shortcutLetters[key] && !a.shiftHeld) {
+			return false
+		}
+	}
+	a.typeAheadAt = now
+
+	// A first letter, or the same letter again, moves past the selected game;
+	// more letters narrow the match from it
+	from := a.selectedIndex()
+	if a.typeAhead == "" || a.typeAhead == string(c) {
+		from++
+	}
+	if a.typeAhead != string(c) {
+		a.typeAhead += string(c)
+	}
+	if idx := a.findGameByPrefix(a.typeAhead, from); idx >= 0 {
+		if idx != a.selectedIndex() {
+			a.selectGame(idx)
+		}
+	} else {
+		a.statusBar.SetText("No game starts with \"" + a.typeAhead + "\"")
+	}
+	return true
+}
+
+// findGameByPrefix returns the index of the first shown game, from index from
+// on and wrapping around to the top, whose name starts with prefix (ignoring
+// case), or -1 if none does
+func (a *App) findGameByPrefix(prefix string, from int) int {
+	games := a.shownGames()
+	if len(games) == 0 {
+		return -1
+	}
+	if from < 0 || from >= len(games) {
+		from = 0
+	}
+	prefix = strings.ToLower(prefix)
+	for n := 0; n < len(games); n++ {
+		i := (from + n) % len(games)
+		if strings.HasPrefix(strings.ToLower(gameDisplayName(games[i])), prefix) {
+			return i
+		}
+	}
+	return -1
+}
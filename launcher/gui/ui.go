@@ -0,0 +1,90 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+// progressUpdateInterval is the most often a download's progress row is redrawn
+const progressUpdateInterval = 100 * time.Millisecond
+
+// eventQueuer is the part of Fyne 2.2's desktop window that queues input
+// events. One goroutine drains that queue and calls every event handler
+// (taps, keys, list selections, dialog buttons), so code queued there runs in
+// turn with them, as fyne.Do does in later Fyne versions.
+type eventQueuer interface {
+	QueueEvent(fn func())
+}
+
+// uiQueue holds code from other goroutines (downloads, set loading, box art,
+// library scans, emulator exits, the controller) until runUIQueue hands it to
+// Fyne's event goroutine, in order. Adding to it never blocks, even once the
+// window has closed and stopped taking events.
+var uiQueue = struct {
+	sync.Mutex
+	pending []func()
+	wake    chan struct{}
+}{wake: make(chan struct{}, 1)}
+
+// runOnEvents queues fn to run on Fyne's event goroutine, after anything
+// queued before it. App state is only changed there: by the event handlers
+// themselves, and through this by work that finishes on other goroutines.
+// Fyne's list callbacks can still run on its render goroutine, so what they
+// read is guarded too (see games.go).
+func runOnEvents(fn func()) {
+	uiQueue.Lock()
+	uiQueue.pending = append(uiQueue.pending, fn)
+	uiQueue.Unlock()
+	select {
+	case uiQueue.wake <- struct{}{}:
+	default:
+	}
+}
+
+// runUIQueue hands queued code to window's event goroutine for the life of
+// the app. A window without an event queue (Fyne's test driver) has no event
+// goroutine to share, so the code runs here instead.
+func runUIQueue(window fyne.Window) {
+	events, ok := window.(eventQueuer)
+	if !ok {
+		logDebug("Window has no event queue; running queued updates on their own goroutine")
+	}
+	for range uiQueue.wake {
+		uiQueue.Lock()
+		pending := uiQueue.pending
+		uiQueue.pending = nil
+		uiQueue.Unlock()
+		run := func() {
+			for _, fn := range pending {
+				fn()
+			}
+		}
+		if ok {
+			events.QueueEvent(run)
+		} else {
+			run()
+		}
+	}
+}
+
+// uiThrottle drops progress updates that come faster than
+// progressUpdateInterval. Parallel download workers share one.
+type uiThrottle struct {
+	mu   sync.Mutex
+	last time.Time
+}
+
+// ready reports whether an update should be shown now. Final updates
+// (done) are always shown.
+func (t *uiThrottle) ready(done bool) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	if !done && now.Sub(t.last) < progressUpdateInterval {
+		return false
+	}
+	t.last = now
+	return true
+}
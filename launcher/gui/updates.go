@@ -0,0 +1,426 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// launcherVersion is this build's version, compared with the update
+// manifest's. Release builds can set it with
+// -ldflags "-X main.launcherVersion=2.1".
+var launcherVersion = "2.0"
+
+// defaultUpdateManifestURL is where the launcher's latest version is
+// published when settings.json has no updateManifestURL. It's empty in
+// source builds; a release build sets it with
+// -ldflags "-X main.defaultUpdateManifestURL=https://...".
+var defaultUpdateManifestURL = ""
+
+// launcherRelease is the JSON served at the update manifest URL, e.g.
+// {"version": "2.1", "url": "https://example.org/emubuddy/2.1", "notes": "..."}
+type launcherRelease struct {
+	Version string `json:"version"`
+	URL     string `json:"url,omitempty"` // download page, opened from the Updates dialog
+	Notes   string `json:"notes,omitempty"`
+}
+
+// emulatorUpdate is one entry of `EmuBuddySetup update -check -json`
+type emulatorUpdate struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Installed string `json:"installed,omitempty"`
+	Latest    string `json:"latest,omitempty"`
+	// Unknown is set when the setup program has no record of the installed
+	// version (an install from before versions were recorded)
+	Unknown bool `json:"unknown,omitempty"`
+}
+
+// updateManifestURL returns the manifest to check, or "" if there's none
+func updateManifestURL() string {
+	if settings.UpdateManifestURL != "" {
+		return settings.UpdateManifestURL
+	}
+	return defaultUpdateManifestURL
+}
+
+// fetchLauncherRelease downloads the update manifest
+func fetchLauncherRelease(ctx context.Context, manifestURL string) (launcherRelease, error) {
+	var release launcherRelease
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, manifestURL, nil)
+	if err != nil {
+		return release, err
+	}
+	req.Header.Set("User-Agent", "EmuBuddy/"+launcherVersion)
+	resp, err := newHTTPClient(30 * time.Second).Do(req)
+	if err != nil {
+		return release, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return release, fmt.Errorf("update manifest: HTTP %d", resp.StatusCode)
+	}
+	// A manifest is a few lines; don't read a whole page if the URL is wrong
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&release); err != nil {
+		return release, fmt.Errorf("update manifest: %w", err)
+	}
+	if release.Version == "" {
+		return release, errors.New("update manifest has no version")
+	}
+	return release, nil
+}
+
+// compareVersions compares dotted versions part by part, numerically where
+// both parts are numbers ("2.10" is newer than "2.9"), returning -1, 0 or 1.
+// A leading "v" is ignored.
+func compareVersions(a, b string) int {
+	as := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bs := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var ap, bp string
+		if i < len(as) {
+			ap = as[i]
+		}
+		if i < len(bs) {
+			bp = bs[i]
+		}
+		an, aErr := strconv.Atoi(ap)
+		bn, bErr := strconv.Atoi(bp)
+		if ap == "" {
+			an, aErr = 0, nil
+		}
+		if bp == "" {
+			bn, bErr = 0, nil
+		}
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				if an < bn {
+					return -1
+				}
+				return 1
+			}
+		case ap != bp:
+			if ap < bp {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// setupCommand returns a command running the setup program with args from
+// the EmuBuddy folder
+func setupCommand(args ...string) (*exec.Cmd, error) {
+	path := setupPath()
+	if !fileExists(path) {
+		return nil, fmt.Errorf("setup program not found: %s", path)
+	}
+	if runtime.GOOS != "windows" {
+		os.Chmod(path, 0755)
+	}
+	cmd := exec.Command(path, args...)
+	cmd.Dir = baseDir
+	return cmd, nil
+}
+
+// checkEmulatorUpdates asks the setup program which installed emulators
+// differ from its catalog (emulators.json included). It only reads;
+// nothing is downloaded.
+func checkEmulatorUpdates() ([]emulatorUpdate, error) {
+	cmd, err := setupCommand("update", "-check", "-json")
+	if err != nil {
+		return nil, err
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%v: %s", err, msg)
+		}
+		return nil, err
+	}
+	var updates []emulatorUpdate
+	if err := json.Unmarshal(out, &updates); err != nil {
+		return nil, fmt.Errorf("unexpected output from %s: %v", setupPath(), err)
+	}
+	return updates, nil
+}
+
+// emulatorUpdateText describes an update for the Updates dialog
+func emulatorUpdateText(update emulatorUpdate) string {
+	latest := update.Latest
+	if latest == "" {
+		latest = "the current download"
+	}
+	if update.Unknown {
+		return fmt.Sprintf("%s: installed version unknown, reinstall %s", update.Name, latest)
+	}
+	installed := update.Installed
+	if installed == "" {
+		installed = "installed"
+	}
+	return fmt.Sprintf("%s: %s -> %s", update.Name, installed, latest)
+}
+
+// showUpdatesDialog checks for a new launcher and for emulator updates, and
+// offers to reinstall the selected emulators. Nothing is downloaded until
+// "Update selected" is pressed.
+func (a *App) showUpdatesDialog() {
+	launcherLabel := widget.NewLabel("Checking for a new version of EmuBuddy...")
+	launcherLabel.Wrapping = fyne.TextWrapWord
+	openBtn := widget.NewButton("Open download page", nil)
+	openBtn.Hide()
+
+	emulatorsBox := container.NewVBox(widget.NewLabel("Checking installed emulators..."))
+	updateBtn := widget.NewButton("Update selected", nil)
+	updateBtn.Disable()
+
+	startupCheck := widget.NewCheck("Check for updates when EmuBuddy starts", func(on bool) {
+		settings.CheckForUpdates = on
+		saveSettings()
+	})
+	startupCheck.SetChecked(settings.CheckForUpdates)
+
+	var d dialog.Dialog
+	content := container.NewVBox(
+		widget.NewLabelWithStyle("Launcher", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		launcherLabel,
+		openBtn,
+		widget.NewSeparator(),
+		widget.NewLabelWithStyle("Emulators", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		emulatorsBox,
+		updateBtn,
+		widget.NewSeparator(),
+		startupCheck,
+	)
+	a.dialogOpen = true
+	d = dialog.NewCustom("Updates", "Close", content, a.window)
+	d.SetOnClosed(func() {
+		a.dialogOpen = false
+	})
+	d.Resize(fyne.NewSize(520, 0))
+	d.Show()
+
+	go func() {
+		text, pageURL := launcherUpdateText()
+		runOnEvents(func() {
+			launcherLabel.SetText(text)
+			if pageURL != nil {
+				openBtn.OnTapped = func() {
+					if err := fyne.CurrentApp().OpenURL(pageURL); err != nil {
+						dialog.ShowError(err, a.window)
+					}
+				}
+				openBtn.Show()
+			}
+		})
+	}()
+
+	go func() {
+		updates, err := checkEmulatorUpdates()
+		runOnEvents(func() {
+			emulatorsBox.RemoveAll()
+			if err != nil {
+				logWarn("Emulator update check failed: %v", err)
+				label := widget.NewLabel(fmt.Sprintf("Couldn't check: %v", err))
+				label.Wrapping = fyne.TextWrapWord
+				emulatorsBox.Add(label)
+				return
+			}
+			if len(updates) == 0 {
+				emulatorsBox.Add(widget.NewLabel("All installed emulators are up to date"))
+				return
+			}
+
+			checks := make([]*widget.Check, len(updates))
+			selectionChanged := func(bool) {
+				for _, check := range checks {
+					if check != nil && check.Checked {
+						updateBtn.Enable()
+						return
+					}
+				}
+				updateBtn.Disable()
+			}
+			for i, update := range updates {
+				checks[i] = widget.NewCheck(emulatorUpdateText(update), selectionChanged)
+				// An unknown version may well be current; leave it to the user
+				checks[i].SetChecked(!update.Unknown)
+				emulatorsBox.Add(checks[i])
+			}
+			selectionChanged(true)
+
+			updateBtn.OnTapped = func() {
+				var ids []string
+				for i, check := range checks {
+					if check.Checked {
+						ids = append(ids, updates[i].ID)
+					}
+				}
+				d.Hide()
+				a.runEmulatorUpdates(ids)
+			}
+		})
+	}()
+}
+
+// launcherUpdateText checks the update manifest and describes the result.
+// The download page is returned when there's a newer version.
+func launcherUpdateText() (string, *url.URL) {
+	manifestURL := updateManifestURL()
+	if manifestURL == "" {
+		return fmt.Sprintf("EmuBuddy %s. No update manifest is configured (updateManifestURL in settings.json).", launcherVersion), nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	release, err := fetchLauncherRelease(ctx, manifestURL)
+	if err != nil {
+		logWarn("Launcher update check failed: %v", err)
+		return fmt.Sprintf("EmuBuddy %s. Couldn't check for a new version: %v", launcherVersion, err), nil
+	}
+	if compareVersions(release.Version, launcherVersion) <= 0 {
+		return fmt.Sprintf("EmuBuddy %s is up to date.", launcherVersion), nil
+	}
+	text := fmt.Sprintf("EmuBuddy %s is available (you have %s).", release.Version, launcherVersion)
+	if release.Notes != "" {
+		text += "\n\n" + release.Notes
+	}
+	pageURL, err := url.Parse(release.URL)
+	if release.URL == "" || err != nil || (pageURL.Scheme != "http" && pageURL.Scheme != "https") {
+		return text, nil
+	}
+	return text, pageURL
+}
+
+// ansiEscape matches the colour codes in the setup program's output
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+// scanOutputLines is a bufio.SplitFunc splitting on \r as well as \n, so
+// progress lines that redraw themselves come through as they change
+func scanOutputLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// runEmulatorUpdates reinstalls the emulators with the given IDs through
+// the setup program, showing its progress in the status bar. Games can't be
+// launched while it runs.
+func (a *App) runEmulatorUpdates(ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+	if a.gameRunning.Load() > 0 {
+		a.statusBar.SetText("Close the running game before updating emulators")
+		return
+	}
+	if !a.updatingEmulators.CompareAndSwap(false, true) {
+		a.statusBar.SetText("Emulators are already being updated")
+		return
+	}
+
+	// -y is the confirmation given in the Updates dialog
+	cmd, err := setupCommand(append([]string{"update", "-y"}, ids...)...)
+	var output io.ReadCloser
+	if err == nil {
+		output, err = cmd.StdoutPipe()
+	}
+	if err == nil {
+		cmd.Stderr = cmd.Stdout
+		err = cmd.Start()
+	}
+	if err != nil {
+		a.updatingEmulators.Store(false)
+		logError("Emulator update failed to start: %v", err)
+		a.statusBar.SetText(fmt.Sprintf("Emulator update failed: %v", err))
+		return
+	}
+	logInfo("Updating emulators: %s", strings.Join(ids, ", "))
+	a.statusBar.SetText("Updating emulators...")
+
+	go func() {
+		var throttle uiThrottle
+		var last string
+		scanner := bufio.NewScanner(output)
+		scanner.Split(scanOutputLines)
+		for scanner.Scan() {
+			line := strings.TrimSpace(ansiEscape.ReplaceAllString(scanner.Text(), ""))
+			if line == "" {
+				continue
+			}
+			logDebug("setup: %s", line)
+			last = line
+			if throttle.ready(false) {
+				runOnEvents(func() { a.statusBar.SetText("Updating emulators: " + line) })
+			}
+		}
+		err := cmd.Wait()
+		a.updatingEmulators.Store(false)
+		runOnEvents(func() {
+			if err != nil {
+				logError("Emulator update failed: %v (%s)", err, last)
+				a.statusBar.SetText(fmt.Sprintf("Emulator update failed: %s", last))
+				return
+			}
+			logInfo("Emulators updated: %s", strings.Join(ids, ", "))
+			a.statusBar.SetText(last)
+		})
+	}()
+}
+
+// checkForUpdatesAtStartup is the opt-in background check (checkForUpdates
+// in settings.json). It only reports what it finds in the status bar.
+func (a *App) checkForUpdatesAtStartup() {
+	var found []string
+	if manifestURL := updateManifestURL(); manifestURL != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		release, err := fetchLauncherRelease(ctx, manifestURL)
+		cancel()
+		if err != nil {
+			logWarn("Launcher update check failed: %v", err)
+		} else if compareVersions(release.Version, launcherVersion) > 0 {
+			found = append(found, "EmuBuddy "+release.Version)
+		}
+	}
+	updates, err := checkEmulatorUpdates()
+	if err != nil {
+		logWarn("Emulator update check failed: %v", err)
+	}
+	for _, update := range updates {
+		if !update.Unknown {
+			found = append(found, update.Name)
+		}
+	}
+	if len(found) == 0 {
+		return
+	}
+	logInfo("Updates available: %s", strings.Join(found, ", "))
+	runOnEvents(func() {
+		a.statusBar.SetText("Updates available: " + strings.Join(found, ", ") + " (see Updates at the top right)")
+	})
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultUserAgent is sent when rotation is off and no UA is configured
+const defaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36"
+
+// defaultUserAgentPool is a small set of realistic desktop browser UAs used
+// when rotation is enabled and settings.json doesn't supply its own list
+var defaultUserAgentPool = []string{
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:133.0) Gecko/20100101 Firefox/133.0",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/18.1 Safari/605.1.15",
+	"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36 Edg/131.0.0.0",
+}
+
+var (
+	userAgentMu     sync.Mutex
+	userAgentByHost = make(map[string]string) // picked once per host for the session
+	userAgentRand   = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// userAgentFor returns the User-Agent to send to rawURL's host. With rotation
+// enabled, each host gets a random UA from the pool that stays the same for
+// the rest of the session so chunked/retried requests look consistent.
+func userAgentFor(rawURL string) string {
+	if settings.UserAgent != "" {
+		return settings.UserAgent
+	}
+	if !settings.RotateUserAgent {
+		return defaultUserAgent
+	}
+
+	pool := settings.UserAgents
+	if len(pool) == 0 {
+		pool = defaultUserAgentPool
+	}
+
+	host := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		host = u.Host
+	}
+
+	userAgentMu.Lock()
+	defer userAgentMu.Unlock()
+	if ua, ok := userAgentByHost[host]; ok {
+		return ua
+	}
+	ua := pool[userAgentRand.Intn(len(pool))]
+	userAgentByHost[host] = ua
+	return ua
+}
+
+// hostHeadersFor returns the hostHeaders entry for rawURL's host, or for the
+// nearest parent domain that has one. They're sent after the launcher's own
+// headers, so a User-Agent or Referer there wins.
+func hostHeadersFor(rawURL string) http.Header {
+	if len(settings.HostHeaders) == 0 {
+		return nil
+	}
+	for _, host := range hostAndParents(hostName(rawURL)) {
+		if headers, ok := settings.HostHeaders[host]; ok {
+			header := make(http.Header, len(headers))
+			for name, value := range headers {
+				header.Set(name, value)
+			}
+			return header
+		}
+	}
+	return nil
+}
+
+// setDownloadHeaders gives req the headers a download of its URL is sent
+// with: the host's User-Agent, then its hostHeaders
+func setDownloadHeaders(req *http.Request) {
+	req.Header.Set("User-Agent", userAgentFor(req.URL.String()))
+	for name, values := range hostHeadersFor(req.URL.String()) {
+		req.Header[name] = values
+	}
+}
+
+// validHeader reports whether name and value can be sent as a request header
+func validHeader(name, value string) bool {
+	if name == "" || strings.ContainsAny(value, "\r\n") {
+		return false
+	}
+	for _, c := range name {
+		if c <= ' ' || c >= 0x7f || c == ':' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"archive/zip"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"strings"
+)
+
+// errHashMismatch is returned by verifyROMFile when the file doesn't match the set
+type errHashMismatch struct {
+	Algo, Expected, Actual string
+}
+
+func (e *errHashMismatch) Error() string {
+	return fmt.Sprintf("%s mismatch: expected %s, got %s", e.Algo, e.Expected, e.Actual)
+}
+
+// hasHashes reports whether the set JSON gives any hash for this game
+func (r ROM) hasHashes() bool {
+	return r.CRC32 != "" || r.SHA1 != "" || r.MD5 != ""
+}
+
+// verifyROMFile checks a downloaded file against the hashes in the set JSON.
+// Sets may list hashes of the zip itself or of the ROM inside it, so for a zip
+// holding a single file the inner file is also accepted. Files are streamed,
+// never loaded into memory. Games without hashes always pass.
+func verifyROMFile(path string, game ROM) error {
+	if !game.hasHashes() || settings.SkipHashVerification {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	err = compareHashes(f, game)
+	f.Close()
+	if err == nil {
+		return nil
+	}
+
+	if strings.HasSuffix(strings.ToLower(path), ".zip") {
+		if innerErr := verifyZipEntry(path, game); innerErr == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// verifyZipEntry hashes the only file inside a zip
+func verifyZipEntry(path string, game ROM) error {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	var entry *zip.File
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if entry != nil {
+			return fmt.Errorf("zip has more than one file")
+		}
+		entry = f
+	}
+	if entry == nil {
+		return fmt.Errorf("zip is empty")
+	}
+
+	rc, err := entry.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return compareHashes(rc, game)
+}
+
+// compareHashes streams r through whichever hashes the game has and compares them
+func compareHashes(r io.Reader, game ROM) error {
+	type check struct {
+		algo     string
+		expected string
+		h        hash.Hash
+	}
+	var checks []check
+	if game.CRC32 != "" {
+		checks = append(checks, check{"CRC32", game.CRC32, crc32.NewIEEE()})
+	}
+	if game.SHA1 != "" {
+		checks = append(checks, check{"SHA1", game.SHA1, sha1.New()})
+	}
+	if game.MD5 != "" {
+		checks = append(checks, check{"MD5", game.MD5, md5.New()})
+	}
+
+	writers := make([]io.Writer, len(checks))
+	for i := range checks {
+		writers[i] = checks[i].h
+	}
+	if _, err := io.Copy(io.MultiWriter(writers...), r); err != nil {
+		return err
+	}
+
+	for _, c := range checks {
+		actual := hex.EncodeToString(c.h.Sum(nil))
+		if !strings.EqualFold(actual, c.expected) {
+			return &errHashMismatch{Algo: c.algo, Expected: c.expected, Actual: actual}
+		}
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package wiiu
 
 import (
+	"context"
 	"encoding/binary"
 	"fmt"
 	"net/http"
@@ -10,12 +11,12 @@ import (
 
 var cetkData []byte
 
-func getDefaultCert(progressReporter ProgressReporter, client *http.Client) ([]byte, error) {
+func getDefaultCert(ctx context.Context, progressReporter ProgressReporter, client *http.Client) ([]byte, error) {
 	if len(cetkData) >= 0x350+0x300 {
 		return cetkData[0x350 : 0x350+0x300], nil
 	}
 	cetkDir := path.Join(os.TempDir(), "cetk")
-	if err := downloadFile(progressReporter, client, "http://ccs.cdn.c.shop.nintendowifi.net/ccs/download/000500101000400a/cetk", cetkDir, true); err != nil {
+	if err := downloadFile(ctx, progressReporter, client, "http://ccs.cdn.c.shop.nintendowifi.net/ccs/download/000500101000400a/cetk", cetkDir, true); err != nil {
 		return nil, err
 	}
 	cetkData, err := os.ReadFile(cetkDir)
@@ -34,7 +35,7 @@ func getDefaultCert(progressReporter ProgressReporter, client *http.Client) ([]b
 }
 
 // GenerateCert generates a certificate file for a title
-func GenerateCert(tmd *TMD, outputPath string, progressReporter ProgressReporter, client *http.Client) error {
+func GenerateCert(ctx context.Context, tmd *TMD, outputPath string, progressReporter ProgressReporter, client *http.Client) error {
 	cert, err := os.Create(outputPath)
 	if err != nil {
 		return err
@@ -49,7 +50,7 @@ func GenerateCert(tmd *TMD, outputPath string, progressReporter ProgressReporter
 		return err
 	}
 
-	defaultCert, err := getDefaultCert(progressReporter, client)
+	defaultCert, err := getDefaultCert(ctx, progressReporter, client)
 	if err != nil {
 		return err
 	}
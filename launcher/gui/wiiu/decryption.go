@@ -3,6 +3,7 @@ package wiiu
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/sha1"
@@ -50,7 +51,27 @@ type FSTData struct {
 	FSTEntries  []FEntry
 }
 
-func extractFileHash(src *os.File, partDataOffset uint64, fileOffset uint64, size uint64, path string, cipherHashTree cipher.Block) error {
+// decryptionProgress reports decryption by bytes written rather than by file,
+// so a title with a few huge files moves steadily instead of jumping, and
+// stops the decryption once the download is cancelled
+type decryptionProgress struct {
+	ctx      context.Context
+	reporter ProgressReporter
+	done     uint64
+	total    uint64
+}
+
+// add counts n more bytes written, returning ctx's error if the download
+// has been cancelled
+func (p *decryptionProgress) add(n int) error {
+	p.done += uint64(n)
+	if p.total > 0 {
+		p.reporter.UpdateDecryptionProgress(float64(p.done) / float64(p.total))
+	}
+	return p.ctx.Err()
+}
+
+func extractFileHash(src *os.File, partDataOffset uint64, fileOffset uint64, size uint64, path string, cipherHashTree cipher.Block, progress *decryptionProgress) error {
 	writeSize := HASH_BLOCK_SIZE
 	blockNumber := (fileOffset / HASH_BLOCK_SIZE) & 0x0F
 
@@ -128,6 +149,9 @@ func extractFileHash(src *os.File, partDataOffset uint64, fileOffset uint64, siz
 		}
 
 		size -= uint64(n)
+		if err := progress.add(n); err != nil {
+			return err
+		}
 
 		blockNumber++
 		if blockNumber >= 16 {
@@ -145,7 +169,7 @@ func extractFileHash(src *os.File, partDataOffset uint64, fileOffset uint64, siz
 	return nil
 }
 
-func extractFile(src *os.File, partDataOffset uint64, fileOffset uint64, size uint64, path string, contentId uint16, cipherHashTree cipher.Block) error {
+func extractFile(src *os.File, partDataOffset uint64, fileOffset uint64, size uint64, path string, contentId uint16, cipherHashTree cipher.Block, progress *decryptionProgress) error {
 	writeSize := BLOCK_SIZE
 
 	dst, err := os.Create(path)
@@ -210,6 +234,9 @@ func extractFile(src *os.File, partDataOffset uint64, fileOffset uint64, size ui
 		}
 
 		size -= uint64(n)
+		if err := progress.add(n); err != nil {
+			return err
+		}
 
 		if soffset != 0 {
 			writeSize = BLOCK_SIZE
@@ -643,7 +670,7 @@ func extractU8(data []byte, outputPath string) error {
 }
 
 // DecryptContents decrypts the contents of a downloaded Wii U title
-func DecryptContents(path string, progressReporter ProgressReporter, deleteEncryptedContents bool) error {
+func DecryptContents(ctx context.Context, path string, progressReporter ProgressReporter, deleteEncryptedContents bool) error {
 	tmdPath := filepath.Join(path, "title.tmd")
 	if _, err := os.Stat(tmdPath); os.IsNotExist(err) {
 		return err
@@ -722,6 +749,9 @@ func DecryptContents(path string, progressReporter ProgressReporter, deleteEncry
 		return fmt.Errorf("failed to create AES cipher: %w", err)
 	}
 
+	progress := &decryptionProgress{ctx: ctx, reporter: progressReporter}
+	progressReporter.UpdateDecryptionProgress(0)
+
 	if tmd.Version == TMD_VERSION_WIIU {
 		fstEncFile, err := os.Open(filepath.Join(path, tmd.Contents[0].CIDStr+".app"))
 		if err != nil {
@@ -749,7 +779,15 @@ func DecryptContents(path string, progressReporter ProgressReporter, deleteEncry
 		level := uint32(0)
 
 		for i := uint32(0); i < fst.Entries-1; i++ {
-			progressReporter.UpdateDecryptionProgress(float64(i) / float64(fst.Entries-1))
+			if fst.FSTEntries[i].Type&1 == 0 && fst.FSTEntries[i].Type&0x80 == 0 {
+				progress.total += uint64(fst.FSTEntries[i].Length)
+			}
+		}
+
+		for i := uint32(0); i < fst.Entries-1; i++ {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
 			if level > 0 {
 				for (level >= 1) && (lEntry[level-1] == i+1) {
 					level--
@@ -797,9 +835,9 @@ func DecryptContents(path string, progressReporter ProgressReporter, deleteEncry
 						return err
 					}
 					if tmdFlags&0x02 != 0 {
-						err = extractFileHash(srcFile, 0, contentOffset, uint64(fst.FSTEntries[i].Length), outputPath, cipherHashTree)
+						err = extractFileHash(srcFile, 0, contentOffset, uint64(fst.FSTEntries[i].Length), outputPath, cipherHashTree, progress)
 					} else {
-						err = extractFile(srcFile, 0, contentOffset, uint64(fst.FSTEntries[i].Length), outputPath, fst.FSTEntries[i].ContentID, cipherHashTree)
+						err = extractFile(srcFile, 0, contentOffset, uint64(fst.FSTEntries[i].Length), outputPath, fst.FSTEntries[i].ContentID, cipherHashTree, progress)
 					}
 					srcFile.Close()
 					if err != nil {
@@ -809,8 +847,14 @@ func DecryptContents(path string, progressReporter ProgressReporter, deleteEncry
 			}
 		}
 	} else {
+		for _, content := range tmd.Contents {
+			progress.total += content.Size
+		}
+
 		for i, content := range tmd.Contents {
-			progressReporter.UpdateDecryptionProgress(float64(i) / float64(len(tmd.Contents)))
+			if err := ctx.Err(); err != nil {
+				return err
+			}
 			srcFile, err := os.Open(filepath.Join(path, content.CIDStr+".app"))
 			if err != nil {
 				return err
@@ -864,6 +908,9 @@ func DecryptContents(path string, progressReporter ProgressReporter, deleteEncry
 			if !foundU8 {
 				_ = os.WriteFile(filepath.Join(path, content.CIDStr+".app"), decData, 0644)
 			}
+			if err := progress.add(int(content.Size)); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -22,8 +22,6 @@ const (
 	NintendoCDNBaseURL     = "http://ccs.cdn.c.shop.nintendowifi.net/ccs/download"
 )
 
-var errCancel = fmt.Errorf("cancelled download")
-
 // WatchdogReader wraps a reader with a timeout timer
 type WatchdogReader struct {
 	io.Reader
@@ -46,8 +44,6 @@ type ProgressReporter interface {
 	SetGameTitle(title string)
 	UpdateDownloadProgress(downloaded int64, filename string)
 	UpdateDecryptionProgress(progress float64)
-	Cancelled() bool
-	SetCancelled()
 	SetDownloadSize(size int64)
 	ResetTotals()
 	MarkFileAsDone(filename string)
@@ -55,6 +51,18 @@ type ProgressReporter interface {
 	SetStartTime(startTime time.Time)
 }
 
+// sleep waits for d, returning early with ctx's error if it's cancelled
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // writerProgress wraps a writer with progress reporting
 type writerProgress struct {
 	w        io.Writer
@@ -85,27 +93,37 @@ func (wp *writerProgress) Close() error {
 	return nil
 }
 
-func downloadFileWithSemaphore(ctx context.Context, progressReporter ProgressReporter, client *http.Client, downloadURL, dstPath string, doRetries bool, sem *semaphore.Weighted) error {
+// downloadFileWithSemaphore downloads a title's content file to dstPath via
+// dstPath.part. A file already completed by an earlier attempt, with the
+// expected size if it's known (>= 0), is kept instead of downloaded again.
+func downloadFileWithSemaphore(ctx context.Context, progressReporter ProgressReporter, client *http.Client, downloadURL, dstPath string, size int64, doRetries bool, sem *semaphore.Weighted) error {
+	if alreadyDownloaded(progressReporter, dstPath, size) {
+		return nil
+	}
 	if err := sem.Acquire(ctx, 1); err != nil {
 		return err
 	}
 	defer sem.Release(1)
 
 	basePath := filepath.Base(dstPath)
+	partPath := dstPath + ".part"
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
-		ctx, cancel := context.WithCancel(ctx)
+		// The watchdog cancels just this attempt; cancelling ctx stops them all
+		attemptCtx, cancel := context.WithCancel(ctx)
 		defer cancel()
 
-		req, err := http.NewRequestWithContext(ctx, "GET", downloadURL, nil)
+		req, err := http.NewRequestWithContext(attemptCtx, "GET", downloadURL, nil)
 		if err != nil {
 			return err
 		}
 
 		resp, err := client.Do(req)
 		if err != nil {
-			if doRetries && attempt < maxRetries && !progressReporter.Cancelled() {
-				time.Sleep(retryDelay)
+			if doRetries && attempt < maxRetries && ctx.Err() == nil {
+				if err := sleep(ctx, retryDelay); err != nil {
+					return err
+				}
 				continue
 			}
 			return err
@@ -113,14 +131,16 @@ func downloadFileWithSemaphore(ctx context.Context, progressReporter ProgressRep
 
 		if resp.StatusCode != http.StatusOK {
 			resp.Body.Close()
-			if doRetries && attempt < maxRetries && !progressReporter.Cancelled() {
-				time.Sleep(retryDelay)
+			if doRetries && attempt < maxRetries && ctx.Err() == nil {
+				if err := sleep(ctx, retryDelay); err != nil {
+					return err
+				}
 				continue
 			}
 			return fmt.Errorf("download error after %d attempts, status code: %d", attempt, resp.StatusCode)
 		}
 
-		file, err := os.Create(dstPath)
+		file, err := os.Create(partPath)
 		if err != nil {
 			resp.Body.Close()
 			return err
@@ -132,7 +152,7 @@ func downloadFileWithSemaphore(ctx context.Context, progressReporter ProgressRep
 
 		progressReporter.SetTotalDownloadedForFile(basePath, 0)
 		writerProgress := newWriterProgress(file, progressReporter, basePath)
-		writerProgressWithContext := ctxio.NewWriter(ctx, writerProgress)
+		writerProgressWithContext := ctxio.NewWriter(attemptCtx, writerProgress)
 
 		watchdog := &WatchdogReader{
 			Reader: resp.Body,
@@ -146,8 +166,10 @@ func downloadFileWithSemaphore(ctx context.Context, progressReporter ProgressRep
 			file.Close()
 			resp.Body.Close()
 			writerProgress.Close()
-			if doRetries && attempt < maxRetries && !progressReporter.Cancelled() {
-				time.Sleep(retryDelay)
+			if doRetries && attempt < maxRetries && ctx.Err() == nil {
+				if err := sleep(ctx, retryDelay); err != nil {
+					return err
+				}
 				continue
 			}
 			return err
@@ -155,6 +177,10 @@ func downloadFileWithSemaphore(ctx context.Context, progressReporter ProgressRep
 		file.Close()
 		resp.Body.Close()
 		writerProgress.Close()
+		// Only a complete file gets its real name, so a retry can skip it
+		if err := os.Rename(partPath, dstPath); err != nil {
+			return err
+		}
 		progressReporter.MarkFileAsDone(basePath)
 		break
 	}
@@ -162,12 +188,12 @@ func downloadFileWithSemaphore(ctx context.Context, progressReporter ProgressRep
 	return nil
 }
 
-func downloadFile(progressReporter ProgressReporter, client *http.Client, downloadURL, dstPath string, doRetries bool) error {
+func downloadFile(ctx context.Context, progressReporter ProgressReporter, client *http.Client, downloadURL, dstPath string, doRetries bool) error {
 	for attempt := 1; attempt <= maxRetries; attempt++ {
-		ctx, cancel := context.WithCancel(context.Background())
+		attemptCtx, cancel := context.WithCancel(ctx)
 		defer cancel()
 
-		req, err := http.NewRequestWithContext(ctx, "GET", downloadURL, nil)
+		req, err := http.NewRequestWithContext(attemptCtx, "GET", downloadURL, nil)
 		if err != nil {
 			return err
 		}
@@ -176,8 +202,10 @@ func downloadFile(progressReporter ProgressReporter, client *http.Client, downlo
 
 		resp, err := client.Do(req)
 		if err != nil {
-			if doRetries && attempt < maxRetries && !progressReporter.Cancelled() {
-				time.Sleep(retryDelay)
+			if doRetries && attempt < maxRetries && ctx.Err() == nil {
+				if err := sleep(ctx, retryDelay); err != nil {
+					return err
+				}
 				continue
 			}
 			return err
@@ -185,8 +213,10 @@ func downloadFile(progressReporter ProgressReporter, client *http.Client, downlo
 
 		if resp.StatusCode != http.StatusOK {
 			resp.Body.Close()
-			if doRetries && attempt < maxRetries && !progressReporter.Cancelled() {
-				time.Sleep(retryDelay)
+			if doRetries && attempt < maxRetries && ctx.Err() == nil {
+				if err := sleep(ctx, retryDelay); err != nil {
+					return err
+				}
 				continue
 			}
 			return fmt.Errorf("download error after %d attempts, status code: %d", attempt, resp.StatusCode)
@@ -216,8 +246,10 @@ func downloadFile(progressReporter ProgressReporter, client *http.Client, downlo
 		if err != nil {
 			file.Close()
 			resp.Body.Close()
-			if doRetries && attempt < maxRetries && !progressReporter.Cancelled() {
-				time.Sleep(retryDelay)
+			if doRetries && attempt < maxRetries && ctx.Err() == nil {
+				if err := sleep(ctx, retryDelay); err != nil {
+					return err
+				}
 				continue
 			}
 			return err
@@ -231,8 +263,23 @@ func downloadFile(progressReporter ProgressReporter, client *http.Client, downlo
 	return nil
 }
 
-// DownloadTitle downloads and optionally decrypts a Wii U title
-func DownloadTitle(titleID, outputDirectory string, doDecryption bool, progressReporter ProgressReporter, deleteEncryptedContents bool, client *http.Client) error {
+// alreadyDownloaded reports whether dstPath was completed by an earlier
+// attempt, and if so counts it towards progress. Files only get their final
+// name once complete, so one with that name is whole.
+func alreadyDownloaded(progressReporter ProgressReporter, dstPath string, size int64) bool {
+	info, err := os.Stat(dstPath)
+	if err != nil || info.IsDir() || (size >= 0 && info.Size() != size) {
+		return false
+	}
+	basePath := filepath.Base(dstPath)
+	progressReporter.SetTotalDownloadedForFile(basePath, info.Size())
+	progressReporter.MarkFileAsDone(basePath)
+	return true
+}
+
+// DownloadTitle downloads and optionally decrypts a Wii U title. Cancelling
+// ctx aborts any request in flight, and DownloadTitle returns ctx's error.
+func DownloadTitle(ctx context.Context, titleID, outputDirectory string, doDecryption bool, progressReporter ProgressReporter, deleteEncryptedContents bool, client *http.Client) error {
 	progressReporter.ResetTotals()
 	progressReporter.SetGameTitle(titleID)
 
@@ -247,9 +294,9 @@ func DownloadTitle(titleID, outputDirectory string, doDecryption bool, progressR
 	}
 
 	tmdPath := filepath.Join(outputDir, "title.tmd")
-	if err := downloadFile(progressReporter, client, fmt.Sprintf("%s/%s", baseURL, "tmd"), tmdPath, true); err != nil {
-		if progressReporter.Cancelled() {
-			return nil
+	if err := downloadFile(ctx, progressReporter, client, fmt.Sprintf("%s/%s", baseURL, "tmd"), tmdPath, true); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
 		}
 		return err
 	}
@@ -265,9 +312,9 @@ func DownloadTitle(titleID, outputDirectory string, doDecryption bool, progressR
 	}
 
 	tikPath := filepath.Join(outputDir, "title.tik")
-	if err := downloadFile(progressReporter, client, fmt.Sprintf("%s/%s", baseURL, "cetk"), tikPath, false); err != nil {
-		if progressReporter.Cancelled() {
-			return nil
+	if err := downloadFile(ctx, progressReporter, client, fmt.Sprintf("%s/%s", baseURL, "cetk"), tikPath, false); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
 		}
 		titleKey, err := GenerateKey(titleID)
 		if err != nil {
@@ -285,14 +332,14 @@ func DownloadTitle(titleID, outputDirectory string, doDecryption bool, progressR
 
 	progressReporter.SetDownloadSize(int64(titleSize))
 
-	if err := GenerateCert(tmd, filepath.Join(outputDir, "title.cert"), progressReporter, client); err != nil {
-		if progressReporter.Cancelled() {
-			return nil
+	if err := GenerateCert(ctx, tmd, filepath.Join(outputDir, "title.cert"), progressReporter, client); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
 		}
 		return err
 	}
 
-	g, ctx := errgroup.WithContext(context.Background())
+	g, gctx := errgroup.WithContext(ctx)
 	g.SetLimit(maxConcurrentDownloads)
 	sem := semaphore.NewWeighted(maxConcurrentDownloads)
 	progressReporter.SetStartTime(time.Now())
@@ -301,38 +348,29 @@ func DownloadTitle(titleID, outputDirectory string, doDecryption bool, progressR
 		i := i
 		g.Go(func() error {
 			filePath := filepath.Join(outputDir, fmt.Sprintf("%08X.app", tmd.Contents[i].ID))
-			if err := downloadFileWithSemaphore(ctx, progressReporter, client, fmt.Sprintf("%s/%08X", baseURL, tmd.Contents[i].ID), filePath, true, sem); err != nil {
-				if progressReporter.Cancelled() {
-					return errCancel
-				}
+			if err := downloadFileWithSemaphore(gctx, progressReporter, client, fmt.Sprintf("%s/%08X", baseURL, tmd.Contents[i].ID), filePath, int64(tmd.Contents[i].Size), true, sem); err != nil {
 				return err
 			}
 
 			if tmd.Contents[i].Type&0x2 == 2 { // has a hash
 				filePath = filepath.Join(outputDir, fmt.Sprintf("%08X.h3", tmd.Contents[i].ID))
-				if err := downloadFileWithSemaphore(ctx, progressReporter, client, fmt.Sprintf("%s/%08X.h3", baseURL, tmd.Contents[i].ID), filePath, true, sem); err != nil {
-					if progressReporter.Cancelled() {
-						return errCancel
-					}
+				if err := downloadFileWithSemaphore(gctx, progressReporter, client, fmt.Sprintf("%s/%08X.h3", baseURL, tmd.Contents[i].ID), filePath, -1, true, sem); err != nil {
 					return err
 				}
 			}
-			if progressReporter.Cancelled() {
-				return errCancel
-			}
 			return nil
 		})
 	}
 
 	if err := g.Wait(); err != nil {
-		if err == errCancel {
-			return nil
+		if ctx.Err() != nil {
+			return ctx.Err()
 		}
 		return err
 	}
 
-	if doDecryption && !progressReporter.Cancelled() {
-		if err := DecryptContents(outputDir, progressReporter, deleteEncryptedContents); err != nil {
+	if doDecryption {
+		if err := DecryptContents(ctx, outputDir, progressReporter, deleteEncryptedContents); err != nil {
 			return err
 		}
 	}
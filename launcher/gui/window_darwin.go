@@ -3,6 +3,8 @@
 package main
 
 import (
+	"fmt"
+	"os"
 	"os/exec"
 	"strings"
 	"sync"
@@ -73,3 +75,19 @@ func checkWindowFocus(windowTitle string) bool {
 	// If we can't detect, assume focused to not block input
 	return true
 }
+
+// focusOwnWindow brings the launcher's process to the front. The process is
+// matched by PID, so it works whatever the app bundle is called.
+func focusOwnWindow(windowTitle string) {
+	script := fmt.Sprintf(`tell application "System Events" to set frontmost of (first process whose unix id is %d) to true`, os.Getpid())
+	if err := exec.Command("osascript", "-e", script).Run(); err != nil {
+		logWarn("Could not refocus window: %v", err)
+	}
+}
+
+// focusProcessWindow brings the process to the front, reporting whether it
+// could
+func focusProcessWindow(pid int) bool {
+	script := fmt.Sprintf(`tell application "System Events" to set frontmost of (first process whose unix id is %d) to true`, pid)
+	return exec.Command("osascript", "-e", script).Run() == nil
+}
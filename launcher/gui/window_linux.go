@@ -3,13 +3,26 @@
 package main
 
 import (
+	"encoding/json"
+	"os"
 	"os/exec"
+	"strconv"
 	"strings"
+	"sync"
 )
 
+var focusUnknownOnce sync.Once
+
 // isWindowFocused checks if a window with the given title is focused.
-// Uses xdotool to get the active window title on Linux/X11.
+// On Wayland the compositor is asked which process owns the focused window;
+// on X11 (and as a fallback) xdotool/xprop give the active window title.
 func isWindowFocused(windowTitle string) bool {
+	if isWaylandSession() {
+		if pid, ok := waylandFocusedPID(); ok {
+			return pid == os.Getpid()
+		}
+	}
+
 	// Try xdotool first (X11)
 	cmd := exec.Command("xdotool", "getactivewindow", "getwindowname")
 	output, err := cmd.Output()
@@ -27,5 +40,105 @@ func isWindowFocused(windowTitle string) bool {
 	}
 
 	// If we can't detect, assume focused to not block input
+	focusUnknownOnce.Do(func() {
+		logWarn("Window focus can't be detected on this desktop; controller input is always handled")
+	})
 	return true
 }
+
+// isWaylandSession reports whether the desktop is a Wayland session. The
+// launcher itself may still run under XWayland, where xdotool only sees X
+// windows and can't tell when a native Wayland window has focus.
+func isWaylandSession() bool {
+	return os.Getenv("WAYLAND_DISPLAY") != "" || os.Getenv("XDG_SESSION_TYPE") == "wayland"
+}
+
+// waylandFocusedPID returns the PID owning the focused window, for the
+// compositors that expose it: Hyprland, Sway, GNOME (only when Shell.Eval is
+// allowed) and KDE (with kdotool installed)
+func waylandFocusedPID() (int, bool) {
+	if os.Getenv("HYPRLAND_INSTANCE_SIGNATURE") != "" {
+		if out, err := exec.Command("hyprctl", "activewindow", "-j").Output(); err == nil {
+			var window struct {
+				PID int `json:"pid"`
+			}
+			if json.Unmarshal(out, &window) == nil && window.PID > 0 {
+				return window.PID, true
+			}
+		}
+	}
+
+	if os.Getenv("SWAYSOCK") != "" {
+		if out, err := exec.Command("swaymsg", "-t", "get_tree").Output(); err == nil {
+			var tree swayNode
+			if json.Unmarshal(out, &tree) == nil {
+				// A focused empty workspace has no PID, which is still "not us"
+				if pid, found := tree.focusedPID(); found {
+					return pid, true
+				}
+			}
+		}
+	}
+
+	// GNOME answers (true, '1234'), or (false, '') when Eval is disabled
+	out, err := exec.Command("gdbus", "call", "--session",
+		"--dest", "org.gnome.Shell", "--object-path", "/org/gnome/Shell",
+		"--method", "org.gnome.Shell.Eval",
+		"global.display.focus_window ? global.display.focus_window.get_pid() : 0").Output()
+	if err == nil {
+		reply := strings.TrimSpace(string(out))
+		if strings.HasPrefix(reply, "(true,") {
+			value := strings.Trim(strings.TrimPrefix(reply, "(true,"), " ')\"")
+			if pid, err := strconv.Atoi(value); err == nil && pid > 0 {
+				return pid, true
+			}
+		}
+	}
+
+	if out, err := exec.Command("kdotool", "getactivewindow", "getwindowpid").Output(); err == nil {
+		if pid, err := strconv.Atoi(strings.TrimSpace(string(out))); err == nil && pid > 0 {
+			return pid, true
+		}
+	}
+
+	return 0, false
+}
+
+// swayNode is the part of `swaymsg -t get_tree` needed to find the focused window
+type swayNode struct {
+	Focused       bool       `json:"focused"`
+	PID           int        `json:"pid"`
+	Nodes         []swayNode `json:"nodes"`
+	FloatingNodes []swayNode `json:"floating_nodes"`
+}
+
+func (n swayNode) focusedPID() (int, bool) {
+	if n.Focused {
+		return n.PID, true
+	}
+	for _, child := range append(n.Nodes, n.FloatingNodes...) {
+		if pid, found := child.focusedPID(); found {
+			return pid, true
+		}
+	}
+	return 0, false
+}
+
+// focusOwnWindow raises and focuses the window with the given title.
+// Uses xdotool, falling back to wmctrl; does nothing if neither works.
+func focusOwnWindow(windowTitle string) {
+	err := exec.Command("xdotool", "search", "--name", "^"+windowTitle+"$", "windowactivate").Run()
+	if err == nil {
+		return
+	}
+	if err := exec.Command("wmctrl", "-a", windowTitle).Run(); err != nil {
+		logWarn("Could not refocus window: %v", err)
+	}
+}
+
+// focusProcessWindow raises and focuses the process's window with xdotool,
+// reporting whether that worked. On Wayland, and for Flatpaks, it usually
+// can't.
+func focusProcessWindow(pid int) bool {
+	return exec.Command("xdotool", "search", "--onlyvisible", "--pid", strconv.Itoa(pid), "windowactivate").Run() == nil
+}
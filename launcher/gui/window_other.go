@@ -7,3 +7,11 @@ package main
 func isWindowFocused(windowTitle string) bool {
 	return true
 }
+
+// focusOwnWindow raises the window with the given title.
+// Fallback for unsupported platforms - does nothing.
+func focusOwnWindow(windowTitle string) {}
+
+// focusProcessWindow brings the process's window to the front.
+// Fallback for unsupported platforms - does nothing.
+func focusProcessWindow(pid int) bool { return false }
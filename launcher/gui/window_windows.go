@@ -3,6 +3,7 @@
 package main
 
 import (
+	"sync"
 	"syscall"
 	"unsafe"
 )
@@ -13,8 +14,17 @@ var (
 	procGetWindowThreadProcessId = user32.NewProc("GetWindowThreadProcessId")
 	kernel32                     = syscall.NewLazyDLL("kernel32.dll")
 	procGetCurrentProcessId      = kernel32.NewProc("GetCurrentProcessId")
+	procFindWindowW              = user32.NewProc("FindWindowW")
+	procIsIconic                 = user32.NewProc("IsIconic")
+	procShowWindow               = user32.NewProc("ShowWindow")
+	procBringWindowToTop         = user32.NewProc("BringWindowToTop")
+	procSetForegroundWindow      = user32.NewProc("SetForegroundWindow")
+	procEnumWindows              = user32.NewProc("EnumWindows")
+	procIsWindowVisible          = user32.NewProc("IsWindowVisible")
 )
 
+const swRestore = 9
+
 func isWindowFocused(windowTitle string) bool {
 	hwnd, _, _ := procGetForegroundWindow.Call()
 	if hwnd == 0 {
@@ -33,3 +43,62 @@ func isWindowFocused(windowTitle string) bool {
 	currentPid, _, _ := procGetCurrentProcessId.Call()
 	return processId == uint32(currentPid)
 }
+
+// focusOwnWindow restores and brings to the front the top-level window with
+// the given title
+func focusOwnWindow(windowTitle string) {
+	title, err := syscall.UTF16PtrFromString(windowTitle)
+	if err != nil {
+		return
+	}
+	hwnd, _, _ := procFindWindowW.Call(0, uintptr(unsafe.Pointer(title)))
+	if hwnd == 0 {
+		logWarn("Could not refocus window: %q not found", windowTitle)
+		return
+	}
+	raiseWindow(hwnd)
+}
+
+// raiseWindow restores a window if it's minimized and brings it to the front
+func raiseWindow(hwnd uintptr) {
+	if iconic, _, _ := procIsIconic.Call(hwnd); iconic != 0 {
+		procShowWindow.Call(hwnd, swRestore)
+	}
+	procBringWindowToTop.Call(hwnd)
+	procSetForegroundWindow.Call(hwnd)
+}
+
+// The EnumWindows callback is made once (Windows only allows so many) and
+// finds the first visible window of windowSearch.pid
+var (
+	windowSearch struct {
+		sync.Mutex
+		pid  uint32
+		hwnd uintptr
+	}
+	findProcessWindow = syscall.NewCallback(func(hwnd, _ uintptr) uintptr {
+		var processId uint32
+		procGetWindowThreadProcessId.Call(hwnd, uintptr(unsafe.Pointer(&processId)))
+		if processId == windowSearch.pid {
+			if visible, _, _ := procIsWindowVisible.Call(hwnd); visible != 0 {
+				windowSearch.hwnd = hwnd
+				return 0
+			}
+		}
+		return 1
+	})
+)
+
+// focusProcessWindow brings the process's window to the front, reporting
+// whether it found one
+func focusProcessWindow(pid int) bool {
+	windowSearch.Lock()
+	defer windowSearch.Unlock()
+	windowSearch.pid, windowSearch.hwnd = uint32(pid), 0
+	procEnumWindows.Call(findProcessWindow, 0)
+	if windowSearch.hwnd == 0 {
+		return false
+	}
+	raiseWindow(windowSearch.hwnd)
+	return true
+}
@@ -0,0 +1,461 @@
+// Package download fetches a URL to a file for the launcher, the installer
+// and romget, so headers, retries, resuming and parallel ranges behave (and
+// get fixed) the same in all three.
+//
+// Data is written to dest+".part" and renamed to dest once complete. Large
+// files on servers that support byte ranges are split across several
+// connections; the ranges done so far are kept in dest+".part.json" so a
+// resumed download continues each one where it stopped.
+package download
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultUserAgent is sent when Options.UserAgent is empty
+	DefaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36"
+	// DefaultMinChunkSize is the smallest range a parallel download is split into
+	DefaultMinChunkSize = 4 * 1024 * 1024
+	// MaxWorkers caps Options.Workers
+	MaxWorkers = 16
+)
+
+// Options tunes a download. The zero value downloads in one stream, without
+// retries, and removes the partial file if the download fails.
+type Options struct {
+	Referer   string
+	UserAgent string
+	// Header holds extra request headers, e.g. Accept-Language. They're set
+	// last, so a User-Agent or Referer here wins over the fields above.
+	Header http.Header
+
+	// Retries is how many times a failed request (a range, or the single
+	// stream) is tried again, each continuing from the last byte written.
+	// Rate-limited responses don't use up a retry.
+	Retries int
+	// Workers is how many connections a large file is split across; 1 or
+	// less downloads in one stream
+	Workers int
+	// MinChunkSize is the smallest range per connection (default
+	// DefaultMinChunkSize)
+	MinChunkSize int64
+	// MinParallelSize is the smallest file split across connections (default
+	// twice MinChunkSize)
+	MinParallelSize int64
+	// Limiter, if set, caps throughput; one Limiter may be shared by several
+	// downloads to cap their total
+	Limiter *Limiter
+	// Resume keeps the partial file when a download fails, and continues
+	// from it on the next call, instead of starting over
+	Resume bool
+
+	// Progress is called with the bytes on disk so far and the total (-1 if
+	// unknown). Parallel ranges call it from several goroutines.
+	Progress func(downloaded, total int64)
+	// Verify, if set, checks the finished file before it's renamed into
+	// place; its error is returned as is and the file is removed
+	Verify func(path string) error
+	// Logf receives notes about how the download goes (resuming, falling
+	// back to one stream, retrying)
+	Logf func(format string, args ...interface{})
+	// Client is used for every request (default: one tuned for large files,
+	// without an overall timeout)
+	Client *http.Client
+	// Proxy is the proxy URL the default Client goes through, overriding
+	// HTTP_PROXY/HTTPS_PROXY/NO_PROXY (see ParseProxy). It's ignored when
+	// Client is set.
+	Proxy string
+}
+
+// ErrRangeIgnored means a ranged request came back as the whole file
+var ErrRangeIgnored = errors.New("server does not support range requests")
+
+// StatusError is a response with an unexpected status code
+type StatusError struct {
+	Status int
+	Detail string // e.g. "for range 0-99", may be empty
+}
+
+func (e *StatusError) Error() string {
+	if e.Detail != "" {
+		return fmt.Sprintf("HTTP %d %s", e.Status, e.Detail)
+	}
+	return fmt.Sprintf("HTTP %d", e.Status)
+}
+
+// PartPath is where a download to dest keeps its data until it's complete
+func PartPath(dest string) string { return dest + ".part" }
+
+// ManifestPath is where a parallel download to dest keeps its ranges' progress
+func ManifestPath(dest string) string { return dest + ".part.json" }
+
+// DiscardPartial removes any partial download for dest
+func DiscardPartial(dest string) {
+	os.Remove(PartPath(dest))
+	os.Remove(ManifestPath(dest))
+}
+
+// Download fetches url to dest. A file large enough for opts.Workers
+// connections is downloaded in parallel ranges when the server supports them,
+// otherwise in one stream.
+func Download(ctx context.Context, url, dest string, opts Options) error {
+	if err := opts.defaults(); err != nil {
+		return err
+	}
+	if !opts.Resume {
+		DiscardPartial(dest)
+	}
+	err := download(ctx, url, dest, &opts)
+	if err != nil && !opts.Resume {
+		DiscardPartial(dest)
+	}
+	return err
+}
+
+func (o *Options) defaults() error {
+	if o.UserAgent == "" {
+		o.UserAgent = DefaultUserAgent
+	}
+	if o.Workers > MaxWorkers {
+		o.Workers = MaxWorkers
+	}
+	if o.MinChunkSize <= 0 {
+		o.MinChunkSize = DefaultMinChunkSize
+	}
+	if o.MinParallelSize <= 0 {
+		o.MinParallelSize = o.MinChunkSize * 2
+	}
+	if o.Progress == nil {
+		o.Progress = func(int64, int64) {}
+	}
+	if o.Logf == nil {
+		o.Logf = func(string, ...interface{}) {}
+	}
+	if o.Client == nil {
+		transport, err := NewTransport(o.Proxy)
+		if err != nil {
+			return err
+		}
+		o.Client = &http.Client{Transport: transport}
+	}
+	return nil
+}
+
+func download(ctx context.Context, url, dest string, opts *Options) error {
+	// A single-stream .part without a manifest is resumed as a single
+	// stream; splitting it into ranges would throw it away
+	singlePart := fileExists(PartPath(dest)) && !fileExists(ManifestPath(dest))
+
+	var size int64 = -1
+	if opts.Workers > 1 && !singlePart {
+		var ranges bool
+		var err error
+		size, ranges, err = probe(ctx, url, opts)
+		if err != nil {
+			return err
+		}
+		if ranges && size >= opts.MinParallelSize {
+			opts.Logf("Downloading %s from %s over %d connections", formatBytes(size), Host(url), opts.Workers)
+			err := downloadParallel(ctx, url, dest, size, opts)
+			if !errors.Is(err, ErrRangeIgnored) {
+				return err
+			}
+			opts.Logf("Server ignored the range request, downloading in one stream instead")
+			DiscardPartial(dest)
+		}
+	}
+	return downloadSingle(ctx, url, dest, size, opts)
+}
+
+// probe asks for the file's size and whether the server serves byte ranges.
+// Only a rate-limited or failing server is an error; anything else just
+// means the download goes in one stream.
+func probe(ctx context.Context, url string, opts *Options) (int64, bool, error) {
+	req, err := newRequest(ctx, "HEAD", url, opts)
+	if err != nil {
+		return -1, false, err
+	}
+	resp, err := opts.Client.Do(req)
+	if err != nil {
+		return -1, false, err
+	}
+	resp.Body.Close()
+	if err := checkRateLimited(resp); err != nil {
+		return -1, false, err
+	}
+	if resp.StatusCode >= 500 {
+		return -1, false, &StatusError{Status: resp.StatusCode}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return -1, false, nil
+	}
+	return resp.ContentLength, resp.Header.Get("Accept-Ranges") == "bytes" && resp.ContentLength > 0, nil
+}
+
+func newRequest(ctx context.Context, method, url string, opts *Options) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", opts.UserAgent)
+	req.Header.Set("Accept", "*/*")
+	if opts.Referer != "" {
+		req.Header.Set("Referer", opts.Referer)
+	}
+	for key, values := range opts.Header {
+		req.Header[http.CanonicalHeaderKey(key)] = values
+	}
+	return req, nil
+}
+
+// downloadSingle downloads in one stream, retrying from the last byte written
+// when the server supports ranges and from the start when it doesn't
+func downloadSingle(ctx context.Context, url, dest string, size int64, opts *Options) error {
+	// A manifest means the .part was laid out by downloadParallel at full
+	// size, which says nothing about how much of it was downloaded
+	if fileExists(ManifestPath(dest)) {
+		DiscardPartial(dest)
+	}
+
+	backoff := &poolBackoff{}
+	var lastErr error
+	for attempt, strikes := 0, 0; attempt <= opts.Retries; {
+		if err := backoff.wait(ctx); err != nil {
+			return err
+		}
+		err := singleAttempt(ctx, url, dest, size, opts)
+		if err == nil {
+			return finish(dest, opts)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		lastErr = err
+
+		var limited *RateLimitedError
+		if errors.As(err, &limited) {
+			if strikes++; strikes > maxRateLimitStrikes {
+				return fmt.Errorf("still rate limited after %d attempts: %w", strikes, err)
+			}
+			opts.Logf("Rate limited (%v), waiting %s", err, backoff.trigger(limited.RetryAfter).Round(time.Second))
+			continue
+		}
+		if !retryable(err) {
+			return err
+		}
+		attempt++
+		if attempt <= opts.Retries {
+			opts.Logf("Download failed (%v), retrying (%d of %d)", err, attempt, opts.Retries)
+			if err := sleep(ctx, time.Duration(attempt)*time.Second); err != nil {
+				return err
+			}
+		}
+	}
+	if opts.Retries == 0 {
+		return lastErr
+	}
+	return fmt.Errorf("failed after %d retries: %w", opts.Retries, lastErr)
+}
+
+// retryable reports whether trying again might help: not for a 4xx other
+// than 408 (the file isn't there, or we're not allowed it)
+func retryable(err error) bool {
+	var status *StatusError
+	if errors.As(err, &status) {
+		return status.Status == http.StatusRequestTimeout || status.Status >= 500
+	}
+	return true
+}
+
+// singleAttempt makes one request for the file, or for the rest of it if a
+// .part is there, appending to the .part
+func singleAttempt(ctx context.Context, url, dest string, size int64, opts *Options) error {
+	partPath := PartPath(dest)
+	var offset int64
+	if info, err := os.Stat(partPath); err == nil {
+		offset = info.Size()
+		if size > 0 && offset >= size {
+			// As big as the whole file but never renamed, or bigger - don't
+			// trust it
+			os.Remove(partPath)
+			offset = 0
+		}
+	}
+
+	req, err := newRequest(ctx, "GET", url, opts)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := opts.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	total := resp.ContentLength
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	switch {
+	case offset > 0 && resp.StatusCode == http.StatusPartialContent:
+		// Check the server is resuming the file we think it is
+		rangeStart, rangeTotal := parseContentRange(resp.Header.Get("Content-Range"))
+		if rangeStart != offset || (size > 0 && rangeTotal != size) {
+			resp.Body.Close()
+			opts.Logf("Server resumed at the wrong place (Content-Range %q), starting over", resp.Header.Get("Content-Range"))
+			os.Remove(partPath)
+			return singleAttempt(ctx, url, dest, size, opts)
+		}
+		flags = os.O_WRONLY | os.O_APPEND
+		if total >= 0 {
+			total += offset
+		}
+		opts.Logf("Resuming from %s", formatBytes(offset))
+	case offset > 0 && resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		// The .part is as big as (or bigger than) the file - start over
+		resp.Body.Close()
+		os.Remove(partPath)
+		return singleAttempt(ctx, url, dest, size, opts)
+	case resp.StatusCode == http.StatusOK:
+		// A fresh download, or the server ignored the Range header
+		if offset > 0 {
+			opts.Logf("Server can't resume, starting over")
+		}
+		offset = 0
+	default:
+		if err := checkRateLimited(resp); err != nil {
+			return err
+		}
+		return &StatusError{Status: resp.StatusCode}
+	}
+
+	out, err := os.OpenFile(partPath, flags, 0644)
+	if err != nil {
+		return err
+	}
+	buffered := bufio.NewWriterSize(out, 1024*1024)
+
+	downloaded := offset
+	opts.Progress(downloaded, total)
+	var copyErr error
+	buf := make([]byte, 1024*1024)
+	for {
+		n, err := resp.Body.Read(buf)
+		if n > 0 {
+			if _, writeErr := buffered.Write(buf[:n]); writeErr != nil {
+				copyErr = writeErr
+				break
+			}
+			downloaded += int64(n)
+			opts.Progress(downloaded, total)
+			if waitErr := opts.Limiter.Wait(ctx, n); waitErr != nil {
+				copyErr = waitErr
+				break
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			copyErr = err
+			break
+		}
+	}
+
+	flushErr := buffered.Flush()
+	closeErr := out.Close()
+	switch {
+	case copyErr != nil:
+		return copyErr
+	case flushErr != nil:
+		return flushErr
+	case closeErr != nil:
+		return closeErr
+	case total > 0 && downloaded != total:
+		return fmt.Errorf("download incomplete: got %d of %d bytes", downloaded, total)
+	}
+	return nil
+}
+
+// finish verifies a complete .part and moves it into place
+func finish(dest string, opts *Options) error {
+	partPath := PartPath(dest)
+	if opts.Verify != nil {
+		if err := opts.Verify(partPath); err != nil {
+			DiscardPartial(dest)
+			return err
+		}
+	}
+	os.Remove(ManifestPath(dest))
+	return os.Rename(partPath, dest)
+}
+
+// parseContentRange parses "bytes 100-199/1000" into start (100) and total
+// (1000). Missing values are returned as -1.
+func parseContentRange(header string) (int64, int64) {
+	start, total := int64(-1), int64(-1)
+	header = strings.TrimPrefix(header, "bytes ")
+	slash := strings.Index(header, "/")
+	if slash < 0 {
+		return start, total
+	}
+	if n, err := strconv.ParseInt(header[slash+1:], 10, 64); err == nil {
+		total = n
+	}
+	if dash := strings.Index(header[:slash], "-"); dash > 0 {
+		if n, err := strconv.ParseInt(header[:dash], 10, 64); err == nil {
+			start = n
+		}
+	}
+	return start, total
+}
+
+// Host returns just the host of a URL, for messages
+func Host(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	return u.Host
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
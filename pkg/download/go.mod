@@ -0,0 +1,3 @@
+module github.com/emubuddy/download
+
+go 1.19
@@ -0,0 +1,167 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rate-limit backoff
+const (
+	rateLimitBaseDelay  = 2 * time.Second
+	rateLimitMaxDelay   = 2 * time.Minute
+	maxRateLimitStrikes = 8 // consecutive 429s a request tolerates before giving up
+)
+
+// RateLimitedError is returned for a 429, or a 503 that carries Retry-After
+type RateLimitedError struct {
+	Status     int
+	RetryAfter time.Duration // 0 if the server didn't say
+}
+
+func (e *RateLimitedError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("HTTP %d (rate limited, retry after %s)", e.Status, e.RetryAfter)
+	}
+	return fmt.Sprintf("HTTP %d (rate limited)", e.Status)
+}
+
+// checkRateLimited turns a 429/503 response into a RateLimitedError, or returns nil
+func checkRateLimited(resp *http.Response) error {
+	retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"))
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return &RateLimitedError{Status: resp.StatusCode, RetryAfter: retryAfter}
+	case resp.StatusCode == http.StatusServiceUnavailable && resp.Header.Get("Retry-After") != "":
+		return &RateLimitedError{Status: resp.StatusCode, RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// parseRetryAfter accepts both forms of Retry-After: seconds or an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// poolBackoff is shared by all workers of one download. When any worker is
+// rate-limited every worker pauses until the same deadline, instead of each
+// one retrying on its own schedule.
+type poolBackoff struct {
+	mu      sync.Mutex
+	until   time.Time
+	strikes int // rate-limited responses since the last successful one
+}
+
+// trigger records a rate-limited response and pushes the shared deadline out.
+// The pause honours Retry-After and grows exponentially, with jitter, while
+// the server keeps refusing.
+func (b *poolBackoff) trigger(retryAfter time.Duration) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delay := rateLimitBaseDelay << uint(b.strikes)
+	if delay > rateLimitMaxDelay || delay <= 0 {
+		delay = rateLimitMaxDelay
+	}
+	if retryAfter > delay {
+		delay = retryAfter
+	}
+	delay += time.Duration(rand.Int63n(int64(delay)/2 + 1))
+	b.strikes++
+
+	if until := time.Now().Add(delay); until.After(b.until) {
+		b.until = until
+	}
+	return time.Until(b.until)
+}
+
+// succeeded resets the exponential growth once the server accepts a request
+func (b *poolBackoff) succeeded() {
+	b.mu.Lock()
+	b.strikes = 0
+	b.mu.Unlock()
+}
+
+// wait blocks while the pool is backing off
+func (b *poolBackoff) wait(ctx context.Context) error {
+	b.mu.Lock()
+	delay := time.Until(b.until)
+	b.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+	return sleep(ctx, delay)
+}
+
+// Limiter is a token bucket capping the throughput of every download it's
+// given to, so the cap applies to the total rather than to each connection
+type Limiter struct {
+	// BytesPerSec returns the cap; 0 or less is unlimited. It's asked on
+	// every read, so the cap can change while downloads run.
+	BytesPerSec func() int64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter returns a Limiter with a fixed cap
+func NewLimiter(bytesPerSec int64) *Limiter {
+	return &Limiter{BytesPerSec: func() int64 { return bytesPerSec }}
+}
+
+// Wait blocks until n more bytes may be passed on. Callers read first and
+// then wait, so the bucket may go into debt by up to one read buffer. A nil
+// Limiter never waits.
+func (l *Limiter) Wait(ctx context.Context, n int) error {
+	if l == nil || l.BytesPerSec == nil {
+		return nil
+	}
+	rate := float64(l.BytesPerSec())
+	if rate <= 0 || n <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if l.last.IsZero() {
+		l.last = now
+		l.tokens = rate
+	}
+	l.tokens += now.Sub(l.last).Seconds() * rate
+	if l.tokens > rate {
+		l.tokens = rate // allow at most one second of burst
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+	return sleep(ctx, delay)
+}
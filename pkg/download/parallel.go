@@ -0,0 +1,292 @@
+package download
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// manifest is saved next to the .part file so a parallel download can pick
+// up where it left off
+type manifest struct {
+	URL       string  `json:"url"`
+	TotalSize int64   `json:"totalSize"`
+	Chunks    []chunk `json:"chunks"`
+}
+
+type chunk struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`  // inclusive
+	Done  int64 `json:"done"` // bytes completed from Start
+}
+
+// loadManifest returns the saved manifest if it matches this download and the
+// .part file is intact, or nil if the download has to start from scratch
+func loadManifest(url, dest string, totalSize int64, opts *Options) *manifest {
+	data, err := os.ReadFile(ManifestPath(dest))
+	if err != nil {
+		return nil
+	}
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil
+	}
+	if m.URL != url || m.TotalSize != totalSize || len(m.Chunks) == 0 {
+		opts.Logf("Partial download doesn't match (size %d vs %d), starting over", m.TotalSize, totalSize)
+		return nil
+	}
+	info, err := os.Stat(PartPath(dest))
+	if err != nil || info.Size() != totalSize {
+		return nil
+	}
+	return &m
+}
+
+func saveManifest(dest string, m *manifest) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(ManifestPath(dest), data, 0644)
+}
+
+// splitChunks divides a file into a range per worker, none smaller than
+// minChunkSize
+func splitChunks(totalSize int64, workers int, minChunkSize int64) []chunk {
+	chunkSize := totalSize / int64(workers)
+	if chunkSize < minChunkSize {
+		chunkSize = minChunkSize
+	}
+	var chunks []chunk
+	for start := int64(0); start < totalSize; start += chunkSize {
+		end := start + chunkSize - 1
+		if end >= totalSize {
+			end = totalSize - 1
+		}
+		chunks = append(chunks, chunk{Start: start, End: end})
+	}
+	return chunks
+}
+
+func downloadParallel(ctx context.Context, url, dest string, totalSize int64, opts *Options) error {
+	m := loadManifest(url, dest, totalSize, opts)
+	if m == nil {
+		DiscardPartial(dest)
+		m = &manifest{URL: url, TotalSize: totalSize, Chunks: splitChunks(totalSize, opts.Workers, opts.MinChunkSize)}
+	} else {
+		opts.Logf("Resuming partial download")
+	}
+
+	out, err := os.OpenFile(PartPath(dest), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	// Pre-allocate, so every range can be written in place
+	if err := out.Truncate(totalSize); err != nil {
+		out.Close()
+		return err
+	}
+
+	// The manifest holds per-range progress
+	var mu sync.Mutex
+	lastSave := time.Now()
+	updateProgress := func(idx int, done int64) {
+		mu.Lock()
+		m.Chunks[idx].Done = done
+		var total int64
+		for _, c := range m.Chunks {
+			total += c.Done
+		}
+		// Persist every couple of seconds so a crash loses little
+		if time.Since(lastSave) > 2*time.Second {
+			saveManifest(dest, m)
+			lastSave = time.Now()
+		}
+		mu.Unlock()
+		opts.Progress(total, totalSize)
+	}
+	if err := saveManifest(dest, m); err != nil {
+		out.Close()
+		return err
+	}
+
+	// A rate limit hit by one worker pauses them all
+	backoff := &poolBackoff{}
+	chunks := make(chan int, len(m.Chunks))
+	errs := make(chan error, opts.Workers)
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range chunks {
+				mu.Lock()
+				c := m.Chunks[idx]
+				mu.Unlock()
+				if err := downloadChunk(ctx, url, out, idx, c, backoff, opts, updateProgress); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+
+	// Queue the ranges that still have data missing
+	var alreadyDone int64
+	for idx, c := range m.Chunks {
+		alreadyDone += c.Done
+		if c.Start+c.Done <= c.End {
+			chunks <- idx
+		}
+	}
+	close(chunks)
+	opts.Progress(alreadyDone, totalSize)
+
+	wg.Wait()
+	close(errs)
+
+	mu.Lock()
+	saveManifest(dest, m)
+	mu.Unlock()
+	closeErr := out.Close()
+
+	// Keep the partial file so the download can be resumed
+	for err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	if closeErr != nil {
+		return closeErr
+	}
+	return finish(dest, opts)
+}
+
+// downloadChunk fetches what's left of one range, retrying up to
+// opts.Retries times from the last byte written
+func downloadChunk(ctx context.Context, url string, out *os.File, idx int, c chunk, backoff *poolBackoff, opts *Options, updateProgress func(idx int, done int64)) error {
+	var lastErr error
+	done := c.Done
+	strikes := 0
+
+	for attempt := 0; attempt <= opts.Retries; {
+		// Don't send anything while the pool is backing off from a rate limit
+		if err := backoff.wait(ctx); err != nil {
+			return err
+		}
+
+		n, err := downloadRange(ctx, url, out, c.Start+done, c.End, opts, func(written int64) {
+			updateProgress(idx, done+written)
+		})
+		done += n
+		if err == nil {
+			backoff.succeeded()
+			return nil
+		}
+		if ctx.Err() != nil || errors.Is(err, ErrRangeIgnored) {
+			return err
+		}
+		lastErr = err
+
+		// Rate limiting pauses every worker and doesn't use up a retry
+		var limited *RateLimitedError
+		if errors.As(err, &limited) {
+			strikes++
+			if strikes > maxRateLimitStrikes {
+				return fmt.Errorf("range %d-%d still rate limited after %d attempts: %w", c.Start, c.End, strikes, err)
+			}
+			delay := backoff.trigger(limited.RetryAfter)
+			opts.Logf("Rate limited on range %d-%d (%v), pausing all connections for %s", c.Start, c.End, err, delay.Round(time.Millisecond))
+			continue
+		}
+		// A 403 or 404 won't go away on a retry, as in the single-stream path
+		if !retryable(err) {
+			return lastErr
+		}
+
+		attempt++
+		if n > 0 {
+			backoff.succeeded()
+		}
+		if attempt <= opts.Retries {
+			if err := sleep(ctx, time.Duration(attempt)*time.Second); err != nil {
+				return err
+			}
+		}
+	}
+	if opts.Retries == 0 {
+		return lastErr
+	}
+	return fmt.Errorf("range %d-%d failed after %d retries: %w", c.Start, c.End, opts.Retries, lastErr)
+}
+
+// downloadRange fetches start..end (inclusive) and writes it at the same
+// offset in out. It returns how many bytes were written, even on error.
+func downloadRange(ctx context.Context, url string, out *os.File, start, end int64, opts *Options, onWritten func(written int64)) (int64, error) {
+	req, err := newRequest(ctx, "GET", url, opts)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+
+	resp, err := opts.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		// A full response to a ranged request would write the wrong bytes here
+		return 0, ErrRangeIgnored
+	}
+	if err := checkRateLimited(resp); err != nil {
+		return 0, err
+	}
+	if resp.StatusCode != http.StatusPartialContent {
+		return 0, &StatusError{Status: resp.StatusCode, Detail: fmt.Sprintf("for range %d-%d", start, end)}
+	}
+	if rangeStart, _ := parseContentRange(resp.Header.Get("Content-Range")); rangeStart != start {
+		return 0, fmt.Errorf("asked for bytes from %d, got %q", start, resp.Header.Get("Content-Range"))
+	}
+
+	buf := make([]byte, 256*1024)
+	pos := start
+	var written int64
+	for {
+		n, err := resp.Body.Read(buf)
+		if n > 0 {
+			// Never write past the end of this range
+			if pos+int64(n) > end+1 {
+				n = int(end + 1 - pos)
+			}
+			if _, writeErr := out.WriteAt(buf[:n], pos); writeErr != nil {
+				return written, writeErr
+			}
+			pos += int64(n)
+			written += int64(n)
+			onWritten(written)
+			if pos > end {
+				return written, nil
+			}
+			if err := opts.Limiter.Wait(ctx, n); err != nil {
+				return written, err
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+	if pos <= end {
+		return written, fmt.Errorf("range %d-%d ended early at %d", start, end, pos)
+	}
+	return written, nil
+}
@@ -0,0 +1,71 @@
+package download
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ParseProxy checks a proxy URL from a flag or a setting. A bare
+// "host:port" is an HTTP proxy; http, https and socks5 proxies are supported,
+// with credentials given as user:password@ (percent-encoding any special
+// characters).
+func ParseProxy(proxy string) (*url.URL, error) {
+	proxy = strings.TrimSpace(proxy)
+	if !strings.Contains(proxy, "://") {
+		proxy = "http://" + proxy
+	}
+	u, err := url.Parse(proxy)
+	if err != nil {
+		// url.Error repeats the URL, password included
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("invalid proxy URL: %v", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q (use http, https or socks5)", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return nil, errors.New("proxy URL has no host")
+	}
+	return u, nil
+}
+
+// ProxyFunc returns the Proxy for a transport: proxy if it's set, otherwise
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY from the environment
+func ProxyFunc(proxy string) (func(*http.Request) (*url.URL, error), error) {
+	if strings.TrimSpace(proxy) == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+	u, err := ParseProxy(proxy)
+	if err != nil {
+		return nil, err
+	}
+	return http.ProxyURL(u), nil
+}
+
+// NewTransport returns the transport downloads use by default, tuned for
+// large files and going through proxy (see ProxyFunc)
+func NewTransport(proxy string) (*http.Transport, error) {
+	proxyFunc, err := ProxyFunc(proxy)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Transport{
+		Proxy:               proxyFunc,
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 100,
+		IdleConnTimeout:     90 * time.Second,
+		WriteBufferSize:     1024 * 1024,
+		ReadBufferSize:      1024 * 1024,
+		DisableCompression:  true,
+		ForceAttemptHTTP2:   true,
+	}, nil
+}